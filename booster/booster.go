@@ -1,6 +1,7 @@
 package booster
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -22,11 +23,11 @@ type Booster struct {
 
 // GenerateBoosters generates and returns 6 booster packs.
 // For Commander Legends, there is an additional "booster pack" containing only two Prismatic Pipers
-func GenerateBoosters(boosterType string, sets []string) ([]Booster, error) {
+func GenerateBoosters(ctx context.Context, boosterType string, sets []string) ([]Booster, error) {
 	boosters := []Booster{}
 
 	for _, set := range sets {
-		booster, err := GenerateBooster(boosterType, set)
+		booster, err := GenerateBooster(ctx, boosterType, set)
 		if err != nil {
 			return nil, err
 		}
@@ -34,7 +35,7 @@ func GenerateBoosters(boosterType string, sets []string) ([]Booster, error) {
 	}
 
 	if boosterType == Commander {
-		booster, err := GenerateBoosterWithOnlyPrismaticPiper("Commander Legends")
+		booster, err := GenerateBoosterWithOnlyPrismaticPiper(ctx, "Commander Legends")
 		if err != nil {
 			return nil, err
 		}
@@ -45,9 +46,9 @@ func GenerateBoosters(boosterType string, sets []string) ([]Booster, error) {
 }
 
 // GenerateBooster generates and returns a booster pack.
-func GenerateBooster(boosterType string, set string) (Booster, error) {
+func GenerateBooster(ctx context.Context, boosterType string, set string) (Booster, error) {
 
-	cards, err := getCards(set)
+	cards, err := getCards(ctx, set)
 	if err != nil {
 		return Booster{}, err
 	}
@@ -67,15 +68,62 @@ func GenerateBooster(boosterType string, set string) (Booster, error) {
 	}
 }
 
-func getCards(set string) ([]*db.Card, error) {
+// getCards returns the cards printed in the set, once per rarity, with the rarity and image of their printing in it.
+// Only the printings found in boosters are used, if the set has any. Without imported printings, the cards with the set are used.
+func getCards(ctx context.Context, set string) ([]*db.Card, error) {
+	collection := db.GetCardStore()
 
-	collection, err := db.GetCardCollection()
+	printings, err := db.GetPrintingStore().GetPrintingsBySet(ctx, set)
 	if err != nil {
 		return nil, err
 	}
-	defer collection.Disconnect()
+	if len(printings) == 0 {
+		return collection.GetCardsBySetName(ctx, set)
+	}
+
+	boosterPrintings := []*db.Printing{}
+	for _, printing := range printings {
+		if printing.InBoosters {
+			boosterPrintings = append(boosterPrintings, printing)
+		}
+	}
+	if len(boosterPrintings) > 0 {
+		printings = boosterPrintings
+	}
 
-	return collection.GetCardsBySetName(set)
+	oracleIDs := []string{}
+	for _, printing := range printings {
+		oracleIDs = append(oracleIDs, printing.OracleID)
+	}
+	cards, err := collection.GetCardsByOracleIDs(ctx, oracleIDs)
+	if err != nil {
+		return nil, err
+	}
+	cardsByOracleID := map[string]*db.Card{}
+	for _, card := range cards {
+		cardsByOracleID[card.OracleID] = card
+	}
+
+	// Alternate arts like showcase or borderless printings would add a card several times to the pool,
+	// only the first printing of a card with a rarity is used
+	printedCards := []*db.Card{}
+	used := map[string]bool{}
+	for _, printing := range printings {
+		card, ok := cardsByOracleID[printing.OracleID]
+		key := printing.OracleID + "|" + printing.Rarity
+		if !ok || used[key] {
+			continue
+		}
+		used[key] = true
+		printedCard := *card
+		printedCard.SetName = printing.SetName
+		printedCard.Rarity = printing.Rarity
+		if imageURL, ok := printing.ImageURLs["normal"]; ok {
+			printedCard.ImageURLs = map[string]string{"normal": imageURL}
+		}
+		printedCards = append(printedCards, &printedCard)
+	}
+	return printedCards, nil
 }
 
 func generateCommanderBooster(cards []*db.Card, set string) Booster {
@@ -111,8 +159,8 @@ func generateNormalBooster(cards []*db.Card, set string) Booster {
 }
 
 // GenerateBoosterWithOnlyPrismaticPiper generates and returns a "booster pack" containing only two Prismatic Pipers
-func GenerateBoosterWithOnlyPrismaticPiper(set string) (Booster, error) {
-	cards, err := getCards(set)
+func GenerateBoosterWithOnlyPrismaticPiper(ctx context.Context, set string) (Booster, error) {
+	cards, err := getCards(ctx, set)
 	if err != nil {
 		return Booster{}, err
 	}
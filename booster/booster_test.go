@@ -0,0 +1,34 @@
+package booster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/maedu/mtg-cards/card/db"
+)
+
+func TestGetCardsUsesOnePrintingPerCardAndRarity(t *testing.T) {
+	db.UseCardStore(db.NewMemoryCardStore(
+		&db.Card{Name: "Sol Ring", OracleID: "sol-ring"},
+		&db.Card{Name: "Llanowar Elves", OracleID: "llanowar-elves"},
+	))
+	db.UsePrintingStore(db.NewMemoryPrintingStore(
+		&db.Printing{ScryfallID: "1", OracleID: "sol-ring", SetCode: "tst", CollectorNumber: "1", Rarity: "uncommon", InBoosters: true},
+		&db.Printing{ScryfallID: "2", OracleID: "sol-ring", SetCode: "tst", CollectorNumber: "2", Rarity: "uncommon", InBoosters: true},
+		&db.Printing{ScryfallID: "3", OracleID: "sol-ring", SetCode: "tst", CollectorNumber: "3", Rarity: "mythic", InBoosters: true},
+		&db.Printing{ScryfallID: "4", OracleID: "llanowar-elves", SetCode: "tst", CollectorNumber: "4", Rarity: "common", InBoosters: true},
+	))
+
+	cards, err := getCards(context.Background(), "tst")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got := []string{}
+	for _, card := range cards {
+		got = append(got, card.Name+" "+card.Rarity)
+	}
+	if fmt.Sprint(got) != "[Sol Ring uncommon Sol Ring mythic Llanowar Elves common]" {
+		t.Errorf("getCards() = %v", got)
+	}
+}
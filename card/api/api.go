@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -24,27 +26,27 @@ type FindCardsResponse struct {
 
 // Setup Setup REST API
 func Setup(r *gin.Engine) {
-	r.GET("/api/cards", handleGetCards)
+	r.GET("/api/cards", auth.RequireScope(userDB.ReadCollection), handleGetCards)
 	r.GET("/api/cards/set", handleGetSet)
-	r.POST("/api/cards/find", handleFindCards)
-	r.GET("/api/cards/update", handleUpdateCards)
-	r.GET("/api/cards/transform", handleTransformCards)
+	r.GET("/api/cards/autocomplete", auth.RequireScope(userDB.ReadCollection), handleAutocomplete)
+	r.GET("/api/cards/export", auth.RequireScope(userDB.ReadCollection), handleExportCards)
+	r.GET("/api/cards/named", auth.RequireScope(userDB.ReadCollection), handleGetNamedCard)
+	r.GET("/api/cards/id/:id", auth.RequireScope(userDB.ReadCollection), handleGetCard)
+	r.POST("/api/cards/find", auth.RequireScope(userDB.ReadCollection), handleFindCards)
+	r.POST("/api/cards/update", auth.RequireRole(userDB.AdminRole), handleUpdateCards)
+	r.POST("/api/cards/transform", auth.RequireRole(userDB.AdminRole), handleTransformCards)
 
 }
 
 func handleGetSet(c *gin.Context) {
+	ctx := c.Request.Context()
 
 	var err error
 
 	set := c.Query("set")
-	collection, err := db.GetCardCollection()
-	if err != nil {
-		c.Error(err)
-		return
-	}
-	defer collection.Disconnect()
+	collection := db.GetCardStore()
 
-	loadedCards, err := collection.GetCardsBySetName(set)
+	loadedCards, err := collection.GetCardsBySetName(ctx, set)
 	if err != nil {
 		c.Error(err)
 		return
@@ -59,6 +61,7 @@ func handleGetSet(c *gin.Context) {
 }
 
 func handleGetCards(c *gin.Context) {
+	ctx := c.Request.Context()
 
 	var err error
 
@@ -81,14 +84,68 @@ func handleGetCards(c *gin.Context) {
 		}
 	}
 
-	collection, err := db.GetCardCollection()
+	request, ok := getCardSearchRequest(c)
+	if !ok {
+		return
+	}
+	// With a cursor, the page after it is returned instead of the page with the number
+	if token, ok := c.GetQuery("cursor"); ok {
+		request.Cursor = &db.Cursor{}
+		if token != "" {
+			request.Cursor, err = db.DecodeCursor(token, request)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+	}
+
+	loadedCards, err := searchCards(ctx, perPage, page, request)
 	if err != nil {
 		c.Error(err)
 		return
 	}
-	defer collection.Disconnect()
+	setUserQuantityOnCards(c, loadedCards.Cards)
+	db.AddMainCardSynergies(loadedCards.Cards, request)
+
+	c.JSON(http.StatusOK, loadedCards)
+}
+
+// getSynergySpec returns the main cards given with mainCardForSynergy, e.g. partner commanders, and how their synergies
+// are combined with synergyMode and one synergyWeight per main card for the weighted mode
+func getSynergySpec(c *gin.Context) (db.SynergySpec, error) {
+	mainCards := []string{}
+	for _, mainCard := range c.QueryArray("mainCardForSynergy") {
+		if mainCard != "" {
+			mainCards = append(mainCards, mainCard)
+		}
+	}
+	weights := []float64{}
+	for _, weightText := range c.QueryArray("synergyWeight") {
+		weight, err := strconv.ParseFloat(weightText, 64)
+		if err != nil {
+			return db.SynergySpec{}, fmt.Errorf("invalid synergy weight %q", weightText)
+		}
+		weights = append(weights, weight)
+	}
+	return db.NewSynergySpec(mainCards, c.Query("synergyMode"), weights)
+}
+
+// getCardSearchRequest returns the search of the query parameters of /api/cards, if they are invalid the error is sent and false returned
+func getCardSearchRequest(c *gin.Context) (db.CardSearchRequest, bool) {
+	var err error
 
 	text := c.Query("text")
+	query, err := db.ParseQuery(c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return db.CardSearchRequest{}, false
+	}
+	colorIdentity, err := getColorIdentity(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return db.CardSearchRequest{}, false
+	}
 	cmcText := c.QueryArray("cmc")
 	cmc := []float64{}
 	if cmcText != nil {
@@ -96,7 +153,7 @@ func handleGetCards(c *gin.Context) {
 			result, err := strconv.ParseFloat(item, 0)
 			if err != nil {
 				c.Error(err)
-				return
+				return db.CardSearchRequest{}, false
 			}
 			cmc = append(cmc, result)
 		}
@@ -104,7 +161,15 @@ func handleGetCards(c *gin.Context) {
 	colors := c.QueryArray("colors")
 	cardGroups := c.QueryArray("cardGroups")
 
-	mainCardForSynergy := c.Query("mainCardForSynergy")
+	synergy, err := getSynergySpec(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return db.CardSearchRequest{}, false
+	}
+	mainCardForSynergy := ""
+	if len(synergy.MainCards) > 0 {
+		mainCardForSynergy = synergy.MainCards[0]
+	}
 	searchRelatedToMainCard := c.Query("searchRelatedToMainCard") == "true"
 
 	priceMinString := c.Query("priceMin")
@@ -113,7 +178,7 @@ func handleGetCards(c *gin.Context) {
 		priceMin, err = strconv.ParseFloat(priceMinString, 0)
 		if err != nil {
 			c.Error(err)
-			return
+			return db.CardSearchRequest{}, false
 		}
 	}
 	priceMaxString := c.Query("priceMax")
@@ -122,63 +187,114 @@ func handleGetCards(c *gin.Context) {
 		priceMax, err = strconv.ParseFloat(priceMaxString, 0)
 		if err != nil {
 			c.Error(err)
-			return
+			return db.CardSearchRequest{}, false
 		}
 	}
 
-	sortBy := c.Query("sortBy")
-	sortDir := c.Query("sortDir")
+	sortBy := strings.ToLower(c.Query("sortBy"))
+	if sortBy != "" && !db.IsSortByField(sortBy) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("can't sort by %q with sortBy", sortBy))
+		return db.CardSearchRequest{}, false
+	}
+	sortDir := strings.ToLower(c.Query("sortDir"))
+	sort, err := db.ParseSort(c.Query("sort"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return db.CardSearchRequest{}, false
+	}
 
-	useSearchWithUserCards := false
-	for _, cardGroup := range cardGroups {
-		if cardGroup == "Collected" {
-			useSearchWithUserCards = true
-			break
-		}
+	legalIn := strings.ToLower(c.DefaultQuery("legalIn", db.DefaultFormat))
+	if legalIn == db.AllFormats {
+		legalIn = ""
+	} else if !db.IsFormat(legalIn) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unknown format %s", legalIn))
+		return db.CardSearchRequest{}, false
 	}
 
 	userID, _ := auth.GetUserIDFromAccessToken(c, false)
 
-	request := db.CardSearchRequest{
+	return db.CardSearchRequest{
 		Text:                    text,
 		Cmc:                     cmc,
 		Colors:                  colors,
 		CardGroups:              cardGroups,
 		MainCardForSynergy:      mainCardForSynergy,
+		Synergy:                 synergy,
 		SearchRelatedToMainCard: searchRelatedToMainCard,
 		PriceMin:                priceMin,
 		PriceMax:                priceMax,
 		SortBy:                  sortBy,
 		SortDir:                 sortDir,
 		UserID:                  userID,
-	}
+		Query:                   query,
+		ColorIdentity:           colorIdentity,
+		WithFacets:              c.Query("facets") == "true",
+		Sort:                    sort,
+		LegalIn:                 legalIn,
+	}, true
+}
 
-	var loadedCards db.PaginatedResult
-	if useSearchWithUserCards {
-		loadedCards, err = collection.GetCollectedCardsPaginated(perPage, page, request)
+// searchCards returns the page of the cards matching the request, searching in the collection of the user if the group Collected is selected
+func searchCards(ctx context.Context, limit int64, page int64, request db.CardSearchRequest) (db.PaginatedResult, error) {
+	collection := db.GetCardStore()
+	for _, cardGroup := range request.CardGroups {
+		if cardGroup == "Collected" {
+			return collection.GetCollectedCardsPaginated(ctx, limit, page, request)
+		}
+	}
+	return collection.GetCardsPaginated(ctx, limit, page, request)
+}
 
-	} else {
-		loadedCards, err = collection.GetCardsPaginated(perPage, page, request)
+// getColorIdentity returns the color identity filter of the request, either the identity given with identity
+// and identityMode, or the combined identity of up to two commanders given with commander, e.g. partners.
+func getColorIdentity(c *gin.Context) (*db.Query, error) {
+	identity, hasIdentity := c.GetQueryArray("identity")
+	commanders := c.QueryArray("commander")
+	if len(commanders) == 0 {
+		if !hasIdentity {
+			return nil, nil
+		}
+		// identity= without colors is the colorless identity
+		colors := []string{}
+		for _, color := range identity {
+			if color != "" {
+				colors = append(colors, color)
+			}
+		}
+		return db.ColorIdentityQuery(colors, c.Query("identityMode"))
 	}
 
+	if hasIdentity {
+		return nil, errors.New("identity and commander can't be combined")
+	}
+	if len(commanders) > 2 {
+		return nil, errors.New("at most two commanders are allowed")
+	}
+	cards, err := db.GetCardStore().GetCardsByNames(c.Request.Context(), commanders)
 	if err != nil {
-		c.Error(err)
-		return
+		return nil, err
 	}
-	setUserQuantityOnCards(c, loadedCards.Cards)
-
-	c.JSON(http.StatusOK, loadedCards)
+	colors := []string{}
+	for _, commander := range commanders {
+		card := findCardByName(cards, commander)
+		if card == nil {
+			return nil, fmt.Errorf("commander %s not found", commander)
+		}
+		for _, color := range card.ColorIdentity {
+			if !containsString(colors, color) {
+				colors = append(colors, color)
+			}
+		}
+	}
+	return db.ColorIdentityQuery(colors, db.IdentitySubset)
 }
 
 func setUserQuantityOnCards(c *gin.Context, cards []*db.Card) error {
+	ctx := c.Request.Context()
 	if userID, ok := auth.GetUserIDFromAccessToken(c, false); ok {
-		userCardCollection, err := userDB.GetUserCardCollection()
-		if err != nil {
-			return fmt.Errorf("getting userCardCollection failed: %w", err)
-		}
-		defer userCardCollection.Disconnect()
+		userCardCollection := userDB.GetUserCardStore()
 
-		userCards, err := userCardCollection.GetUserCardsByUserID(userID)
+		userCards, err := userCardCollection.GetUserCardsByUserID(ctx, userID)
 		if err != nil {
 			return fmt.Errorf("getting cards by userID failed: %w", err)
 		}
@@ -207,6 +323,7 @@ func setUserQuantityOnCards(c *gin.Context, cards []*db.Card) error {
 }
 
 func handleFindCards(c *gin.Context) {
+	ctx := c.Request.Context()
 
 	var request FindCardsRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -215,12 +332,7 @@ func handleFindCards(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
-	collection, err := db.GetCardCollection()
-	if err != nil {
-		c.Error(err)
-		return
-	}
-	defer collection.Disconnect()
+	collection := db.GetCardStore()
 
 	cards := map[string]*db.Card{}
 
@@ -230,7 +342,7 @@ func handleFindCards(c *gin.Context) {
 	for _, name := range request.Cards {
 		cards[name] = nil
 	}
-	foundCards, err := collection.GetCardsByNames(cardsToFind)
+	foundCards, err := collection.GetCardsByNames(ctx, cardsToFind)
 	if err != nil {
 		c.Error(err)
 		return
@@ -243,7 +355,7 @@ func handleFindCards(c *gin.Context) {
 
 	sets := map[string][]*db.Card{}
 	for _, name := range request.Sets {
-		cards, err := collection.GetCardsBySetName(name)
+		cards, err := collection.GetCardsBySetName(ctx, name)
 		if err != nil {
 			c.Error(err)
 			return
@@ -259,3 +371,27 @@ func handleFindCards(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// findCardByName returns the card with the name, or with a card face with the name
+func findCardByName(cards []*db.Card, name string) *db.Card {
+	for _, card := range cards {
+		if card.Name == name {
+			return card
+		}
+		for _, cardFace := range card.CardFaces {
+			if cardFace.Name == name {
+				return card
+			}
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
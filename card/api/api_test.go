@@ -0,0 +1,392 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/maedu/mtg-cards/card/db"
+	"github.com/maedu/mtg-cards/server"
+)
+
+func setupMemoryCards() {
+	db.UseCardStore(db.NewMemoryCardStore(
+		&db.Card{Name: "Sol Ring", Cmc: 1, Colors: []string{"C"}, CardGroups: []string{"Artifact", "Ramp"}, OracleText: "{T}: Add {C}{C}.", Price: 1.5},
+		&db.Card{Name: "Llanowar Elves", Cmc: 1, Colors: []string{"G"}, CardGroups: []string{"Creature", "Ramp"}, OracleText: "{T}: Add {G}.", Price: 0.2},
+		&db.Card{Name: "Harmonize", Cmc: 4, Colors: []string{"G"}, CardGroups: []string{"Sorcery", "Draw"}, OracleText: "Draw three cards.", Price: 0.3},
+		&db.Card{Name: "Forest", Cmc: 0, Colors: []string{"C"}, CardGroups: []string{"Land"}, IsLand: true},
+	))
+}
+
+func getCards(t *testing.T, ts *httptest.Server, query string) db.PaginatedResult {
+	res, err := http.Get(fmt.Sprintf("%s/api/cards?%s", ts.URL, query))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("bad status: %s", res.Status)
+	}
+
+	var result db.PaginatedResult
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return result
+}
+
+func cardNames(cards []*db.Card) []string {
+	names := []string{}
+	for _, card := range cards {
+		names = append(names, card.Name)
+	}
+	return names
+}
+
+func TestHandleGetCards(t *testing.T) {
+	setupMemoryCards()
+	server := server.Configure()
+	Setup(server)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "Sorted by name, lands last",
+			query: "sortBy=name",
+			want:  []string{"Harmonize", "Llanowar Elves", "Sol Ring", "Forest"},
+		},
+		{
+			name:  "Text",
+			query: "text=draw",
+			want:  []string{"Harmonize"},
+		},
+		{
+			name:  "Colors and card groups",
+			query: "colors=G&cardGroups=Ramp",
+			want:  []string{"Llanowar Elves"},
+		},
+		{
+			name:  "Price",
+			query: "priceMin=1&sortBy=name",
+			want:  []string{"Sol Ring"},
+		},
+		{
+			name:  "Query",
+			query: "q=" + url.QueryEscape(`o:add -c:g cmc<=1`),
+			want:  []string{"Sol Ring"},
+		},
+		{
+			name:  "Paginated",
+			query: "sortBy=cmc&sortDir=desc&perPage=2&page=2",
+			want:  []string{"Llanowar Elves", "Forest"},
+		},
+		{
+			name:  "Sort by is case-insensitive",
+			query: "sortBy=CMC&sortDir=DESC&perPage=2&page=2",
+			want:  []string{"Llanowar Elves", "Forest"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cardNames(getCards(t, ts, tt.query).Cards)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("handleGetCards() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleFindCards(t *testing.T) {
+	setupMemoryCards()
+	server := server.Configure()
+	Setup(server)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	body, _ := json.Marshal(FindCardsRequest{Cards: []string{"Sol Ring", "Black Lotus"}})
+	res, err := http.Post(fmt.Sprintf("%s/api/cards/find", ts.URL), "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer res.Body.Close()
+
+	var response FindCardsResponse
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.Cards["Sol Ring"] == nil || response.Cards["Sol Ring"].Name != "Sol Ring" {
+		t.Errorf("Expected Sol Ring to be found, got %v", response.Cards["Sol Ring"])
+	}
+	if card, ok := response.Cards["Black Lotus"]; !ok || card != nil {
+		t.Errorf("Expected Black Lotus to be nil, got %v", card)
+	}
+}
+
+func TestHandleGetCardsInvalidQuery(t *testing.T) {
+	setupMemoryCards()
+	server := server.Configure()
+	Setup(server)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	res, err := http.Get(fmt.Sprintf("%s/api/cards?q=%s", ts.URL, url.QueryEscape("cmc>=x")))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %s", http.StatusBadRequest, res.Status)
+	}
+
+	var message string
+	if err := json.NewDecoder(res.Body).Decode(&message); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if message != `cmc needs a number, got "x" at position 1` {
+		t.Errorf("unexpected error %q", message)
+	}
+}
+
+func TestHandleGetCardsColorIdentity(t *testing.T) {
+	db.UseCardStore(db.NewMemoryCardStore(
+		&db.Card{Name: "Sol Ring", Colors: []string{"C"}},
+		&db.Card{Name: "Llanowar Elves", Colors: []string{"G"}, ColorIdentity: []string{"G"}},
+		&db.Card{Name: "Kitchen Finks", Colors: []string{"G", "W"}, ColorIdentity: []string{"G", "W"}},
+		&db.Card{Name: "Birds of Paradise", Colors: []string{"G"}, ColorIdentity: []string{"G"}},
+		&db.Card{Name: "Deathrite Shaman", Colors: []string{"B", "G"}, ColorIdentity: []string{"B", "G"}},
+		&db.Card{Name: "Tymna the Weaver", Colors: []string{"W", "B"}, ColorIdentity: []string{"W", "B"}, IsCommander: true},
+		&db.Card{Name: "Thrasios, Triton Hero", Colors: []string{"G", "U"}, ColorIdentity: []string{"G", "U"}, IsCommander: true},
+	))
+	server := server.Configure()
+	Setup(server)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "Subset",
+			query: "identity=G&identity=W&sortBy=name",
+			want:  []string{"Birds of Paradise", "Kitchen Finks", "Llanowar Elves", "Sol Ring"},
+		},
+		{
+			name:  "Exact",
+			query: "identity=G&identity=W&identityMode=exact",
+			want:  []string{"Kitchen Finks"},
+		},
+		{
+			name:  "Colorless",
+			query: "identity=",
+			want:  []string{"Sol Ring"},
+		},
+		{
+			name:  "Commander",
+			query: "commander=" + url.QueryEscape("Thrasios, Triton Hero") + "&sortBy=name",
+			want:  []string{"Birds of Paradise", "Llanowar Elves", "Sol Ring", "Thrasios, Triton Hero"},
+		},
+		{
+			name:  "Partners",
+			query: "commander=" + url.QueryEscape("Thrasios, Triton Hero") + "&commander=" + url.QueryEscape("Tymna the Weaver") + "&q=-is:commander&sortBy=name",
+			want:  []string{"Birds of Paradise", "Deathrite Shaman", "Kitchen Finks", "Llanowar Elves", "Sol Ring"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cardNames(getCards(t, ts, tt.query).Cards)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("handleGetCards() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, query := range []string{"commander=Unknown", "identity=X", "identity=G&identityMode=superset", "identity=G&commander=" + url.QueryEscape("Tymna the Weaver")} {
+		res, err := http.Get(fmt.Sprintf("%s/api/cards?%s", ts.URL, query))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %s", query, http.StatusBadRequest, res.Status)
+		}
+	}
+}
+
+func TestHandleGetCardsFacets(t *testing.T) {
+	setupMemoryCards()
+	server := server.Configure()
+	Setup(server)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	result := getCards(t, ts, "facets=true&cardGroups=Ramp&perPage=1")
+	if len(result.Cards) != 1 {
+		t.Errorf("expected 1 card on the page, got %d", len(result.Cards))
+	}
+	want := map[string]string{
+		db.ColorFacet:     "[{C 1} {G 1}]",
+		db.CmcFacet:       "[{1 2}]",
+		db.CardGroupFacet: "[{Ramp 2} {Artifact 1} {Creature 1}]",
+		db.RarityFacet:    "[]",
+		db.SetFacet:       "[]",
+	}
+	for facet, counts := range want {
+		if got := fmt.Sprint(result.Facets[facet]); got != counts {
+			t.Errorf("facet %s = %s, want %s", facet, got, counts)
+		}
+	}
+
+	if result := getCards(t, ts, "cardGroups=Ramp"); result.Facets != nil {
+		t.Errorf("expected no facets, got %v", result.Facets)
+	}
+}
+
+func TestHandleGetCardsCursor(t *testing.T) {
+	setupMemoryCards()
+	server := server.Configure()
+	Setup(server)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	sort := url.QueryEscape("cmc desc, price")
+	names := []string{}
+	query := "sort=" + sort + "&perPage=1&cursor="
+	for i := 0; i < 10; i++ {
+		result := getCards(t, ts, query)
+		names = append(names, cardNames(result.Cards)...)
+		if result.NextCursor == "" {
+			break
+		}
+		// A card added while paging shows up on a later page, without repeating or skipping other cards
+		if i == 0 {
+			db.GetCardStore().Create(context.Background(), &db.Card{Name: "Mana Crypt", Cmc: 0, Colors: []string{"C"}, Price: 150})
+		}
+		query = "sort=" + sort + "&perPage=1&cursor=" + result.NextCursor
+	}
+	want := []string{"Harmonize", "Llanowar Elves", "Sol Ring", "Mana Crypt", "Forest"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Errorf("paged cards = %v, want %v", names, want)
+	}
+
+	first := getCards(t, ts, "sort="+sort+"&perPage=2&page=1")
+	if first.NextCursor == "" {
+		t.Fatalf("Expected a next cursor for the first page")
+	}
+	for _, query := range []string{"cursor=invalid", "sort=name&cursor=" + first.NextCursor, "sort=color", "sortBy=color", "sortBy=score", "sortBy=relevance"} {
+		res, err := http.Get(fmt.Sprintf("%s/api/cards?%s", ts.URL, query))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %s", query, http.StatusBadRequest, res.Status)
+		}
+	}
+}
+
+func TestHandleGetCardsSynergyWithMainCards(t *testing.T) {
+	db.UseCardStore(db.NewMemoryCardStore(
+		&db.Card{Name: "Esper Sentinel", Synergies: map[string]float64{"Tymna the Weaver": 0.6}},
+		&db.Card{Name: "Mystic Remora", Synergies: map[string]float64{"Tymna the Weaver": 0.3, "Thrasios, Triton Hero": 0.4}},
+		&db.Card{Name: "Tireless Tracker", Synergies: map[string]float64{"Thrasios, Triton Hero": 0.1}},
+		&db.Card{Name: "Sol Ring", Synergies: map[string]float64{}},
+	))
+	server := server.Configure()
+	Setup(server)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	mainCards := "mainCardForSynergy=" + url.QueryEscape("Tymna the Weaver") + "&mainCardForSynergy=" + url.QueryEscape("Thrasios, Triton Hero")
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"Max", "sort=synergy+desc&searchRelatedToMainCard=true", []string{"Esper Sentinel", "Mystic Remora", "Tireless Tracker"}},
+		{"Average", "sort=synergy+desc&synergyMode=avg&searchRelatedToMainCard=true", []string{"Mystic Remora", "Esper Sentinel", "Tireless Tracker"}},
+		{"Weighted", "sort=synergy+desc&synergyMode=weighted&synergyWeight=1&synergyWeight=3&searchRelatedToMainCard=true", []string{"Mystic Remora", "Esper Sentinel", "Tireless Tracker"}},
+		{"Synergy group", "cardGroups=Synergy&synergyMode=avg&sort=name", []string{"Esper Sentinel", "Mystic Remora"}},
+		{"Missing synergy last", "sort=synergy+desc", []string{"Esper Sentinel", "Mystic Remora", "Tireless Tracker", "Sol Ring"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := getCards(t, ts, mainCards+"&"+tt.query)
+			if got := cardNames(result.Cards); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("cards = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	result := getCards(t, ts, mainCards+"&synergyMode=avg&text=remora")
+	if len(result.Cards) != 1 {
+		t.Fatalf("expected 1 card, got %v", cardNames(result.Cards))
+	}
+	card := result.Cards[0]
+	if fmt.Sprint(card.MainCardSynergies) != "map[Thrasios, Triton Hero:0.4 Tymna the Weaver:0.3]" || card.Synergy == nil || *card.Synergy != 0.35 {
+		t.Errorf("unexpected synergies %v, combined %v", card.MainCardSynergies, card.Synergy)
+	}
+
+	for _, query := range []string{"synergyMode=sum", "synergyMode=weighted&synergyWeight=1", "synergyMode=weighted&synergyWeight=a&synergyWeight=1"} {
+		res, err := http.Get(fmt.Sprintf("%s/api/cards?%s&%s", ts.URL, mainCards, query))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %s", query, http.StatusBadRequest, res.Status)
+		}
+	}
+}
+
+func TestHandleGetCardsLegalIn(t *testing.T) {
+	db.UseCardStore(db.NewMemoryCardStore(
+		&db.Card{Name: "Sol Ring", Legalities: map[string]string{"commander": db.Legal, "vintage": db.Restricted, "modern": db.NotLegal}},
+		&db.Card{Name: "Black Lotus", Legalities: map[string]string{"commander": db.Banned, "vintage": db.Restricted, "modern": db.NotLegal}},
+		&db.Card{Name: "Ragavan, Nimble Pilferer", Legalities: map[string]string{"commander": db.Legal, "vintage": db.Legal, "modern": db.Banned}},
+		&db.Card{Name: "Harmonize"},
+	))
+	server := server.Configure()
+	Setup(server)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"Commander by default", "sort=name", []string{"Harmonize", "Ragavan, Nimble Pilferer", "Sol Ring"}},
+		{"Vintage with restricted cards", "legalIn=vintage&sort=name", []string{"Black Lotus", "Ragavan, Nimble Pilferer", "Sol Ring"}},
+		{"Modern", "legalIn=Modern", []string{}},
+		{"All formats", "legalIn=all&q=" + url.QueryEscape("banned:commander or banned:modern") + "&sort=name", []string{"Black Lotus", "Ragavan, Nimble Pilferer"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := getCards(t, ts, tt.query)
+			if got := cardNames(result.Cards); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("cards = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	res, err := http.Get(fmt.Sprintf("%s/api/cards?legalIn=casual", ts.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %s", http.StatusBadRequest, res.Status)
+	}
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maedu/mtg-cards/card/db"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	defaultAutocompleteLimit = 10
+	maxAutocompleteLimit     = 50
+
+	// nameIndexMaxAge is how long the names are kept, cards transformed by another server are seen after it
+	nameIndexMaxAge = time.Hour
+)
+
+// AutocompleteResult is a card matching the typed name, Face is set if a face of a split or double-faced card matched
+type AutocompleteResult struct {
+	Name  string `json:"name"`
+	Face  string `json:"face,omitempty"`
+	Fuzzy bool   `json:"fuzzy"`
+
+	rank int
+}
+
+type nameEntry struct {
+	name       string
+	face       string
+	normalized []rune
+}
+
+// nameIndex contains the normalized names of all cards and their faces of a store
+type nameIndex struct {
+	store    db.CardStore
+	loadedAt time.Time
+	entries  []nameEntry
+}
+
+var (
+	nameIndexMutex   sync.Mutex
+	currentNameIndex *nameIndex
+)
+
+// getNameIndex returns the names of the cards of the current store, they are loaded again after nameIndexMaxAge
+func getNameIndex(ctx context.Context) (*nameIndex, error) {
+	nameIndexMutex.Lock()
+	defer nameIndexMutex.Unlock()
+
+	store := db.GetCardStore()
+	if currentNameIndex != nil && currentNameIndex.store == store && time.Since(currentNameIndex.loadedAt) < nameIndexMaxAge {
+		return currentNameIndex, nil
+	}
+
+	cards, err := store.GetCardNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	index := &nameIndex{store: store, loadedAt: time.Now()}
+	for _, card := range cards {
+		index.entries = append(index.entries, nameEntry{name: card.Name, normalized: []rune(normalizeName(card.Name))})
+		for _, cardFace := range card.CardFaces {
+			if cardFace.Name != "" && cardFace.Name != card.Name {
+				index.entries = append(index.entries, nameEntry{name: card.Name, face: cardFace.Name, normalized: []rune(normalizeName(cardFace.Name))})
+			}
+		}
+	}
+	currentNameIndex = index
+	return index, nil
+}
+
+// resetNameIndex drops the names, so they are loaded again with the next request, e.g. after the cards were replaced
+func resetNameIndex() {
+	nameIndexMutex.Lock()
+	defer nameIndexMutex.Unlock()
+	currentNameIndex = nil
+}
+
+var removeMarks = runes.Remove(runes.In(unicode.Mn))
+
+// normalizeName returns the name in lower case without diacritics and punctuation, e.g. "Lim-Dûl's Vault" is "lim duls vault"
+func normalizeName(name string) string {
+	withoutMarks, _, err := transform.String(transform.Chain(norm.NFD, removeMarks, norm.NFC), name)
+	if err == nil {
+		name = withoutMarks
+	}
+
+	var normalized strings.Builder
+	for _, char := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(char) || unicode.IsDigit(char):
+			normalized.WriteRune(char)
+		case char == '\'' || char == '’':
+			// "Urza's" is typed as "urzas" as often as "urza s"
+		default:
+			normalized.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(normalized.String()), " ")
+}
+
+// autocomplete returns the card with the text as name, the cards whose name starts with the text, then the ones with a word starting with it,
+// and then the ones whose name is similar, allowing more typos for longer texts
+func (index *nameIndex) autocomplete(text string, limit int) []AutocompleteResult {
+	query := []rune(normalizeName(text))
+	if len(query) == 0 {
+		return []AutocompleteResult{}
+	}
+	maxTypos := 0
+	if len(query) >= 8 {
+		maxTypos = 2
+	} else if len(query) >= 4 {
+		maxTypos = 1
+	}
+
+	bestByName := map[string]AutocompleteResult{}
+	for _, entry := range index.entries {
+		rank := matchRank(entry.normalized, query, maxTypos)
+		if rank < 0 {
+			continue
+		}
+		if best, ok := bestByName[entry.name]; ok && best.rank <= rank {
+			continue
+		}
+		bestByName[entry.name] = AutocompleteResult{Name: entry.name, Face: entry.face, Fuzzy: rank > 2, rank: rank}
+	}
+
+	results := []AutocompleteResult{}
+	for _, result := range bestByName {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].rank != results[j].rank {
+			return results[i].rank < results[j].rank
+		}
+		if len(results[i].Name) != len(results[j].Name) {
+			return len(results[i].Name) < len(results[j].Name)
+		}
+		return results[i].Name < results[j].Name
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// matchRank returns 0 if the name is the query, 1 if the name starts with the query, 2 if a word of it does,
+// 3 + the number of typos for a similar name and -1 if the name does not match
+func matchRank(name []rune, query []rune, maxTypos int) int {
+	if hasPrefix(name, query) {
+		if len(name) == len(query) {
+			return 0
+		}
+		return 1
+	}
+	for i, char := range name {
+		if char == ' ' && hasPrefix(name[i+1:], query) {
+			return 2
+		}
+	}
+	if maxTypos == 0 {
+		return -1
+	}
+
+	// Compare the query with the start of the name, which may be longer or shorter than the query because of the typos
+	typos := maxTypos + 1
+	for length := len(query) - maxTypos; length <= len(query)+maxTypos; length++ {
+		if length < 1 || length > len(name) {
+			continue
+		}
+		if distance := levenshtein(name[:length], query); distance < typos {
+			typos = distance
+		}
+	}
+	if typos > maxTypos {
+		return -1
+	}
+	return 3 + typos
+}
+
+func hasPrefix(name []rune, prefix []rune) bool {
+	if len(prefix) > len(name) {
+		return false
+	}
+	for i, char := range prefix {
+		if name[i] != char {
+			return false
+		}
+	}
+	return true
+}
+
+// levenshtein returns the number of inserted, removed or replaced characters to change a into b
+func levenshtein(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
+
+// handleAutocomplete returns the cards matching the typed name in q, at most limit
+func handleAutocomplete(c *gin.Context) {
+	limit := defaultAutocompleteLimit
+	if limitText := c.Query("limit"); limitText != "" {
+		var err error
+		limit, err = strconv.Atoi(limitText)
+		if err != nil || limit < 1 || limit > maxAutocompleteLimit {
+			c.JSON(http.StatusBadRequest, "limit must be between 1 and 50")
+			return
+		}
+	}
+
+	index, err := getNameIndex(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, index.autocomplete(c.Query("q"), limit))
+}
+
+// findCardNameFuzzy returns the name of the card matching the text best, false if none is similar enough
+func findCardNameFuzzy(ctx context.Context, text string) (string, bool, error) {
+	index, err := getNameIndex(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	results := index.autocomplete(text, 1)
+	if len(results) == 0 {
+		return "", false, nil
+	}
+	return results[0].Name, true, nil
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/maedu/mtg-cards/card/db"
+	edhrecDB "github.com/maedu/mtg-cards/edhrec/db"
+	"github.com/maedu/mtg-cards/server"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := map[string]string{
+		"Lim-Dûl's Vault":             "lim duls vault",
+		"Junún Efreet":                "junun efreet",
+		"Atraxa, Praetors' Voice":     "atraxa praetors voice",
+		"Fire // Ice":                 "fire ice",
+		"  Jace,   the Mind Sculptor": "jace the mind sculptor",
+	}
+	for name, want := range tests {
+		if got := normalizeName(name); got != want {
+			t.Errorf("normalizeName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestHandleAutocomplete(t *testing.T) {
+	db.UseCardStore(db.NewMemoryCardStore(
+		&db.Card{Name: "Lim-Dûl the Necromancer"},
+		&db.Card{Name: "Lim-Dûl's Vault"},
+		&db.Card{Name: "Junún Efreet"},
+		&db.Card{Name: "Jace, the Mind Sculptor"},
+		&db.Card{Name: "Jace Beleren"},
+		&db.Card{Name: "Mind Stone"},
+		&db.Card{Name: "Fire // Ice", CardFaces: []db.Card{{Name: "Fire"}, {Name: "Ice"}}},
+		&db.Card{Name: "Delver of Secrets // Insectile Aberration", CardFaces: []db.Card{{Name: "Delver of Secrets"}, {Name: "Insectile Aberration"}}},
+	))
+	edhrecDB.UseSynergyStore(edhrecDB.NewMemorySynergyStore())
+	server := server.Configure()
+	Setup(server)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	tests := []struct {
+		name string
+		q    string
+		want string
+	}{
+		{"Prefix", "jace", "[Jace Beleren||false Jace, the Mind Sculptor||false]"},
+		{"Word prefix", "mind", "[Mind Stone||false Jace, the Mind Sculptor||false]"},
+		{"Punctuation and diacritics", "Lim-Dul", "[Lim-Dûl's Vault||false Lim-Dûl the Necromancer||false]"},
+		{"Diacritics", "Junun Efreet", "[Junún Efreet||false]"},
+		{"Typo", "jace belerne", "[Jace Beleren||true]"},
+		{"Face", "insectile", "[Delver of Secrets // Insectile Aberration|Insectile Aberration|false]"},
+		{"Exact face first", "ice", "[Fire // Ice|Ice|false]"},
+		{"Short text without typos", "jcae", "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := http.Get(fmt.Sprintf("%s/api/cards/autocomplete?q=%s", ts.URL, url.QueryEscape(tt.q)))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer res.Body.Close()
+			var results []AutocompleteResult
+			if err := json.NewDecoder(res.Body).Decode(&results); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			got := []string{}
+			for _, result := range results {
+				got = append(got, fmt.Sprintf("%s|%s|%v", result.Name, result.Face, result.Fuzzy))
+			}
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("autocomplete(%q) = %s, want %s", tt.q, fmt.Sprint(got), tt.want)
+			}
+		})
+	}
+
+	res, err := http.Get(fmt.Sprintf("%s/api/cards/named?fuzzy=%s", ts.URL, url.QueryEscape("jace the mind scluptor")))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("bad status: %s", res.Status)
+	}
+	var detail CardDetail
+	if err := json.NewDecoder(res.Body).Decode(&detail); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if detail.Card == nil || detail.Name != "Jace, the Mind Sculptor" {
+		t.Errorf("expected Jace, the Mind Sculptor, got %v", detail.Card)
+	}
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maedu/mtg-cards/card/db"
+	edhrecDB "github.com/maedu/mtg-cards/edhrec/db"
+	"github.com/maedu/mtg-cards/scryfall/client"
+	scryfallDB "github.com/maedu/mtg-cards/scryfall/db"
+	"github.com/maedu/mtg-cards/user/auth"
+	userDB "github.com/maedu/mtg-cards/user/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultTopSynergies = 20
+
+var oracleIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// CardDetail is a card with everything known about it
+type CardDetail struct {
+	*db.Card
+	Printings    []CardPrinting `json:"printings"`
+	Rulings      []CardRuling   `json:"rulings"`
+	TopSynergies []CardSynergy  `json:"topSynergies"`
+	Quantity     int64          `json:"quantity"`
+}
+
+// CardPrinting is a printing of the card in a set
+type CardPrinting struct {
+	ScryfallID      string `json:"scryfallId"`
+	SetCode         string `json:"setCode"`
+	SetName         string `json:"setName"`
+	CollectorNumber string `json:"collectorNumber"`
+	Rarity          string `json:"rarity"`
+	ReleasedAt      string `json:"releasedAt"`
+	ImageURL        string `json:"imageURL"`
+	Price           string `json:"price"`
+}
+
+// CardRuling is a ruling of the card
+type CardRuling struct {
+	Source      string `json:"source"`
+	PublishedAt string `json:"publishedAt"`
+	Comment     string `json:"comment"`
+}
+
+// CardSynergy is the synergy of a card with the card of the CardDetail as main card
+type CardSynergy struct {
+	Name    string  `json:"name"`
+	Synergy float64 `json:"synergy"`
+}
+
+// handleGetCard returns the CardDetail of the card with the ID or the oracle ID
+func handleGetCard(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	var card *db.Card
+	var err error
+	collection := db.GetCardStore()
+	if oracleIDPattern.MatchString(id) {
+		card, err = collection.GetCardByOracleID(ctx, id)
+	} else if objectID, idErr := primitive.ObjectIDFromHex(id); idErr == nil {
+		card, err = collection.GetCardByID(ctx, objectID)
+	}
+	writeCardDetail(c, card, err)
+}
+
+// handleGetNamedCard returns the CardDetail of the card with the name, with fuzzy instead of name of the card with the most similar name
+func handleGetNamedCard(c *gin.Context) {
+	ctx := c.Request.Context()
+	name := c.Query("name")
+
+	var card *db.Card
+	var err error
+	if fuzzy := c.Query("fuzzy"); fuzzy != "" {
+		var found bool
+		name, found, err = findCardNameFuzzy(ctx, fuzzy)
+		if err != nil || !found {
+			writeCardDetail(c, nil, err)
+			return
+		}
+	}
+	cards, err := db.GetCardStore().GetCardsByNames(ctx, []string{name})
+	if err == nil {
+		card = findCardByName(cards, name)
+	}
+	writeCardDetail(c, card, err)
+}
+
+// writeCardDetail responds with the CardDetail of the card, or with the error of finding it
+func writeCardDetail(c *gin.Context, card *db.Card, err error) {
+	ctx := c.Request.Context()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if card == nil {
+		c.JSON(http.StatusNotFound, "Card not found")
+		return
+	}
+
+	topSynergies := defaultTopSynergies
+	if limit := c.Query("synergies"); limit != "" {
+		topSynergies, err = strconv.Atoi(limit)
+		if err != nil || topSynergies < 0 {
+			c.JSON(http.StatusBadRequest, "synergies must be a positive number")
+			return
+		}
+	}
+
+	detail := &CardDetail{
+		Card:      card,
+		Printings: getPrintings(ctx, card),
+		Rulings:   getRulings(ctx, card),
+	}
+	detail.TopSynergies, err = getTopSynergies(ctx, card.Name, topSynergies)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if userID, ok := auth.GetUserIDFromAccessToken(c, false); ok {
+		detail.Quantity, err = getOwnedQuantity(ctx, userID, card.Name)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// getPrintings returns the imported printings, if there are none they are loaded from scryfall, without them if scryfall is not available
+func getPrintings(ctx context.Context, card *db.Card) []CardPrinting {
+	printings := []CardPrinting{}
+	if card.OracleID == "" {
+		return printings
+	}
+	storedPrintings, err := db.GetPrintingStore().GetPrintingsByOracleID(ctx, card.OracleID)
+	if err != nil {
+		log.Printf("Loading printings of %s failed: %v", card.Name, err)
+	}
+	if len(storedPrintings) > 0 {
+		for _, printing := range storedPrintings {
+			price := ""
+			if usd, ok := printing.Prices[string(scryfallDB.USD)]; ok {
+				price = strconv.FormatFloat(usd, 'f', 2, 64)
+			}
+			printings = append(printings, CardPrinting{
+				ScryfallID:      printing.ScryfallID,
+				SetCode:         printing.SetCode,
+				SetName:         printing.SetName,
+				CollectorNumber: printing.CollectorNumber,
+				Rarity:          printing.Rarity,
+				ReleasedAt:      printing.ReleasedAt,
+				ImageURL:        printing.ImageURLs[scryfallDB.Normal],
+				Price:           price,
+			})
+		}
+		return printings
+	}
+
+	scryfallPrintings, err := client.GetPrintings(ctx, card.OracleID)
+	if err != nil {
+		log.Printf("Loading printings of %s failed: %v", card.Name, err)
+		return printings
+	}
+	for _, printing := range scryfallPrintings {
+		printings = append(printings, CardPrinting{
+			ScryfallID:      printing.ID,
+			SetCode:         printing.Set,
+			SetName:         printing.SetName,
+			CollectorNumber: printing.CollectorNumber,
+			Rarity:          printing.Rarity,
+			ReleasedAt:      printing.ReleasedAt,
+			ImageURL:        printing.ImageURLs[scryfallDB.Normal],
+			Price:           printing.Prices[scryfallDB.USD],
+		})
+	}
+	return printings
+}
+
+// getRulings loads the rulings from scryfall, without them if scryfall is not available
+func getRulings(ctx context.Context, card *db.Card) []CardRuling {
+	rulings := []CardRuling{}
+	if card.ScryfallID == "" {
+		return rulings
+	}
+	scryfallRulings, err := client.GetRulings(ctx, card.ScryfallID)
+	if err != nil {
+		log.Printf("Loading rulings of %s failed: %v", card.Name, err)
+		return rulings
+	}
+	for _, ruling := range scryfallRulings {
+		rulings = append(rulings, CardRuling{
+			Source:      ruling.Source,
+			PublishedAt: ruling.PublishedAt,
+			Comment:     ruling.Comment,
+		})
+	}
+	return rulings
+}
+
+// getTopSynergies returns the cards with the highest synergy with the main card
+func getTopSynergies(ctx context.Context, mainCard string, limit int) ([]CardSynergy, error) {
+	edhrecSynergies, err := edhrecDB.GetSynergyStore().GetEdhrecSynergysByMainCard(ctx, mainCard)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(edhrecSynergies, func(i, j int) bool {
+		return edhrecSynergies[i].Synergy > edhrecSynergies[j].Synergy
+	})
+	if len(edhrecSynergies) > limit {
+		edhrecSynergies = edhrecSynergies[:limit]
+	}
+
+	synergies := []CardSynergy{}
+	for _, edhrecSynergy := range edhrecSynergies {
+		synergies = append(synergies, CardSynergy{Name: edhrecSynergy.CardWithSynergy, Synergy: edhrecSynergy.Synergy})
+	}
+	return synergies, nil
+}
+
+// getOwnedQuantity returns how many copies of the card the user collected, in all sets
+func getOwnedQuantity(ctx context.Context, userID string, name string) (int64, error) {
+	userCards, err := userDB.GetUserCardStore().GetUserCardsByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	nameOfFirstSide := name
+	if index := strings.Index(name, " // "); index > -1 {
+		// Two-sided collected cards only contain the first side
+		nameOfFirstSide = name[:index]
+	}
+	var quantity int64
+	for _, userCard := range userCards {
+		if userCard.Name == name || userCard.Name == nameOfFirstSide {
+			for _, set := range userCard.Sets {
+				quantity += set.Quantity
+			}
+		}
+	}
+	return quantity, nil
+}
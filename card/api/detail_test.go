@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maedu/mtg-cards/card/db"
+	edhrecDB "github.com/maedu/mtg-cards/edhrec/db"
+	"github.com/maedu/mtg-cards/server"
+	"github.com/maedu/mtg-cards/user/auth"
+	userDB "github.com/maedu/mtg-cards/user/db"
+)
+
+const solRingOracleID = "6ad8011d-3471-4369-9d68-b264cc027487"
+
+func setupScryfall(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response gin.H
+		switch {
+		case r.URL.Path == "/cards/sol-ring/rulings":
+			response = gin.H{"data": []gin.H{{"source": "wotc", "published_at": "2004-10-04", "comment": "Some ruling."}}}
+		case r.URL.Path == "/cards/search" && r.URL.Query().Get("q") == "oracleid:"+solRingOracleID:
+			response = gin.H{"data": []gin.H{
+				{"id": "1", "set": "lea", "set_name": "Limited Edition Alpha", "collector_number": "270", "rarity": "uncommon", "prices": gin.H{"usd": "1000.00"}},
+				{"id": "2", "set": "c21", "set_name": "Commander 2021", "collector_number": "263", "rarity": "uncommon", "prices": gin.H{"usd": "1.50"}},
+			}}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(ts.Close)
+
+	os.Setenv("SCRYFALL_BASE_URL", ts.URL)
+	t.Cleanup(func() { os.Unsetenv("SCRYFALL_BASE_URL") })
+}
+
+func TestHandleGetCard(t *testing.T) {
+	setupScryfall(t)
+	solRing := &db.Card{Name: "Sol Ring", OracleID: solRingOracleID, ScryfallID: "sol-ring", OracleText: "{T}: Add {C}{C}."}
+	db.UseCardStore(db.NewMemoryCardStore(
+		solRing,
+		&db.Card{Name: "Delver of Secrets // Insectile Aberration", CardFaces: []db.Card{{Name: "Delver of Secrets"}, {Name: "Insectile Aberration"}}},
+	))
+	// Without imported printings, they are loaded from scryfall
+	db.UsePrintingStore(db.NewMemoryPrintingStore())
+	edhrecDB.UseSynergyStore(edhrecDB.NewMemorySynergyStore(
+		edhrecDB.EdhrecSynergy{MainCard: "Sol Ring", CardWithSynergy: "Mana Crypt", Synergy: 0.3},
+		edhrecDB.EdhrecSynergy{MainCard: "Sol Ring", CardWithSynergy: "Arcane Signet", Synergy: 0.5},
+		edhrecDB.EdhrecSynergy{MainCard: "Sol Ring", CardWithSynergy: "Mind Stone", Synergy: 0.1},
+	))
+	userDB.UseUserCardStore(userDB.NewMemoryUserCardStore(
+		&userDB.UserCard{UserID: "user@example.com", Name: "Sol Ring", Sets: []userDB.Set{{ID: "c21", Quantity: 2}, {ID: "lea", Quantity: 1}}},
+	))
+	previous := auth.GetAuthenticator()
+	auth.UseAuthenticator(auth.StaticAuthenticator{"user": "user@example.com"})
+	defer auth.UseAuthenticator(previous)
+
+	server := server.Configure()
+	Setup(server)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	getCard := func(path string, token string) (*http.Response, CardDetail) {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer res.Body.Close()
+		var detail CardDetail
+		if res.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(res.Body).Decode(&detail); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		return res, detail
+	}
+
+	res, detail := getCard("/api/cards/id/"+solRing.ID.Hex()+"?synergies=2", "user")
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("bad status: %s", res.Status)
+	}
+	if detail.Name != "Sol Ring" || detail.OracleText != "{T}: Add {C}{C}." {
+		t.Errorf("unexpected card %v", detail.Card)
+	}
+	if len(detail.Printings) != 2 || detail.Printings[1].SetName != "Commander 2021" || detail.Printings[1].Price != "1.50" {
+		t.Errorf("unexpected printings %v", detail.Printings)
+	}
+	if len(detail.Rulings) != 1 || detail.Rulings[0].Comment != "Some ruling." {
+		t.Errorf("unexpected rulings %v", detail.Rulings)
+	}
+	if fmt.Sprint(detail.TopSynergies) != "[{Arcane Signet 0.5} {Mana Crypt 0.3}]" {
+		t.Errorf("unexpected synergies %v", detail.TopSynergies)
+	}
+	if detail.Quantity != 3 {
+		t.Errorf("expected quantity 3, got %d", detail.Quantity)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		want   string
+	}{
+		{"Oracle ID", "/api/cards/id/" + solRingOracleID, http.StatusOK, "Sol Ring"},
+		{"Name", "/api/cards/named?name=" + url.QueryEscape("Sol Ring"), http.StatusOK, "Sol Ring"},
+		{"Name of face", "/api/cards/named?name=" + url.QueryEscape("Insectile Aberration"), http.StatusOK, "Delver of Secrets // Insectile Aberration"},
+		{"Unknown name", "/api/cards/named?name=Unknown", http.StatusNotFound, ""},
+		{"Unknown ID", "/api/cards/id/5f666907ca5504e135b45ac2", http.StatusNotFound, ""},
+		{"Invalid ID", "/api/cards/id/invalid", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, detail := getCard(tt.path, "")
+			if res.StatusCode != tt.status {
+				t.Fatalf("expected status %d, got %s", tt.status, res.Status)
+			}
+			if tt.want != "" && detail.Name != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, detail.Name)
+			}
+			if detail.Quantity != 0 {
+				t.Errorf("expected no quantity without user, got %d", detail.Quantity)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maedu/mtg-cards/card/db"
+)
+
+const (
+	csvFormat  = "csv"
+	textFormat = "text"
+)
+
+// exportBatchSize is how many cards are loaded at once, each batch is written before the next one is loaded
+var exportBatchSize int64 = 500
+
+// exportColumns are the columns of the CSV export with the value of a card
+var exportColumns = map[string]func(card *db.Card) string{
+	"name":   func(card *db.Card) string { return card.Name },
+	"set":    func(card *db.Card) string { return card.SetName },
+	"cmc":    func(card *db.Card) string { return strconv.FormatFloat(card.Cmc, 'f', -1, 64) },
+	"price":  func(card *db.Card) string { return strconv.FormatFloat(card.Price, 'f', 2, 64) },
+	"groups": func(card *db.Card) string { return strings.Join(card.CardGroups, ";") },
+	"synergy": func(card *db.Card) string {
+		if card.Synergy == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*card.Synergy, 'f', -1, 64)
+	},
+}
+
+var defaultExportColumns = []string{"name", "set", "cmc", "price", "groups", "synergy"}
+
+// handleExportCards exports all cards matching the parameters of /api/cards, not only a page,
+// with format=csv the columns are chosen with columns=name,cmc and format=text is a decklist with a line "1 Name" per card.
+// The cards are written in batches while they are loaded, so large results are streamed.
+func handleExportCards(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	format := c.DefaultQuery("format", csvFormat)
+	if format != csvFormat && format != textFormat {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("unknown format %q", format))
+		return
+	}
+	columns, err := getExportColumns(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	request, ok := getCardSearchRequest(c)
+	if !ok {
+		return
+	}
+	request.WithFacets = false
+	request.Cursor = &db.Cursor{}
+
+	result, err := searchCards(ctx, exportBatchSize, 1, request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if format == csvFormat {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="cards.csv"`)
+	} else {
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="cards.txt"`)
+	}
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	if format == csvFormat {
+		writer.Write(columns)
+	}
+	for {
+		db.AddMainCardSynergies(result.Cards, request)
+		for _, card := range result.Cards {
+			if format == textFormat {
+				fmt.Fprintf(c.Writer, "1 %s\n", card.Name)
+				continue
+			}
+			row := []string{}
+			for _, column := range columns {
+				row = append(row, exportColumns[column](card))
+			}
+			writer.Write(row)
+		}
+		writer.Flush()
+		c.Writer.Flush()
+
+		if result.NextCursor == "" {
+			return
+		}
+		request.Cursor, err = db.DecodeCursor(result.NextCursor, request)
+		if err == nil {
+			result, err = searchCards(ctx, exportBatchSize, 1, request)
+		}
+		if err != nil {
+			// The status was already sent, the export ends early
+			log.Printf("Export of cards failed: %v", err)
+			return
+		}
+	}
+}
+
+// getExportColumns returns the columns given with columns, separated by commas or as several parameters
+func getExportColumns(c *gin.Context) ([]string, error) {
+	columns := []string{}
+	for _, value := range c.QueryArray("columns") {
+		for _, column := range strings.Split(value, ",") {
+			column = strings.ToLower(strings.TrimSpace(column))
+			if column == "" {
+				continue
+			}
+			if _, ok := exportColumns[column]; !ok {
+				return nil, fmt.Errorf("unknown column %q", column)
+			}
+			columns = append(columns, column)
+		}
+	}
+	if len(columns) == 0 {
+		return defaultExportColumns, nil
+	}
+	return columns, nil
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maedu/mtg-cards/server"
+)
+
+func TestHandleExportCards(t *testing.T) {
+	setupMemoryCards()
+	server := server.Configure()
+	Setup(server)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	// Small batches, so the export has to load several of them
+	defer func(size int64) { exportBatchSize = size }(exportBatchSize)
+	exportBatchSize = 2
+
+	tests := []struct {
+		name        string
+		query       string
+		status      int
+		contentType string
+		want        string
+	}{
+		{"CSV", "sort=name&perPage=1&page=2", http.StatusOK, "text/csv; charset=utf-8",
+			"name,set,cmc,price,groups,synergy\nHarmonize,,4,0.30,Sorcery;Draw,\nLlanowar Elves,,1,0.20,Creature;Ramp,\nSol Ring,,1,1.50,Artifact;Ramp,\nForest,,0,0.00,Land,\n"},
+		{"Columns", "columns=name,price&cardGroups=Ramp&sort=price+desc", http.StatusOK, "text/csv; charset=utf-8",
+			"name,price\nSol Ring,1.50\nLlanowar Elves,0.20\n"},
+		{"Decklist", "format=text&colors=G&sort=name", http.StatusOK, "text/plain; charset=utf-8",
+			"1 Harmonize\n1 Llanowar Elves\n"},
+		{"Unknown column", "columns=name,power", http.StatusBadRequest, "", ""},
+		{"Unknown format", "format=xml", http.StatusBadRequest, "", ""},
+		{"Invalid query", "q=cmc<", http.StatusBadRequest, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := http.Get(fmt.Sprintf("%s/api/cards/export?%s", ts.URL, tt.query))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer res.Body.Close()
+			if res.StatusCode != tt.status {
+				t.Fatalf("Expected status %d, got %s", tt.status, res.Status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := res.Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %s, want %s", got, tt.contentType)
+			}
+			body, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if string(body) != tt.want {
+				t.Errorf("export = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maedu/mtg-cards/card/db"
+	jobDB "github.com/maedu/mtg-cards/job/db"
+	"github.com/maedu/mtg-cards/scryfall/client"
+	scryfallDB "github.com/maedu/mtg-cards/scryfall/db"
+)
+
+// printingImporter imports the default cards of scryfall as printings, they replace the stored printings on Commit
+type printingImporter struct {
+	staging db.PrintingStaging
+}
+
+func newPrintingImporter(ctx context.Context) (client.CardImporter, error) {
+	staging, err := db.GetPrintingStore().NewStaging(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return printingImporter{staging: staging}, nil
+}
+
+func (importer printingImporter) CreateMany(ctx context.Context, cards []*scryfallDB.ScryfallCard) error {
+	printings := []*db.Printing{}
+	for _, card := range cards {
+		if printing := transformPrinting(card); printing != nil {
+			printings = append(printings, printing)
+		}
+	}
+	return importer.staging.CreateMany(ctx, printings)
+}
+
+func (importer printingImporter) Commit(ctx context.Context) error {
+	return importer.staging.Commit(ctx)
+}
+
+func (importer printingImporter) Discard(ctx context.Context) error {
+	return importer.staging.Discard(ctx)
+}
+
+// UpdatePrintings replaces the printings with the ones of the default cards of scryfall, if they changed or force is set
+func UpdatePrintings(ctx context.Context, force bool, report func(progress jobDB.Progress)) error {
+	updated, err := client.UpdateDefaultCards(ctx, force, newPrintingImporter, func(progress client.ImportProgress) {
+		client.LogProgress(progress)
+		if report != nil {
+			jobProgress := importProgress(progress)
+			jobProgress.Message = fmt.Sprintf("Imported %d printings", progress.Cards)
+			report(jobProgress)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if !updated && report != nil {
+		report(jobDB.Progress{Message: "Printings are unchanged"})
+	}
+	return nil
+}
+
+// transformPrinting returns the printing of the scryfall card, nil for cards without oracle ID like reversible cards
+func transformPrinting(scryfallCard *scryfallDB.ScryfallCard) *db.Printing {
+	if scryfallCard.OracleID == "" {
+		return nil
+	}
+
+	prices := map[string]float64{}
+	for currency, price := range scryfallCard.Prices {
+		if amount := parseAmount(price); amount > 0 {
+			prices[string(currency)] = amount
+		}
+	}
+
+	imageURLs := map[string]string{}
+	images := scryfallCard.ImageURLs
+	if len(images) == 0 && len(scryfallCard.CardFaces) > 0 {
+		// Double-faced cards only have images per face, the front is used
+		images = scryfallCard.CardFaces[0].ImageURLs
+	}
+	for size, url := range images {
+		if size == scryfallDB.Normal || size == scryfallDB.Large {
+			imageURLs[size] = url
+		}
+	}
+
+	return &db.Printing{
+		ScryfallID:      scryfallCard.ID,
+		OracleID:        scryfallCard.OracleID,
+		Name:            scryfallCard.Name,
+		SetCode:         scryfallCard.Set,
+		SetName:         scryfallCard.SetName,
+		CollectorNumber: scryfallCard.CollectorNumber,
+		Rarity:          scryfallCard.Rarity,
+		ReleasedAt:      scryfallCard.ReleasedAt,
+		Foil:            scryfallCard.Foil,
+		Nonfoil:         scryfallCard.Nonfoil,
+		InBoosters:      scryfallCard.Booster,
+		Prices:          prices,
+		ImageURLs:       imageURLs,
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/maedu/mtg-cards/card/db"
+	scryfallDB "github.com/maedu/mtg-cards/scryfall/db"
+)
+
+func TestPrintingImporter(t *testing.T) {
+	store := db.NewMemoryPrintingStore(&db.Printing{ScryfallID: "old", OracleID: solRingOracleID})
+	db.UsePrintingStore(store)
+	importer, err := newPrintingImporter(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = importer.CreateMany(context.Background(), []*scryfallDB.ScryfallCard{
+		{
+			ID: "2", OracleID: solRingOracleID, Name: "Sol Ring", Set: "c21", SetName: "Commander 2021", CollectorNumber: "263",
+			Rarity: "uncommon", ReleasedAt: "2021-04-23", Nonfoil: true,
+			Prices:    map[scryfallDB.Currency]string{scryfallDB.USD: "1.50", scryfallDB.USD_FOIL: ""},
+			ImageURLs: map[string]string{scryfallDB.Normal: "normal.jpg", "small": "small.jpg"},
+		},
+		{
+			ID: "1", OracleID: solRingOracleID, Name: "Sol Ring", Set: "lea", SetName: "Limited Edition Alpha", CollectorNumber: "270",
+			Rarity: "uncommon", ReleasedAt: "1993-08-05", Nonfoil: true, Booster: true,
+			CardFaces: []scryfallDB.ScryfallCard{{ImageURLs: map[string]string{scryfallDB.Large: "large.jpg"}}},
+		},
+		{ID: "3", Name: "Reversible card without oracle ID"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if printings, _ := store.GetPrintingsByOracleID(context.Background(), solRingOracleID); len(printings) != 1 {
+		t.Errorf("Expected the previous printings until the import is committed, got %d", len(printings))
+	}
+	if err := importer.Commit(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	printings, _ := store.GetPrintingsByOracleID(context.Background(), solRingOracleID)
+	got := []string{}
+	for _, printing := range printings {
+		got = append(got, fmt.Sprintf("%s %s %v %v %v", printing.SetCode, printing.CollectorNumber, printing.InBoosters, printing.Prices, printing.ImageURLs))
+	}
+	want := []string{"lea 270 true map[] map[large:large.jpg]", "c21 263 false map[usd:1.5] map[normal:normal.jpg]"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("printings = %v, want %v", got, want)
+	}
+
+	if printings, _ := store.GetPrintingsBySet(context.Background(), "Commander 2021"); len(printings) != 1 {
+		t.Errorf("expected 1 printing of the set, got %d", len(printings))
+	}
+}
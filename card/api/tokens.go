@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"github.com/maedu/mtg-cards/card/db"
+	scryfallDB "github.com/maedu/mtg-cards/scryfall/db"
+)
+
+// tokenCollector collects the tokens and emblems while the cards are transformed and links them to the cards creating them.
+// Both sides of the link are used, as the card and the token might only list each other in all_parts of another printing.
+type tokenCollector struct {
+	tokens []*db.Token
+	// creatorNames are the names of the cards creating a token by its scryfall ID, as listed by the token
+	creatorNames map[string][]string
+	// createdParts are the tokens by the oracle ID of the card creating them, as listed by the card
+	createdParts    map[string][]scryfallDB.RelatedCard
+	oracleIDsByName map[string]string
+}
+
+func newTokenCollector() *tokenCollector {
+	return &tokenCollector{
+		creatorNames:    map[string][]string{},
+		createdParts:    map[string][]scryfallDB.RelatedCard{},
+		oracleIDsByName: map[string]string{},
+	}
+}
+
+func isTokenLayout(layout string) bool {
+	switch layout {
+	case "token", "double_faced_token", "emblem":
+		return true
+	}
+	return false
+}
+
+// add collects the scryfall card if it is a token, or the tokens it creates if it was transformed into the card
+func (collector *tokenCollector) add(scryfallCard *scryfallDB.ScryfallCard, card *db.Card) {
+	if isTokenLayout(scryfallCard.Layout) {
+		collector.tokens = append(collector.tokens, transformToken(scryfallCard))
+		for _, part := range scryfallCard.AllParts {
+			if part.Component == scryfallDB.ComboPieceComponent {
+				collector.creatorNames[scryfallCard.ID] = append(collector.creatorNames[scryfallCard.ID], part.Name)
+			}
+		}
+		return
+	}
+	if card == nil {
+		return
+	}
+
+	collector.oracleIDsByName[card.Name] = card.OracleID
+	for _, part := range scryfallCard.AllParts {
+		if part.Component == scryfallDB.TokenComponent && part.ID != scryfallCard.ID {
+			collector.createdParts[card.OracleID] = append(collector.createdParts[card.OracleID], part)
+		}
+	}
+}
+
+// linkedTokens returns the collected tokens with the oracle IDs of the cards creating them.
+// A token listed by a card is found by its scryfall ID, otherwise by its name and type line, as it might be another printing.
+func (collector *tokenCollector) linkedTokens() []*db.Token {
+	byID := map[string]*db.Token{}
+	byNameAndType := map[string][]*db.Token{}
+	createdBy := map[*db.Token]map[string]bool{}
+	for _, token := range collector.tokens {
+		byID[token.ScryfallID] = token
+		key := token.Name + "|" + token.TypeLine
+		byNameAndType[key] = append(byNameAndType[key], token)
+		createdBy[token] = map[string]bool{}
+		for _, name := range collector.creatorNames[token.ScryfallID] {
+			if oracleID, ok := collector.oracleIDsByName[name]; ok {
+				createdBy[token][oracleID] = true
+			}
+		}
+	}
+
+	for oracleID, parts := range collector.createdParts {
+		for _, part := range parts {
+			tokens := byNameAndType[part.Name+"|"+part.TypeLine]
+			if token, ok := byID[part.ID]; ok {
+				tokens = []*db.Token{token}
+			}
+			for _, token := range tokens {
+				createdBy[token][oracleID] = true
+			}
+		}
+	}
+
+	for _, token := range collector.tokens {
+		token.CreatedBy = []string{}
+		for oracleID := range createdBy[token] {
+			token.CreatedBy = append(token.CreatedBy, oracleID)
+		}
+		sort.Strings(token.CreatedBy)
+	}
+	return collector.tokens
+}
+
+// transformToken returns the token or emblem of the scryfall card, double-faced tokens are shown with their front face
+func transformToken(scryfallCard *scryfallDB.ScryfallCard) *db.Token {
+	face := scryfallCard
+	if len(scryfallCard.CardFaces) > 0 {
+		face = &scryfallCard.CardFaces[0]
+	}
+
+	imageURLs := map[string]string{}
+	images := scryfallCard.ImageURLs
+	if len(images) == 0 {
+		images = face.ImageURLs
+	}
+	for size, url := range images {
+		if size == scryfallDB.Normal || size == scryfallDB.Large {
+			imageURLs[size] = url
+		}
+	}
+
+	colors := scryfallCard.Colors
+	if colors == nil {
+		colors = face.Colors
+	}
+	if colors == nil {
+		colors = []string{}
+	}
+
+	return &db.Token{
+		ScryfallID: scryfallCard.ID,
+		OracleID:   scryfallCard.OracleID,
+		Name:       scryfallCard.Name,
+		Layout:     scryfallCard.Layout,
+		TypeLine:   scryfallCard.TypeLine,
+		OracleText: face.OracleText,
+		Colors:     colors,
+		Power:      face.Power,
+		Toughness:  face.Toughness,
+		IsEmblem:   scryfallCard.Layout == "emblem",
+		ImageURLs:  imageURLs,
+		CreatedBy:  []string{},
+	}
+}
+
+// replaceTokens replaces the stored tokens with the collected ones, the stored tokens are kept if writing the collected ones fails
+func replaceTokens(ctx context.Context, store db.TokenStore, collector *tokenCollector) error {
+	staging, err := store.NewStaging(ctx)
+	if err != nil {
+		return err
+	}
+	err = staging.CreateMany(ctx, collector.linkedTokens())
+	if err != nil {
+		if discardErr := staging.Discard(context.Background()); discardErr != nil {
+			log.Printf("Discarding the staged tokens failed: %v", discardErr)
+		}
+		return err
+	}
+	return staging.Commit(ctx)
+}
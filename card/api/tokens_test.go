@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/maedu/mtg-cards/card/db"
+	scryfallDB "github.com/maedu/mtg-cards/scryfall/db"
+)
+
+func TestTokenCollector(t *testing.T) {
+	soldier := scryfallDB.RelatedCard{ID: "soldier", Component: scryfallDB.TokenComponent, Name: "Soldier", TypeLine: "Token Creature — Soldier"}
+	collector := newTokenCollector()
+	collector.add(&scryfallDB.ScryfallCard{ID: "soldier", Name: "Soldier", Layout: "token", TypeLine: soldier.TypeLine, Power: "1", Toughness: "1",
+		AllParts: []scryfallDB.RelatedCard{soldier, {ID: "muster", Component: scryfallDB.ComboPieceComponent, Name: "Raise the Alarm"}}}, nil)
+	collector.add(&scryfallDB.ScryfallCard{ID: "elspeth-emblem", Name: "Elspeth, Knight-Errant Emblem", Layout: "emblem", TypeLine: "Emblem — Elspeth"}, nil)
+	collector.add(&scryfallDB.ScryfallCard{ID: "treasure", Name: "Treasure // Soldier", Layout: "double_faced_token", TypeLine: "Token Artifact — Treasure // Token Creature — Soldier",
+		CardFaces: []scryfallDB.ScryfallCard{{Name: "Treasure", OracleText: "{T}, Sacrifice this artifact: Add one mana of any color."}, {Name: "Soldier"}}}, nil)
+
+	collector.add(&scryfallDB.ScryfallCard{ID: "raise", Name: "Raise the Alarm"}, &db.Card{Name: "Raise the Alarm", OracleID: "raise-the-alarm"})
+	// Another printing of the soldier token is found by its name and type line
+	collector.add(&scryfallDB.ScryfallCard{ID: "elspeth", Name: "Elspeth, Knight-Errant", AllParts: []scryfallDB.RelatedCard{
+		{ID: "other-soldier", Component: scryfallDB.TokenComponent, Name: "Soldier", TypeLine: soldier.TypeLine},
+		{ID: "elspeth-emblem", Component: scryfallDB.TokenComponent, Name: "Elspeth, Knight-Errant Emblem"},
+	}}, &db.Card{Name: "Elspeth, Knight-Errant", OracleID: "elspeth"})
+	// Cards which weren't transformed create no tokens
+	collector.add(&scryfallDB.ScryfallCard{ID: "art", Layout: "art_series", AllParts: []scryfallDB.RelatedCard{soldier}}, nil)
+
+	got := []string{}
+	for _, token := range collector.linkedTokens() {
+		got = append(got, fmt.Sprintf("%s %v %v %s", token.Name, token.CreatedBy, token.IsEmblem, token.OracleText))
+	}
+	want := []string{
+		"Soldier [elspeth raise-the-alarm] false ",
+		"Elspeth, Knight-Errant Emblem [elspeth] true ",
+		"Treasure // Soldier [] false {T}, Sacrifice this artifact: Add one mana of any color.",
+	}
+	if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", want) {
+		t.Errorf("linkedTokens() = %q, want %q", got, want)
+	}
+}
+
+func TestReplaceTokens(t *testing.T) {
+	store := db.NewMemoryTokenStore(&db.Token{ScryfallID: "old", Name: "Goblin", CreatedBy: []string{"krenko"}})
+	collector := newTokenCollector()
+	collector.add(&scryfallDB.ScryfallCard{ID: "treasure", Name: "Treasure", Layout: "token",
+		AllParts: []scryfallDB.RelatedCard{{Component: scryfallDB.ComboPieceComponent, Name: "Dockside Extortionist"}}}, nil)
+	collector.add(&scryfallDB.ScryfallCard{ID: "dockside", Name: "Dockside Extortionist"}, &db.Card{Name: "Dockside Extortionist", OracleID: "dockside"})
+
+	if err := replaceTokens(context.Background(), store, collector); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tokens, _ := store.GetTokensCreatedBy(context.Background(), []string{"krenko"}); len(tokens) != 0 {
+		t.Errorf("Expected the previous tokens to be replaced, got %d", len(tokens))
+	}
+	if tokens, _ := store.GetTokensCreatedBy(context.Background(), []string{"dockside"}); len(tokens) != 1 || tokens[0].Name != "Treasure" {
+		t.Errorf("Expected the Treasure token, got %v", tokens)
+	}
+}
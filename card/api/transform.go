@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,56 +13,114 @@ import (
 	"github.com/maedu/mtg-cards/card/cardgroup"
 	"github.com/maedu/mtg-cards/card/db"
 	edhrecDB "github.com/maedu/mtg-cards/edhrec/db"
+	"github.com/maedu/mtg-cards/job"
+	jobDB "github.com/maedu/mtg-cards/job/db"
 	"github.com/maedu/mtg-cards/scryfall/client"
 	scryfallDB "github.com/maedu/mtg-cards/scryfall/db"
 )
 
+// Job types and the key of all jobs replacing the cards, only one of them runs at a time
+const (
+	UpdateCardsJob    = "update_cards"
+	TransformCardsJob = "transform_cards"
+	cardsJobKey       = "cards"
+)
+
 func handleUpdateCards(c *gin.Context) {
+	force := c.Query("force") == "true"
 
-	err := client.UpdateCards()
+	updateJob, err := EnqueueUpdateCards(force)
 	if err != nil {
-		fmt.Printf("Error updating cards: %v\n", err)
+		fmt.Printf("Error enqueuing card update: %v\n", err)
 		c.JSON(http.StatusInternalServerError, err)
 		return
 	}
 
-	err = TransformCards()
+	c.JSON(http.StatusAccepted, updateJob)
+}
+
+func handleTransformCards(c *gin.Context) {
+	transformJob, err := EnqueueTransformCards()
 	if err != nil {
-		fmt.Printf("Error transforming cards: %v\n", err)
+		fmt.Printf("Error enqueuing card transformation: %v\n", err)
 		c.JSON(http.StatusInternalServerError, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, nil)
+	c.JSON(http.StatusAccepted, transformJob)
+}
+
+// EnqueueUpdateCards adds a job importing the scryfall cards and transforming them and importing the printings, if they changed or force is set
+func EnqueueUpdateCards(force bool) (*jobDB.Job, error) {
+	return job.Enqueue(UpdateCardsJob, cardsJobKey, func(ctx context.Context, report func(progress jobDB.Progress)) error {
+		updated, err := client.UpdateCards(ctx, force, func(progress client.ImportProgress) {
+			client.LogProgress(progress)
+			report(importProgress(progress))
+		})
+		if err != nil {
+			return fmt.Errorf("updating cards: %w", err)
+		}
+		if updated {
+			err = TransformCards(ctx, report)
+			if err != nil {
+				return fmt.Errorf("transforming cards: %w", err)
+			}
+		} else {
+			report(jobDB.Progress{Message: "Cards are unchanged"})
+		}
+
+		err = UpdatePrintings(ctx, force, report)
+		if err != nil {
+			return fmt.Errorf("updating printings: %w", err)
+		}
+		return nil
+	})
+}
 
+// EnqueueTransformCards adds a job transforming the scryfall cards
+func EnqueueTransformCards() (*jobDB.Job, error) {
+	return job.Enqueue(TransformCardsJob, cardsJobKey, func(ctx context.Context, report func(progress jobDB.Progress)) error {
+		return TransformCards(ctx, report)
+	})
 }
 
-func handleTransformCards(c *gin.Context) {
+// EnqueueCardsJob adds a job of the type, which runs one at a time with the other jobs replacing the cards
+func EnqueueCardsJob(jobType string, run job.Run) (*jobDB.Job, error) {
+	return job.Enqueue(jobType, cardsJobKey, run)
+}
 
-	err := TransformCards()
-	if err != nil {
-		fmt.Printf("Error transforming cards: %v2\n", err)
-		c.JSON(http.StatusInternalServerError, err)
-		return
+func importProgress(progress client.ImportProgress) jobDB.Progress {
+	total := progress.TotalBytes
+	if total < 0 {
+		total = 0
+	}
+	return jobDB.Progress{
+		Current: progress.ReadBytes,
+		Total:   total,
+		Message: fmt.Sprintf("Imported %d cards", progress.Cards),
 	}
+}
 
-	c.JSON(http.StatusOK, nil)
+// cardsTransformedHooks are called after the transformed cards replaced the previous ones
+var cardsTransformedHooks []func(ctx context.Context) error
 
+// OnCardsTransformed adds a hook called after every transformation, e.g. to backfill data depending on the new cards
+func OnCardsTransformed(hook func(ctx context.Context) error) {
+	cardsTransformedHooks = append(cardsTransformedHooks, hook)
 }
 
-func TransformCards() error {
+// TransformCards replaces the cards and the tokens with the transformed scryfall cards, report is called after every page if set
+func TransformCards(ctx context.Context, report func(progress jobDB.Progress)) error {
 
 	log.Println("Get scryfallCollection")
 	scryfallCollection := scryfallDB.GetScryfallCardCollection()
-	defer scryfallCollection.Disconnect()
 
-	edhrecSynergyCollection, err := edhrecDB.GetEdhrecSynergyCollection()
-	defer edhrecSynergyCollection.Disconnect()
+	edhrecSynergyCollection := edhrecDB.GetSynergyStore()
 
+	edhSynergies, err := edhrecSynergyCollection.GetAllEdhrecSynergys(ctx)
 	if err != nil {
 		return err
 	}
-	edhSynergies, err := edhrecSynergyCollection.GetAllEdhrecSynergys()
 	synergies := map[string]map[string]float64{}
 	for _, edhSynergy := range edhSynergies {
 		if synergies[edhSynergy.CardWithSynergy] == nil {
@@ -70,14 +129,43 @@ func TransformCards() error {
 		synergies[edhSynergy.CardWithSynergy][edhSynergy.MainCard] = edhSynergy.Synergy
 	}
 
-	collection, err := db.GetCardCollection()
+	collection := db.GetCardStore()
+	staging, err := collection.NewStaging(ctx)
+	if err != nil {
+		return fmt.Errorf("NewStaging failed: %w", err)
+	}
+
+	tokens := newTokenCollector()
+	err = transformInto(ctx, staging, scryfallCollection, &synergies, tokens, report)
 	if err != nil {
+		// Keep the previous cards, the context might be cancelled already
+		if discardErr := staging.Discard(context.Background()); discardErr != nil {
+			log.Printf("Discarding the staged cards failed: %v", discardErr)
+		}
 		return err
 	}
-	defer collection.Disconnect()
 
-	collection.DeleteAll()
+	err = staging.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("Commit failed: %w", err)
+	}
+	resetNameIndex()
+
+	err = replaceTokens(ctx, db.GetTokenStore(), tokens)
+	if err != nil {
+		return fmt.Errorf("replacing tokens failed: %w", err)
+	}
+	for _, hook := range cardsTransformedHooks {
+		err = hook(ctx)
+		if err != nil {
+			return fmt.Errorf("after transformation: %w", err)
+		}
+	}
+	fmt.Println("Transformation done")
+	return nil
+}
 
+func transformInto(ctx context.Context, staging db.CardStaging, scryfallCollection *scryfallDB.ScryfallCardCollection, synergies *map[string]map[string]float64, tokens *tokenCollector, report func(progress jobDB.Progress)) error {
 	priceMap := getPriceMap()
 
 	var page int64 = 0
@@ -87,7 +175,7 @@ func TransformCards() error {
 	for first || page > 0 {
 
 		first = false
-		loadedScryfallCardsPaginated, err := scryfallCollection.GetScryfallCardsPaginated(limit, page)
+		loadedScryfallCardsPaginated, err := scryfallCollection.GetScryfallCardsPaginated(ctx, limit, page)
 		if err != nil {
 			return fmt.Errorf("GetScryfallCardsPaginated failed, for page %d: %w", page, err)
 		}
@@ -95,18 +183,26 @@ func TransformCards() error {
 		cards := []*db.Card{}
 
 		for _, scryfallCard := range loadedScryfallCardsPaginated.Cards {
-			card := transformCard(scryfallCard, &synergies, &priceMap, nil)
+			card := transformCard(scryfallCard, synergies, &priceMap, nil)
+			tokens.add(scryfallCard, card)
 			if card != nil {
 				cards = append(cards, card)
 			}
 		}
-		err = collection.CreateMany(cards)
+		err = staging.CreateMany(ctx, cards)
 		if err != nil {
 			return fmt.Errorf("CreateMany failed for page %d: %w", page, err)
 		}
+		if report != nil {
+			pagination := loadedScryfallCardsPaginated.Pagination
+			transformed := pagination.Page * pagination.PerPage
+			if transformed > pagination.Total {
+				transformed = pagination.Total
+			}
+			report(jobDB.Progress{Current: transformed, Total: pagination.Total, Message: "Transforming cards"})
+		}
 		page = loadedScryfallCardsPaginated.Pagination.Next
 	}
-	fmt.Println("Transformation done")
 	return nil
 }
 
@@ -122,7 +218,7 @@ func transformCard(scryfallCard *scryfallDB.ScryfallCard, synergies *map[string]
 
 	switch scryfallCard.Layout {
 	case "art_series", "token", "double_faced_token", "emblem":
-		// Ignore those types
+		// Ignore those types, tokens and emblems are collected by the tokenCollector
 		return nil
 	}
 
@@ -156,18 +252,20 @@ func transformCard(scryfallCard *scryfallDB.ScryfallCard, synergies *map[string]
 		return nil
 	}
 
-	legalInCommander := false
-	if len(scryfallCard.Legalities) > 0 {
+	// Cards are kept if they can be played in any format, the legalities of the faces are the ones of their card
+	var legalities map[string]string
+	legalInAnyFormat := false
+	if parentCard == nil {
+		legalities = map[string]string{}
 		for gameType, legalText := range scryfallCard.Legalities {
-			if gameType == scryfallDB.Commander {
-				legalInCommander = legalText == scryfallDB.Legal
-				if legalInCommander {
-					break
-				}
+			legalities[string(gameType)] = string(legalText)
+			if legalText == scryfallDB.Legal || legalText == scryfallDB.Restricted {
+				legalInAnyFormat = true
 			}
 		}
 	}
-	if !legalInCommander && parentCard == nil {
+	legalInCommander := scryfallCard.Legalities[scryfallDB.Commander] == scryfallDB.Legal
+	if !legalInAnyFormat && parentCard == nil {
 		return nil
 	}
 
@@ -250,6 +348,7 @@ func transformCard(scryfallCard *scryfallDB.ScryfallCard, synergies *map[string]
 
 	card := &db.Card{
 		ScryfallID:      scryfallCard.ID,
+		OracleID:        scryfallCard.OracleID,
 		Name:            scryfallCard.Name,
 		Lang:            scryfallCard.Lang,
 		ImageURLs:       imageURLs,
@@ -261,7 +360,18 @@ func transformCard(scryfallCard *scryfallDB.ScryfallCard, synergies *map[string]
 		Colors:          colors,
 		ColorIdentity:   scryfallCard.ColorIdentity,
 		Keywords:        scryfallCard.Keywords,
+		Power:           scryfallCard.Power,
+		Toughness:       scryfallCard.Toughness,
+		Loyalty:         scryfallCard.Loyalty,
+		PowerValue:      statValue(scryfallCard.Power),
+		ToughnessValue:  statValue(scryfallCard.Toughness),
+		LoyaltyValue:    statValue(scryfallCard.Loyalty),
+		ProducedMana:    scryfallCard.ProducedMana,
+		Reserved:        scryfallCard.Reserved,
+		Digital:         scryfallCard.Digital,
+		Promo:           scryfallCard.Promo,
 		LegalInComander: legalInCommander,
+		Legalities:      legalities,
 		SetName:         scryfallCard.SetName,
 		Rarity:          scryfallCard.Rarity,
 		EdhrecRank:      scryfallCard.EdhrecRank,
@@ -279,6 +389,26 @@ func transformCard(scryfallCard *scryfallDB.ScryfallCard, synergies *map[string]
 	return card
 }
 
+// statValue returns the number of a power, toughness or loyalty like "3", "1+*" or "*", where * counts as 0.
+// It is nil if the card has no such value or it isn't a number, like "∞".
+func statValue(stat string) *float64 {
+	if stat == "" {
+		return nil
+	}
+	if index := strings.Index(stat, "*"); index >= 0 {
+		stat = strings.TrimRight(stat[:index], "+-")
+	}
+	if stat == "" {
+		value := 0.0
+		return &value
+	}
+	value, err := strconv.ParseFloat(stat, 64)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
 func parseAmount(amount string) float64 {
 	val := strings.ReplaceAll(amount, "'", "")
 	val = strings.ReplaceAll(val, ",", "")
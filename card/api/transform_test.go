@@ -0,0 +1,33 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStatValue(t *testing.T) {
+	tests := []struct {
+		stat string
+		want string
+	}{
+		{"", "<nil>"},
+		{"3", "3"},
+		{"-1", "-1"},
+		{"*", "0"},
+		{"1+*", "1"},
+		{"*²", "0"},
+		{"+2", "2"},
+		{"∞", "<nil>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stat, func(t *testing.T) {
+			got := "<nil>"
+			if value := statValue(tt.stat); value != nil {
+				got = fmt.Sprint(*value)
+			}
+			if got != tt.want {
+				t.Errorf("statValue() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ var rampManaRegex, _ = regexp.Compile(`(?i)Adds? (\{[CBUGRW]\}|[a-zA-Z ]+mana)`)
 var landCardRegex, _ = regexp.Compile("(?i)(Land|Forest|Plains|Mountain|Swamp|Island) card[^.]+put .+?onto the battlefield")
 var canPlayAdditionalLandRegex, _ = regexp.Compile(`(?i)You may play \w+ additional lands?`)
 var canPutAdditionalLandRegex, _ = regexp.Compile(`(?i)You may put a land card from your hand onto the battlefield`)
+var manaAbilityRegex, _ = regexp.Compile(`(?im)^[^:\n]+:\s*Add\b`)
 
 var rampCards = []string{
 	"Jeweled Lotus",
@@ -44,12 +45,27 @@ func hasRampText(card *db.Card) bool {
 		}
 	}
 
-	return rampManaRegex.MatchString(card.OracleText) ||
+	return hasManaAbility(card) ||
+		rampManaRegex.MatchString(card.OracleText) ||
 		landCardRegex.MatchString(card.OracleText) ||
 		canPlayAdditionalLandRegex.MatchString(card.OracleText) ||
 		canPutAdditionalLandRegex.MatchString(card.OracleText)
 }
 
+// hasManaAbility returns true for permanents with an activated ability adding the mana scryfall lists for them,
+// which covers abilities rampManaRegex misses. Spells and cards only creating mana with tokens like Treasures are not included.
+func hasManaAbility(card *db.Card) bool {
+	if len(card.ProducedMana) == 0 {
+		return false
+	}
+	for _, cardType := range card.CardTypes {
+		if cardType == db.Instant || cardType == db.Sorcery {
+			return false
+		}
+	}
+	return manaAbilityRegex.MatchString(card.OracleText)
+}
+
 func isRampCard(card *db.Card) bool {
 	for _, rampCard := range rampCards {
 		if rampCard == card.Name {
@@ -95,12 +95,57 @@ func Test_hasRampText(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "Karametra's Acolyte",
+			args: args{
+				card: &db.Card{
+					CardTypes:    []db.CardType{"Creature"},
+					OracleText:   "{T}: Add an amount of {G} equal to your devotion to green.",
+					ProducedMana: []string{"G"},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "Smothering Tithe creates Treasures",
+			args: args{
+				card: &db.Card{
+					CardTypes:    []db.CardType{"Enchantment"},
+					OracleText:   "Whenever an opponent draws a card, that player may pay {2}. If they don't, you create a Treasure token.",
+					ProducedMana: []string{"B", "G", "R", "U", "W"},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "Dockside Extortionist creates Treasures",
+			args: args{
+				card: &db.Card{
+					CardTypes:    []db.CardType{"Creature"},
+					OracleText:   "When Dockside Extortionist enters the battlefield, create X Treasure tokens, where X is the number of artifacts and enchantments your opponents control.",
+					ProducedMana: []string{"B", "G", "R", "U", "W"},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "Big Score is a spell",
+			args: args{
+				card: &db.Card{
+					CardTypes:    []db.CardType{"Instant"},
+					OracleText:   "As an additional cost to cast this spell, discard a card.\nDraw two cards and create two Treasure tokens.",
+					ProducedMana: []string{"B", "G", "R", "U", "W"},
+				},
+			},
+			want: false,
+		},
 		{
 			name: "Barbarian Ring",
 			args: args{
 				card: &db.Card{
-					CardTypes:  []db.CardType{"Land"},
-					OracleText: "{T}: Add {R}. Barbarian Ring deals 1 damage to you.",
+					CardTypes:    []db.CardType{"Land"},
+					OracleText:   "{T}: Add {R}. Barbarian Ring deals 1 damage to you.",
+					ProducedMana: []string{"R"},
 				},
 			},
 			want: false,
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"log"
 	"sort"
@@ -23,6 +24,8 @@ type Rarity string
 type PaginatedResult struct {
 	Cards      []*Card                   `json:"cards"`
 	Pagination pagination.PaginationData `json:"pagination"`
+	Facets     Facets                    `json:"facets,omitempty"`
+	NextCursor string                    `json:"nextCursor,omitempty"`
 }
 
 type CardType string
@@ -48,6 +51,7 @@ const (
 type Card struct {
 	ID              primitive.ObjectID `bson:"_id" json:"id,omitempty"`
 	ScryfallID      string             `bson:"scryfall_id" json:"-"`
+	OracleID        string             `bson:"oracle_id" json:"oracleId"`
 	Name            string             `json:"name"`
 	Lang            string             `json:"lang"`
 	Layout          string             `json:"layout"`
@@ -56,11 +60,19 @@ type Card struct {
 	Cmc             float64            `json:"cmc"`
 	TypeLine        string             `bson:"type_line" json:"typeLine"`
 	CardTypes       []CardType         `bson:"card_types" json:"cardTypes"`
-	OracleText      string             `bson:"oracle_text" json:"-"`
+	OracleText      string             `bson:"oracle_text" json:"oracleText"`
 	Colors          []string           `json:"colors"`
 	ColorIdentity   []string           `json:"colorIdentity"`
 	Keywords        []string           `json:"keywords"`
+	Power           string             `json:"power"`
+	Toughness       string             `json:"toughness"`
+	Loyalty         string             `json:"loyalty"`
+	ProducedMana    []string           `bson:"produced_mana" json:"producedMana"`
+	Reserved        bool               `json:"reserved"`
+	Digital         bool               `json:"digital"`
+	Promo           bool               `json:"promo"`
 	LegalInComander bool               `json:"legalInCommander"`
+	Legalities      map[string]string  `bson:"legalities" json:"legalities"`
 	SetName         string             `bson:"set_name" json:"setName"`
 	Rarity          string             `json:"rarity"`
 	EdhrecRank      int                `json:"edhrecRank"`
@@ -73,6 +85,13 @@ type Card struct {
 	CardGroups      []string           `bson:"card_groups" json:"cardGroups"`
 	Synergies       map[string]float64 `bson:"synergies" json:"synergies"`
 	InCollection    bool               `bson:"-" json:"inCollection"`
+	// PowerValue, ToughnessValue and LoyaltyValue are the numbers compared by the search, * counts as 0, nil if the card has none
+	PowerValue     *float64 `bson:"power_value,omitempty" json:"-"`
+	ToughnessValue *float64 `bson:"toughness_value,omitempty" json:"-"`
+	LoyaltyValue   *float64 `bson:"loyalty_value,omitempty" json:"-"`
+	// MainCardSynergies are the synergies with the main cards of the search and Synergy their combination
+	MainCardSynergies map[string]float64 `bson:"-" json:"mainCardSynergies,omitempty"`
+	Synergy           *float64           `bson:"-" json:"synergy,omitempty"`
 }
 
 type CardSearchRequest struct {
@@ -81,27 +100,36 @@ type CardSearchRequest struct {
 	Colors                  []string
 	CardGroups              []string
 	MainCardForSynergy      string
+	Synergy                 SynergySpec
 	SearchRelatedToMainCard bool
 	PriceMin                float64
 	PriceMax                float64
 	SortBy                  string
 	SortDir                 string
 	UserID                  string
+	Query                   *Query
+	ColorIdentity           *Query
+	WithFacets              bool
+	Sort                    []SortKey
+	Cursor                  *Cursor
+	// LegalIn is the format the cards must be legal in, all formats if it is empty
+	LegalIn string
 }
 
 // CardCollection ...
 type CardCollection struct {
-	*mongo.Client
 	*mongo.Collection
-	context.Context
-	context.CancelFunc
 }
 
-// GetCardCollection ...
-func GetCardCollection() (CardCollection, error) {
-	client, ctx, cancel := db.GetConnection()
-	db := client.Database(db.GetDatabaseName())
-	collection := db.Collection("cards")
+// NewCardCollection creates the CardCollection using the shared client
+func NewCardCollection(client *mongo.Client) *CardCollection {
+	return &CardCollection{
+		Collection: client.Database(db.GetDatabaseName()).Collection("cards"),
+	}
+}
+
+// CreateTextIndex creates the weighted text index used by the full text search
+func CreateTextIndex(ctx context.Context, database *mongo.Database) error {
 	modelOpts := options.Index()
 	modelOpts.SetWeights(bson.M{
 		"name":        5,
@@ -122,30 +150,44 @@ func GetCardCollection() (CardCollection, error) {
 	}
 
 	opts := options.CreateIndexes().SetMaxTime(10 * time.Second)
-	indexName, err := collection.Indexes().CreateOne(ctx, index, opts)
+	indexName, err := database.Collection("cards").Indexes().CreateOne(ctx, index, opts)
 	if err != nil {
-		return CardCollection{}, err
+		return err
 	}
 	fmt.Printf("Index created: %s\n", indexName)
+	return nil
+}
 
-	return CardCollection{
-		Client:     client,
-		Collection: collection,
-		Context:    ctx,
-		CancelFunc: cancel,
-	}, nil
+// CardID returns the ID of the card derived from its oracle ID, so the card keeps its ID when the cards are transformed again.
+// Cards without oracle ID, like reversible cards, use their scryfall ID instead.
+func CardID(card *Card) primitive.ObjectID {
+	key := card.OracleID
+	if key == "" {
+		key = card.ScryfallID
+	}
+	if key == "" {
+		return primitive.NewObjectID()
+	}
+	hash := sha256.Sum256([]byte(key))
+	var id primitive.ObjectID
+	copy(id[:], hash[:])
+	return id
 }
 
-// Disconnect ...
-func (collection *CardCollection) Disconnect() {
-	collection.CancelFunc()
-	collection.Client.Disconnect(collection.Context)
+// CreateOracleIDIndex creates the index used to find a card by its oracle ID
+func CreateOracleIDIndex(ctx context.Context, database *mongo.Database) error {
+	model := mongo.IndexModel{
+		Keys: bson.M{
+			"oracle_id": 1,
+		},
+	}
+	_, err := database.Collection("cards").Indexes().CreateOne(ctx, model)
+	return err
 }
 
 // GetAllCards Retrives all cards from the db
-func (collection *CardCollection) GetAllCards() ([]*Card, error) {
+func (collection *CardCollection) GetAllCards(ctx context.Context) ([]*Card, error) {
 	var cards []*Card = []*Card{}
-	ctx := collection.Context
 
 	cursor, err := collection.Collection.Find(ctx, bson.D{})
 	if err != nil {
@@ -241,16 +283,12 @@ func getFilter(request CardSearchRequest) (bson.M, bson.M) {
 
 		if synergyFound {
 			// Filter for synergy
-			filters = append(filters, bson.M{"synergies." + request.MainCardForSynergy: bson.M{
-				"$gte": 0.2,
-			}})
+			filters = append(filters, synergySpec(request).synergyFilter(minSynergy))
 		}
 	}
 
 	if request.SearchRelatedToMainCard {
-		filters = append(filters, bson.M{"synergies." + request.MainCardForSynergy: bson.M{
-			"$exists": true,
-		}})
+		filters = append(filters, synergySpec(request).relatedFilter())
 	}
 
 	if request.PriceMin > PriceFilterSkipped {
@@ -265,6 +303,18 @@ func getFilter(request CardSearchRequest) (bson.M, bson.M) {
 		}})
 	}
 
+	if queryFilter := request.Query.filter(); queryFilter != nil {
+		filters = append(filters, queryFilter)
+	}
+
+	if identityFilter := request.ColorIdentity.filter(); identityFilter != nil {
+		filters = append(filters, identityFilter)
+	}
+
+	if request.LegalIn != "" {
+		filters = append(filters, legalityCondition(request.LegalIn, Legal, Restricted).filter())
+	}
+
 	filter := bson.M{}
 	if len(filters) > 0 {
 		filter = bson.M{
@@ -279,35 +329,50 @@ func getFilter(request CardSearchRequest) (bson.M, bson.M) {
 }
 
 // GetCardsPaginated Retrives all cards from the db
-func (collection *CardCollection) GetCardsPaginated(limit int64, page int64, request CardSearchRequest) (PaginatedResult, error) {
-	var cards []*Card = []*Card{}
-
+func (collection *CardCollection) GetCardsPaginated(ctx context.Context, limit int64, page int64, request CardSearchRequest) (PaginatedResult, error) {
 	filter, projection := getFilter(request)
+	matchStage := bson.M{"$match": filter}
+	if request.Cursor != nil {
+		return collection.getCardsAfterCursor(ctx, limit, request, projection, matchStage)
+	}
+
 	sort := getSortOptions(request)
-	paginatedData, err := pagination.New(collection.Collection).Limit(limit).Page(page).Filter(filter).Select(projection).Sort(sort).Find()
+	query := db.PaginatedQuery{Limit: limit, Page: page, Filter: filter, Projection: projection, Sort: sort}
+	var data []bson.Raw
+	var paginationData pagination.PaginationData
+	var err error
+	if sortsByCombinedSynergy(request) {
+		query.Projection = addCombinedSynergy(projection, request)
+		data, paginationData, err = query.Aggregate(ctx, collection.Collection, matchStage)
+	} else {
+		data, paginationData, err = query.Find(ctx, collection.Collection)
+	}
 	if err != nil {
 		return PaginatedResult{}, err
 	}
 
-	for _, raw := range paginatedData.Data {
-		var card *Card
-		if marshallErr := bson.Unmarshal(raw, &card); marshallErr != nil {
-			log.Printf("Failed marshalling: %v", err)
+	cards, err := unmarshalCards(data)
+	if err != nil {
+		return PaginatedResult{}, err
+	}
+	result := PaginatedResult{
+		Cards:      cards,
+		Pagination: paginationData,
+	}
+	if paginationData.Next > 0 && len(cards) > 0 {
+		result.NextCursor = newCursor(cards[len(cards)-1], request)
+	}
+	if request.WithFacets {
+		result.Facets, err = getFacets(ctx, collection.Collection, matchStage)
+		if err != nil {
 			return PaginatedResult{}, err
 		}
-		cards = append(cards, card)
-
 	}
-	return PaginatedResult{
-		Cards:      cards,
-		Pagination: paginatedData.Pagination,
-	}, nil
+	return result, nil
 }
 
 // GetCollectedCardsPaginated Retrives all cards from the db
-func (collection *CardCollection) GetCollectedCardsPaginated(limit int64, page int64, request CardSearchRequest) (PaginatedResult, error) {
-	var cards []*Card = []*Card{}
-
+func (collection *CardCollection) GetCollectedCardsPaginated(ctx context.Context, limit int64, page int64, request CardSearchRequest) (PaginatedResult, error) {
 	fmt.Println("GetCollectedCardsPaginated")
 
 	filter, projection := getFilter(request)
@@ -325,9 +390,17 @@ func (collection *CardCollection) GetCollectedCardsPaginated(limit int64, page i
 
 	matchForUserStage := bson.M{"$match": bson.M{"user_cards.user_id": bson.M{"$eq": request.UserID}}}
 
+	if request.Cursor != nil {
+		return collection.getCardsAfterCursor(ctx, limit, request, projection, matchStage, lookupUserCards, matchForUserStage)
+	}
+
 	sort := getSortOptions(request)
 
-	paginatedData, err := pagination.New(collection.Collection).Limit(limit).Page(page).Select(projection).Sort(sort).Aggregate(
+	query := db.PaginatedQuery{Limit: limit, Page: page, Projection: projection, Sort: sort}
+	if sortsByCombinedSynergy(request) {
+		query.Projection = addCombinedSynergy(projection, request)
+	}
+	data, paginationData, err := query.Aggregate(ctx, collection.Collection,
 		matchStage,
 		lookupUserCards,
 		matchForUserStage,
@@ -336,52 +409,175 @@ func (collection *CardCollection) GetCollectedCardsPaginated(limit int64, page i
 		return PaginatedResult{}, err
 	}
 
-	for _, raw := range paginatedData.Data {
+	cards, err := unmarshalCards(data)
+	if err != nil {
+		return PaginatedResult{}, err
+	}
+	result := PaginatedResult{
+		Cards:      cards,
+		Pagination: paginationData,
+	}
+	if paginationData.Next > 0 && len(cards) > 0 {
+		result.NextCursor = newCursor(cards[len(cards)-1], request)
+	}
+	if request.WithFacets {
+		result.Facets, err = getFacets(ctx, collection.Collection, matchStage, lookupUserCards, matchForUserStage)
+		if err != nil {
+			return PaginatedResult{}, err
+		}
+	}
+	return result, nil
+}
+
+// getCardsAfterCursor returns the cards of the pipeline after the cursor of the request
+func (collection *CardCollection) getCardsAfterCursor(ctx context.Context, limit int64, request CardSearchRequest, projection bson.M, pipeline ...bson.M) (PaginatedResult, error) {
+	if limit < 1 {
+		limit = 10
+	}
+	addFields, sort := sortValueStages(request)
+	exclude := []string{}
+	for field := range addFields {
+		exclude = append(exclude, field)
+	}
+	for field, value := range projection {
+		addFields[field] = value
+	}
+
+	query := db.CursorQuery{Limit: limit, AddFields: addFields, Sort: sort, Exclude: exclude}
+	if len(request.Cursor.Values) > 0 {
+		query.After = cursorFilter(request.Cursor, request)
+	}
+	data, total, hasMore, err := query.Aggregate(ctx, collection.Collection, pipeline...)
+	if err != nil {
+		return PaginatedResult{}, err
+	}
+
+	cards, err := unmarshalCards(data)
+	if err != nil {
+		return PaginatedResult{}, err
+	}
+	result := PaginatedResult{
+		Cards:      cards,
+		Pagination: db.CursorPaginationData(total, query.Limit),
+	}
+	if hasMore && len(cards) > 0 {
+		result.NextCursor = newCursor(cards[len(cards)-1], request)
+	}
+	if request.WithFacets {
+		result.Facets, err = getFacets(ctx, collection.Collection, pipeline...)
+		if err != nil {
+			return PaginatedResult{}, err
+		}
+	}
+	return result, nil
+}
+
+func unmarshalCards(data []bson.Raw) ([]*Card, error) {
+	var cards []*Card = []*Card{}
+	for _, raw := range data {
 		var card *Card
 		if marshallErr := bson.Unmarshal(raw, &card); marshallErr != nil {
-			log.Printf("Failed marshalling: %v", err)
-			return PaginatedResult{}, err
+			log.Printf("Failed marshalling: %v", marshallErr)
+			return nil, marshallErr
 		}
 		cards = append(cards, card)
 
 	}
-	return PaginatedResult{
-		Cards:      cards,
-		Pagination: paginatedData.Pagination,
-	}, nil
+	return cards, nil
 }
 
-func getSortOptions(request CardSearchRequest) bson.D {
-	trimmedText := strings.TrimSpace(request.Text)
-	if trimmedText != "" {
-		return bson.D{
-			primitive.E{Key: "score", Value: bson.M{
-				"$meta": "textScore",
-			}}}
+// GetCardNames retrieves all cards with only their names and the names of their faces
+func (collection *CardCollection) GetCardNames(ctx context.Context) ([]*Card, error) {
+	var cards []*Card = []*Card{}
+
+	opts := options.Find().SetProjection(bson.M{"name": 1, "card_faces.name": 1})
+	cursor, err := collection.Collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
 	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &cards)
+	if err != nil {
+		log.Printf("Failed marshalling %v", err)
+		return nil, err
+	}
+	return cards, nil
+}
 
-	sortDir := 1
-	if request.SortDir == "desc" {
-		sortDir = -1
+// GetCardByID retrieves a card by its ID, nil if there is none
+func (collection *CardCollection) GetCardByID(ctx context.Context, id primitive.ObjectID) (*Card, error) {
+	return collection.findOne(ctx, bson.M{"_id": id})
+}
+
+// GetCardByOracleID retrieves a card by its oracle ID, nil if there is none
+func (collection *CardCollection) GetCardByOracleID(ctx context.Context, oracleID string) (*Card, error) {
+	return collection.findOne(ctx, bson.M{"oracle_id": oracleID})
+}
+
+// GetCardsByOracleIDs retrieves the cards with the oracle IDs
+func (collection *CardCollection) GetCardsByOracleIDs(ctx context.Context, oracleIDs []string) ([]*Card, error) {
+	var cards []*Card = []*Card{}
+
+	cursor, err := collection.Collection.Find(ctx, bson.M{"oracle_id": bson.M{"$in": oracleIDs}})
+	if err != nil {
+		return nil, err
 	}
-	sortBy := request.SortBy
-	if sortBy == "synergy" {
-		sortBy = "synergies." + request.MainCardForSynergy
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &cards)
+	if err != nil {
+		log.Printf("Failed marshalling %v", err)
+		return nil, err
 	}
+	return cards, nil
+}
 
-	sort := bson.D{
-		primitive.E{Key: "is_land", Value: 1},
-		primitive.E{Key: sortBy, Value: sortDir},
+// OracleIDsByName returns the oracle IDs of the cards with the names, or with a card face with one of the names.
+// Names of unknown cards are missing in the result.
+func OracleIDsByName(ctx context.Context, store CardStore, names []string) (map[string]string, error) {
+	oracleIDs := map[string]string{}
+	if len(names) == 0 {
+		return oracleIDs, nil
 	}
+	cards, err := store.GetCardsByNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	requested := map[string]bool{}
+	for _, name := range names {
+		requested[name] = true
+	}
+	for _, card := range cards {
+		if card.OracleID == "" {
+			continue
+		}
+		if requested[card.Name] {
+			oracleIDs[card.Name] = card.OracleID
+		}
+		for _, cardFace := range card.CardFaces {
+			if _, ok := oracleIDs[cardFace.Name]; !ok && requested[cardFace.Name] {
+				oracleIDs[cardFace.Name] = card.OracleID
+			}
+		}
+	}
+	return oracleIDs, nil
+}
 
-	return sort
+func (collection *CardCollection) findOne(ctx context.Context, filter bson.M) (*Card, error) {
+	var card *Card
+	err := collection.Collection.FindOne(ctx, filter).Decode(&card)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return card, nil
 }
 
 // GetCardsByNames retrieves cards by their names from the db
-func (collection *CardCollection) GetCardsByNames(names []string) ([]*Card, error) {
+func (collection *CardCollection) GetCardsByNames(ctx context.Context, names []string) ([]*Card, error) {
 	log.Printf("find cards by names: %d", len(names))
 	var cards []*Card = []*Card{}
-	ctx := collection.Context
 
 	inFilter := bson.M{"$in": names}
 
@@ -404,9 +600,8 @@ func (collection *CardCollection) GetCardsByNames(names []string) ([]*Card, erro
 }
 
 // GetCardsBySetName retrieves a card by its set name from the db
-func (collection *CardCollection) GetCardsBySetName(setName string) ([]*Card, error) {
+func (collection *CardCollection) GetCardsBySetName(ctx context.Context, setName string) ([]*Card, error) {
 	var cards []*Card = []*Card{}
-	ctx := collection.Context
 
 	cursor, err := collection.Collection.Find(ctx, bson.D{bson.E{Key: "set_name", Value: setName}})
 	if err != nil {
@@ -422,9 +617,8 @@ func (collection *CardCollection) GetCardsBySetName(setName string) ([]*Card, er
 }
 
 // Create creating a card in a mongo
-func (collection *CardCollection) Create(card *Card) (primitive.ObjectID, error) {
-	ctx := collection.Context
-	card.ID = primitive.NewObjectID()
+func (collection *CardCollection) Create(ctx context.Context, card *Card) (primitive.ObjectID, error) {
+	card.ID = CardID(card)
 
 	result, err := collection.Collection.InsertOne(ctx, card)
 	if err != nil {
@@ -436,19 +630,17 @@ func (collection *CardCollection) Create(card *Card) (primitive.ObjectID, error)
 }
 
 // DeleteAll cards in the collection
-func (collection *CardCollection) DeleteAll() error {
-	ctx := collection.Context
+func (collection *CardCollection) DeleteAll(ctx context.Context) error {
 
 	return collection.Collection.Drop(ctx)
 }
 
 // CreateMany creating many cards in a mongo
-func (collection *CardCollection) CreateMany(cards []*Card) error {
-	ctx := collection.Context
+func (collection *CardCollection) CreateMany(ctx context.Context, cards []*Card) error {
 
 	var ui []interface{}
 	for _, t := range cards {
-		t.ID = primitive.NewObjectID()
+		t.ID = CardID(t)
 		ui = append(ui, t)
 	}
 
@@ -462,8 +654,7 @@ func (collection *CardCollection) CreateMany(cards []*Card) error {
 }
 
 // ReplaceAll first delete all and then create many cards in a mongo db
-func (collection *CardCollection) ReplaceAll(cards []*Card) (*[]primitive.ObjectID, error) {
-	ctx := collection.Context
+func (collection *CardCollection) ReplaceAll(ctx context.Context, cards []*Card) (*[]primitive.ObjectID, error) {
 
 	err := collection.Collection.Drop(ctx)
 	if err != nil {
@@ -472,7 +663,7 @@ func (collection *CardCollection) ReplaceAll(cards []*Card) (*[]primitive.Object
 
 	var ui []interface{}
 	for _, t := range cards {
-		t.ID = primitive.NewObjectID()
+		t.ID = CardID(t)
 		ui = append(ui, t)
 	}
 
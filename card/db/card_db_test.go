@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCardIDIsStable(t *testing.T) {
+	store := NewMemoryCardStore()
+	staging, _ := store.NewStaging(context.Background())
+	staging.CreateMany(context.Background(), []*Card{{Name: "Sol Ring", OracleID: "6ad8011d-3471-4369-9d68-b264cc027487"}})
+	staging.Commit(context.Background())
+	first, _ := store.GetCardByOracleID(context.Background(), "6ad8011d-3471-4369-9d68-b264cc027487")
+
+	// A transformation creates new card values, the ID stays the same
+	staging, _ = store.NewStaging(context.Background())
+	staging.CreateMany(context.Background(), []*Card{{Name: "Sol Ring", OracleID: "6ad8011d-3471-4369-9d68-b264cc027487"}})
+	staging.Commit(context.Background())
+	card, _ := store.GetCardByID(context.Background(), first.ID)
+	if card == nil || card.Name != "Sol Ring" {
+		t.Errorf("Expected the card to be found by its previous ID %s, got %v", first.ID.Hex(), card)
+	}
+
+	if CardID(&Card{OracleID: "a"}) == CardID(&Card{OracleID: "b"}) {
+		t.Error("Expected different IDs for different oracle IDs")
+	}
+}
+
+func TestMemoryCardStoreReturnsCopies(t *testing.T) {
+	power := 1.0
+	store := NewMemoryCardStore(&Card{
+		Name:       "Delver of Secrets // Insectile Aberration",
+		OracleID:   "delver",
+		Colors:     []string{"U"},
+		Legalities: map[string]string{"modern": Legal},
+		Synergies:  map[string]float64{"Brainstorm": 0.5},
+		CardFaces:  []Card{{Name: "Delver of Secrets", PowerValue: &power, ImageURLs: map[string]string{"normal": "front.jpg"}}},
+	})
+
+	card, _ := store.GetCardByOracleID(context.Background(), "delver")
+	card.Colors[0] = "R"
+	card.Legalities["modern"] = Banned
+	card.Synergies["Brainstorm"] = 1
+	*card.CardFaces[0].PowerValue = 3
+	card.CardFaces[0].ImageURLs["normal"] = "changed.jpg"
+
+	stored, _ := store.GetCardByOracleID(context.Background(), "delver")
+	if stored.Colors[0] != "U" || stored.Legalities["modern"] != Legal || stored.Synergies["Brainstorm"] != 0.5 ||
+		*stored.CardFaces[0].PowerValue != 1 || stored.CardFaces[0].ImageURLs["normal"] != "front.jpg" {
+		t.Errorf("Expected the stored card to be unchanged, got %+v", stored)
+	}
+}
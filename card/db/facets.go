@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	ColorFacet     = "colors"
+	CmcFacet       = "cmc"
+	CardGroupFacet = "cardGroups"
+	RarityFacet    = "rarity"
+	SetFacet       = "sets"
+)
+
+// FacetCount is the number of matching cards having the value
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// Facets contains the counts per value of each facet, ordered by the count, the cmc facet by the cmc.
+// Like the cmc filter, the cmc facet counts cards up to 1 as 1 and cards from 7 as 7.
+type Facets map[string][]FacetCount
+
+// facetStage returns the $facet stage counting the cards of the pipeline for each facet
+func facetStage() bson.M {
+	byCount := bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}
+	countBy := func(field string, unwind bool) bson.A {
+		stages := bson.A{}
+		if unwind {
+			stages = append(stages, bson.M{"$unwind": "$" + field})
+		}
+		return append(stages,
+			bson.M{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": byCount},
+		)
+	}
+	cmcBucket := bson.M{"$min": bson.A{bson.M{"$max": bson.A{bson.M{"$floor": "$cmc"}, 1}}, 7}}
+
+	return bson.M{"$facet": bson.M{
+		ColorFacet:     countBy("colors", true),
+		CardGroupFacet: countBy("card_groups", true),
+		RarityFacet:    countBy("rarity", false),
+		SetFacet:       countBy("set_name", false),
+		CmcFacet: bson.A{
+			bson.M{"$group": bson.M{"_id": cmcBucket, "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.M{"_id": 1}},
+		},
+	}}
+}
+
+// getFacets counts the cards matching the pipeline for each facet
+func getFacets(ctx context.Context, collection *mongo.Collection, pipeline ...bson.M) (Facets, error) {
+	stages := append([]bson.M{}, pipeline...)
+	stages = append(stages, facetStage())
+
+	cursor, err := collection.Aggregate(ctx, stages, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []map[string][]struct {
+		Value interface{} `bson:"_id"`
+		Count int64       `bson:"count"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	facets := Facets{}
+	for _, facet := range []string{ColorFacet, CmcFacet, CardGroupFacet, RarityFacet, SetFacet} {
+		facets[facet] = []FacetCount{}
+		if len(result) == 0 {
+			continue
+		}
+		for _, count := range result[0][facet] {
+			if count.Value == nil {
+				continue
+			}
+			facets[facet] = append(facets[facet], FacetCount{Value: fmt.Sprint(count.Value), Count: count.Count})
+		}
+	}
+	return facets, nil
+}
+
+// countFacets counts the cards for each facet like getFacets, used by the memory store
+func countFacets(cards []*Card) Facets {
+	counts := map[string]map[string]int64{}
+	for _, facet := range []string{ColorFacet, CmcFacet, CardGroupFacet, RarityFacet, SetFacet} {
+		counts[facet] = map[string]int64{}
+	}
+	for _, card := range cards {
+		for _, color := range card.Colors {
+			counts[ColorFacet][color]++
+		}
+		for _, cardGroup := range card.CardGroups {
+			counts[CardGroupFacet][cardGroup]++
+		}
+		if card.Rarity != "" {
+			counts[RarityFacet][card.Rarity]++
+		}
+		if card.SetName != "" {
+			counts[SetFacet][card.SetName]++
+		}
+		cmcBucket := math.Min(math.Max(math.Floor(card.Cmc), 1), 7)
+		counts[CmcFacet][strconv.FormatFloat(cmcBucket, 'f', -1, 64)]++
+	}
+
+	facets := Facets{}
+	for facet, values := range counts {
+		facetCounts := []FacetCount{}
+		for value, count := range values {
+			facetCounts = append(facetCounts, FacetCount{Value: value, Count: count})
+		}
+		sort.Slice(facetCounts, func(i, j int) bool {
+			if facet == CmcFacet {
+				return facetCounts[i].Value < facetCounts[j].Value
+			}
+			if facetCounts[i].Count != facetCounts[j].Count {
+				return facetCounts[i].Count > facetCounts[j].Count
+			}
+			return facetCounts[i].Value < facetCounts[j].Value
+		})
+		facets[facet] = facetCounts
+	}
+	return facets
+}
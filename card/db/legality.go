@@ -0,0 +1,61 @@
+package db
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Legalities of a card in a format, as given by scryfall
+const (
+	Legal      = "legal"
+	NotLegal   = "not_legal"
+	Restricted = "restricted"
+	Banned     = "banned"
+
+	// DefaultFormat is the format the cards are searched in, if no other format is requested
+	DefaultFormat = "commander"
+	// AllFormats disables the legality filter of the search
+	AllFormats = "all"
+)
+
+// Formats are the formats scryfall returns the legalities of
+var Formats = []string{
+	"standard", "future", "historic", "gladiator", "pioneer", "explorer", "modern", "legacy", "pauper", "vintage",
+	"penny", "commander", "brawl", "historicbrawl", "alchemy", "paupercommander", "duel", "oldschool", "premodern",
+}
+
+// IsFormat returns whether scryfall knows the legalities of the format
+func IsFormat(format string) bool {
+	return containsString(Formats, format)
+}
+
+// Legality returns the legality of the card in the format, empty if it is unknown.
+// Cards transformed before their legalities were kept are all legal in commander.
+func (card *Card) Legality(format string) string {
+	if card.Legalities == nil {
+		if format == DefaultFormat {
+			return Legal
+		}
+		return ""
+	}
+	return card.Legalities[format]
+}
+
+// IsLegalIn returns whether the card can be played in the format, restricted cards included
+func (card *Card) IsLegalIn(format string) bool {
+	legality := card.Legality(format)
+	return legality == Legal || legality == Restricted
+}
+
+// legalityCondition matches the cards with one of the legalities in the format
+func legalityCondition(format string, legalities ...string) queryNode {
+	filter := bson.M{"legalities." + format: bson.M{"$in": legalities}}
+	if format == DefaultFormat && containsString(legalities, Legal) {
+		filter = bson.M{"$or": bson.A{filter, bson.M{"legalities": bson.M{"$exists": false}}}}
+	}
+	return condition{
+		bson: filter,
+		match: func(card *Card) bool {
+			return containsString(legalities, card.Legality(format))
+		},
+	}
+}
@@ -0,0 +1,641 @@
+package db
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/maedu/mtg-cards/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryCardStore is a CardStore keeping all cards in memory, used by tests and when running without MongoDB
+type MemoryCardStore struct {
+	// CollectedCardNames returns the names of the cards collected by a user, used for GetCollectedCardsPaginated
+	CollectedCardNames func(ctx context.Context, userID string) (map[string]bool, error)
+
+	mutex sync.RWMutex
+	cards []*Card
+}
+
+// NewMemoryCardStore creates a MemoryCardStore containing the given cards
+func NewMemoryCardStore(cards ...*Card) *MemoryCardStore {
+	store := &MemoryCardStore{}
+	store.CreateMany(context.Background(), cards)
+	return store
+}
+
+// GetAllCards returns copies of all cards
+func (store *MemoryCardStore) GetAllCards(ctx context.Context) ([]*Card, error) {
+	return store.find(func(card *Card) bool { return true }), nil
+}
+
+// GetCardsPaginated returns a page of the cards matching the request
+func (store *MemoryCardStore) GetCardsPaginated(ctx context.Context, limit int64, page int64, request CardSearchRequest) (PaginatedResult, error) {
+	cards := store.find(func(card *Card) bool { return matchesRequest(card, request) })
+	return paginate(cards, limit, page, request), nil
+}
+
+// GetCollectedCardsPaginated returns a page of the cards matching the request, which are collected by the user of the request
+func (store *MemoryCardStore) GetCollectedCardsPaginated(ctx context.Context, limit int64, page int64, request CardSearchRequest) (PaginatedResult, error) {
+	collected := map[string]bool{}
+	if store.CollectedCardNames != nil {
+		var err error
+		collected, err = store.CollectedCardNames(ctx, request.UserID)
+		if err != nil {
+			return PaginatedResult{}, err
+		}
+	}
+
+	cards := store.find(func(card *Card) bool {
+		return collected[card.Name] && matchesRequest(card, request)
+	})
+	return paginate(cards, limit, page, request), nil
+}
+
+// GetCardNames returns all cards, the memory store has no reason to leave out the other fields
+func (store *MemoryCardStore) GetCardNames(ctx context.Context) ([]*Card, error) {
+	return store.GetAllCards(ctx)
+}
+
+// GetCardByID returns the card with the ID, nil if there is none
+func (store *MemoryCardStore) GetCardByID(ctx context.Context, id primitive.ObjectID) (*Card, error) {
+	return first(store.find(func(card *Card) bool { return card.ID == id })), nil
+}
+
+// GetCardByOracleID returns the card with the oracle ID, nil if there is none
+func (store *MemoryCardStore) GetCardByOracleID(ctx context.Context, oracleID string) (*Card, error) {
+	return first(store.find(func(card *Card) bool { return card.OracleID == oracleID })), nil
+}
+
+// GetCardsByOracleIDs returns the cards with the oracle IDs
+func (store *MemoryCardStore) GetCardsByOracleIDs(ctx context.Context, oracleIDs []string) ([]*Card, error) {
+	oracleIDSet := map[string]bool{}
+	for _, oracleID := range oracleIDs {
+		oracleIDSet[oracleID] = true
+	}
+	return store.find(func(card *Card) bool { return oracleIDSet[card.OracleID] }), nil
+}
+
+// GetCardsByNames returns the cards with the given names, or a card face with one of the names
+func (store *MemoryCardStore) GetCardsByNames(ctx context.Context, names []string) ([]*Card, error) {
+	nameSet := map[string]bool{}
+	for _, name := range names {
+		nameSet[name] = true
+	}
+
+	return store.find(func(card *Card) bool {
+		if nameSet[card.Name] {
+			return true
+		}
+		for _, cardFace := range card.CardFaces {
+			if nameSet[cardFace.Name] {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// GetCardsBySetName returns the cards of the set
+func (store *MemoryCardStore) GetCardsBySetName(ctx context.Context, setName string) ([]*Card, error) {
+	return store.find(func(card *Card) bool { return card.SetName == setName }), nil
+}
+
+// Create adds a card
+func (store *MemoryCardStore) Create(ctx context.Context, card *Card) (primitive.ObjectID, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	card.ID = CardID(card)
+	store.cards = append(store.cards, copyCard(card))
+	return card.ID, nil
+}
+
+// CreateMany adds many cards
+func (store *MemoryCardStore) CreateMany(ctx context.Context, cards []*Card) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, card := range cards {
+		card.ID = CardID(card)
+		store.cards = append(store.cards, copyCard(card))
+	}
+	return nil
+}
+
+// DeleteAll removes all cards
+func (store *MemoryCardStore) DeleteAll(ctx context.Context) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.cards = nil
+	return nil
+}
+
+// ReplaceAll removes all cards and adds the given ones
+func (store *MemoryCardStore) ReplaceAll(ctx context.Context, cards []*Card) (*[]primitive.ObjectID, error) {
+	store.DeleteAll(ctx)
+	store.CreateMany(ctx, cards)
+
+	oids := []primitive.ObjectID{}
+	for _, card := range cards {
+		oids = append(oids, card.ID)
+	}
+	return &oids, nil
+}
+
+// NewStaging creates a staging, which replaces all cards of the store on Commit
+func (store *MemoryCardStore) NewStaging(ctx context.Context) (CardStaging, error) {
+	return &memoryCardStaging{store: store}, nil
+}
+
+type memoryCardStaging struct {
+	store *MemoryCardStore
+	cards []*Card
+}
+
+func (staging *memoryCardStaging) CreateMany(ctx context.Context, cards []*Card) error {
+	for _, card := range cards {
+		card.ID = CardID(card)
+		staging.cards = append(staging.cards, copyCard(card))
+	}
+	return nil
+}
+
+func (staging *memoryCardStaging) Commit(ctx context.Context) error {
+	staging.store.mutex.Lock()
+	defer staging.store.mutex.Unlock()
+
+	staging.store.cards = staging.cards
+	staging.cards = nil
+	return nil
+}
+
+func (staging *memoryCardStaging) Discard(ctx context.Context) error {
+	staging.cards = nil
+	return nil
+}
+
+func (store *MemoryCardStore) find(matches func(card *Card) bool) []*Card {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	cards := []*Card{}
+	for _, card := range store.cards {
+		copied := copyCard(card)
+		if matches(copied) {
+			cards = append(cards, copied)
+		}
+	}
+	return cards
+}
+
+func first(cards []*Card) *Card {
+	if len(cards) == 0 {
+		return nil
+	}
+	return cards[0]
+}
+
+// copyCard copies the card, so callers can't modify the stored one
+func copyCard(card *Card) *Card {
+	copied := *card
+	copied.ImageURLs = copyStringMap(card.ImageURLs)
+	copied.CardTypes = append([]CardType(nil), card.CardTypes...)
+	copied.Colors = append([]string(nil), card.Colors...)
+	copied.ColorIdentity = append([]string(nil), card.ColorIdentity...)
+	copied.Keywords = append([]string(nil), card.Keywords...)
+	copied.ProducedMana = append([]string(nil), card.ProducedMana...)
+	copied.Legalities = copyStringMap(card.Legalities)
+	copied.CardGroups = append([]string{}, card.CardGroups...)
+	copied.Synergies = copyFloatMap(card.Synergies)
+	copied.MainCardSynergies = copyFloatMap(card.MainCardSynergies)
+	copied.PowerValue = copyFloat(card.PowerValue)
+	copied.ToughnessValue = copyFloat(card.ToughnessValue)
+	copied.LoyaltyValue = copyFloat(card.LoyaltyValue)
+	copied.Synergy = copyFloat(card.Synergy)
+	if card.CardFaces != nil {
+		copied.CardFaces = make([]Card, len(card.CardFaces))
+		for i := range card.CardFaces {
+			copied.CardFaces[i] = *copyCard(&card.CardFaces[i])
+		}
+	}
+	return &copied
+}
+
+// copyStringMap copies the map, a nil map stays nil
+func copyStringMap(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return copied
+}
+
+// copyFloatMap copies the map, a nil map stays nil
+func copyFloatMap(values map[string]float64) map[string]float64 {
+	if values == nil {
+		return nil
+	}
+	copied := make(map[string]float64, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return copied
+}
+
+func copyFloat(value *float64) *float64 {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
+// matchesRequest evaluates the filter built by getFilter on a single card
+func matchesRequest(card *Card, request CardSearchRequest) bool {
+	if terms := strings.Fields(strings.ToLower(request.Text)); len(terms) > 0 {
+		card.Score = textScore(card, terms)
+		if card.Score == 0 {
+			return false
+		}
+	}
+
+	if len(request.Cmc) > 0 {
+		cmcMatches := false
+		for _, cmc := range request.Cmc {
+			if (cmc <= 1 && card.Cmc <= cmc) || (cmc >= 7 && card.Cmc >= cmc) || card.Cmc == cmc {
+				cmcMatches = true
+				break
+			}
+		}
+		if !cmcMatches {
+			return false
+		}
+	}
+
+	if len(request.Colors) > 0 && !matchesColors(card, request.Colors) {
+		return false
+	}
+
+	for _, cardGroup := range request.CardGroups {
+		switch cardGroup {
+		case "Collected":
+		case "Synergy":
+			if synergy, ok := synergySpec(request).combine(card); !ok || synergy < minSynergy {
+				return false
+			}
+		default:
+			if !containsString(card.CardGroups, cardGroup) {
+				return false
+			}
+		}
+	}
+
+	if request.SearchRelatedToMainCard {
+		if !synergySpec(request).isRelated(card) {
+			return false
+		}
+	}
+
+	if request.PriceMin > PriceFilterSkipped && card.Price < request.PriceMin {
+		return false
+	}
+	if request.PriceMax > PriceFilterSkipped && card.Price > request.PriceMax {
+		return false
+	}
+
+	if !request.Query.matches(card) || !request.ColorIdentity.matches(card) {
+		return false
+	}
+
+	if request.LegalIn != "" && !card.IsLegalIn(request.LegalIn) {
+		return false
+	}
+
+	return true
+}
+
+func matchesColors(card *Card, colors []string) bool {
+	filteredColors := []string{}
+	muliColorSelected := false
+	for _, color := range colors {
+		if color == "M" {
+			muliColorSelected = true
+		} else {
+			filteredColors = append(filteredColors, color)
+		}
+	}
+	sort.Strings(filteredColors)
+
+	if muliColorSelected {
+		if len(card.Colors) < 2 {
+			return false
+		}
+		return len(filteredColors) == 0 || strings.Join(card.Colors, "") == strings.Join(filteredColors, "")
+	}
+
+	for _, color := range card.Colors {
+		if !containsString(filteredColors, color) {
+			return false
+		}
+	}
+	return true
+}
+
+// textScore approximates the weighted text index of the cards collection
+func textScore(card *Card, terms []string) float64 {
+	fields := []struct {
+		text   string
+		weight float64
+	}{
+		{card.Name, 5},
+		{card.OracleText, 4},
+		{card.TypeLine, 2},
+		{strings.Join(card.CardGroups, " "), 2},
+		{card.SetName, 1},
+	}
+
+	score := 0.0
+	for _, term := range terms {
+		for _, field := range fields {
+			if strings.Contains(strings.ToLower(field.text), term) {
+				score += field.weight
+			}
+		}
+	}
+	return score
+}
+
+func paginate(cards []*Card, limit int64, page int64, request CardSearchRequest) PaginatedResult {
+	sortCards(cards, request)
+
+	var facets Facets
+	if request.WithFacets {
+		facets = countFacets(cards)
+	}
+
+	if limit < 1 {
+		limit = 10
+	}
+	if page < 1 {
+		page = 1
+	}
+	total := int64(len(cards))
+	data := db.PaginationData(total, page, limit)
+
+	start := (page - 1) * limit
+	if request.Cursor != nil {
+		data = db.CursorPaginationData(total, limit)
+		start = 0
+		if len(request.Cursor.Values) > 0 {
+			keys := sortKeys(request)
+			for start < total && compareCard(cards[start], request.Cursor.Values, keys, request) <= 0 {
+				start++
+			}
+		}
+	}
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	result := PaginatedResult{
+		Cards:      cards[start:end],
+		Pagination: data,
+		Facets:     facets,
+	}
+	if end < total && end > start {
+		result.NextCursor = newCursor(cards[end-1], request)
+	}
+	return result
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryPrintingStore is a PrintingStore keeping all printings in memory, used by tests and when running without MongoDB
+type MemoryPrintingStore struct {
+	mutex sync.RWMutex
+	// printings are stored by their scryfall ID
+	printings map[string]*Printing
+}
+
+// NewMemoryPrintingStore creates a MemoryPrintingStore containing the given printings
+func NewMemoryPrintingStore(printings ...*Printing) *MemoryPrintingStore {
+	store := &MemoryPrintingStore{printings: map[string]*Printing{}}
+	store.CreateMany(context.Background(), printings)
+	return store
+}
+
+// GetPrintingsByOracleID returns copies of the printings of the card with the oracle ID, ordered by their release
+func (store *MemoryPrintingStore) GetPrintingsByOracleID(ctx context.Context, oracleID string) ([]*Printing, error) {
+	return store.find(func(printing *Printing) bool { return printing.OracleID == oracleID }), nil
+}
+
+// GetPrintingsBySet returns copies of the printings of the set, given by its code or its name
+func (store *MemoryPrintingStore) GetPrintingsBySet(ctx context.Context, set string) ([]*Printing, error) {
+	return store.find(func(printing *Printing) bool { return printing.SetCode == set || printing.SetName == set }), nil
+}
+
+// CreateMany adds many printings, an existing printing with the same scryfall ID is replaced
+func (store *MemoryPrintingStore) CreateMany(ctx context.Context, printings []*Printing) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, printing := range printings {
+		store.printings[printing.ScryfallID] = copyPrinting(printing)
+	}
+	return nil
+}
+
+// DeleteAll removes all printings
+func (store *MemoryPrintingStore) DeleteAll(ctx context.Context) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.printings = map[string]*Printing{}
+	return nil
+}
+
+// NewStaging creates a staging, which replaces all printings of the store on Commit
+func (store *MemoryPrintingStore) NewStaging(ctx context.Context) (PrintingStaging, error) {
+	return &memoryPrintingStaging{store: store, printings: map[string]*Printing{}}, nil
+}
+
+type memoryPrintingStaging struct {
+	store     *MemoryPrintingStore
+	printings map[string]*Printing
+}
+
+func (staging *memoryPrintingStaging) CreateMany(ctx context.Context, printings []*Printing) error {
+	for _, printing := range printings {
+		staging.printings[printing.ScryfallID] = copyPrinting(printing)
+	}
+	return nil
+}
+
+func (staging *memoryPrintingStaging) Commit(ctx context.Context) error {
+	staging.store.mutex.Lock()
+	defer staging.store.mutex.Unlock()
+
+	staging.store.printings = staging.printings
+	staging.printings = map[string]*Printing{}
+	return nil
+}
+
+func (staging *memoryPrintingStaging) Discard(ctx context.Context) error {
+	staging.printings = map[string]*Printing{}
+	return nil
+}
+
+func (store *MemoryPrintingStore) find(matches func(printing *Printing) bool) []*Printing {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	printings := []*Printing{}
+	for _, printing := range store.printings {
+		if matches(printing) {
+			printings = append(printings, copyPrinting(printing))
+		}
+	}
+	sort.SliceStable(printings, func(i, j int) bool {
+		a, b := printings[i], printings[j]
+		if a.ReleasedAt != b.ReleasedAt {
+			return a.ReleasedAt < b.ReleasedAt
+		}
+		if a.SetCode != b.SetCode {
+			return a.SetCode < b.SetCode
+		}
+		return a.CollectorNumber < b.CollectorNumber
+	})
+	return printings
+}
+
+// copyPrinting copies the printing, so callers can't modify the stored one
+func copyPrinting(printing *Printing) *Printing {
+	copied := *printing
+	copied.Prices = map[string]float64{}
+	for currency, price := range printing.Prices {
+		copied.Prices[currency] = price
+	}
+	copied.ImageURLs = map[string]string{}
+	for size, url := range printing.ImageURLs {
+		copied.ImageURLs[size] = url
+	}
+	return &copied
+}
+
+// MemoryTokenStore is a TokenStore keeping all tokens in memory, used by tests and when running without MongoDB
+type MemoryTokenStore struct {
+	mutex sync.RWMutex
+	// tokens are stored by their scryfall ID
+	tokens map[string]*Token
+}
+
+// NewMemoryTokenStore creates a MemoryTokenStore containing the given tokens
+func NewMemoryTokenStore(tokens ...*Token) *MemoryTokenStore {
+	store := &MemoryTokenStore{tokens: map[string]*Token{}}
+	store.CreateMany(context.Background(), tokens)
+	return store
+}
+
+// GetTokensCreatedBy returns copies of the tokens and emblems created by one of the cards with the oracle IDs, ordered by their name
+func (store *MemoryTokenStore) GetTokensCreatedBy(ctx context.Context, oracleIDs []string) ([]*Token, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	tokens := []*Token{}
+	for _, token := range store.tokens {
+		for _, oracleID := range oracleIDs {
+			if containsString(token.CreatedBy, oracleID) {
+				tokens = append(tokens, copyToken(token))
+				break
+			}
+		}
+	}
+	sort.SliceStable(tokens, func(i, j int) bool {
+		if tokens[i].IsEmblem != tokens[j].IsEmblem {
+			return !tokens[i].IsEmblem
+		}
+		return tokens[i].Name < tokens[j].Name
+	})
+	return tokens, nil
+}
+
+// CreateMany adds many tokens, an existing token with the same scryfall ID is replaced
+func (store *MemoryTokenStore) CreateMany(ctx context.Context, tokens []*Token) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, token := range tokens {
+		store.tokens[token.ScryfallID] = copyToken(token)
+	}
+	return nil
+}
+
+// DeleteAll removes all tokens
+func (store *MemoryTokenStore) DeleteAll(ctx context.Context) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.tokens = map[string]*Token{}
+	return nil
+}
+
+// NewStaging creates a staging, which replaces all tokens of the store on Commit
+func (store *MemoryTokenStore) NewStaging(ctx context.Context) (TokenStaging, error) {
+	return &memoryTokenStaging{store: store, tokens: map[string]*Token{}}, nil
+}
+
+type memoryTokenStaging struct {
+	store  *MemoryTokenStore
+	tokens map[string]*Token
+}
+
+func (staging *memoryTokenStaging) CreateMany(ctx context.Context, tokens []*Token) error {
+	for _, token := range tokens {
+		staging.tokens[token.ScryfallID] = copyToken(token)
+	}
+	return nil
+}
+
+func (staging *memoryTokenStaging) Commit(ctx context.Context) error {
+	staging.store.mutex.Lock()
+	defer staging.store.mutex.Unlock()
+
+	staging.store.tokens = staging.tokens
+	staging.tokens = map[string]*Token{}
+	return nil
+}
+
+func (staging *memoryTokenStaging) Discard(ctx context.Context) error {
+	staging.tokens = map[string]*Token{}
+	return nil
+}
+
+// copyToken copies the token, so callers can't modify the stored one
+func copyToken(token *Token) *Token {
+	copied := *token
+	copied.Colors = append([]string{}, token.Colors...)
+	copied.CreatedBy = append([]string{}, token.CreatedBy...)
+	copied.ImageURLs = map[string]string{}
+	for size, url := range token.ImageURLs {
+		copied.ImageURLs[size] = url
+	}
+	return &copied
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"log"
+
+	"github.com/maedu/mtg-cards/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Printing is a printing of a card in a set, the card is the one with the same oracle ID
+type Printing struct {
+	ScryfallID      string             `bson:"_id" json:"scryfallId"`
+	OracleID        string             `bson:"oracle_id" json:"oracleId"`
+	Name            string             `bson:"name" json:"name"`
+	SetCode         string             `bson:"set_code" json:"setCode"`
+	SetName         string             `bson:"set_name" json:"setName"`
+	CollectorNumber string             `bson:"collector_number" json:"collectorNumber"`
+	Rarity          string             `bson:"rarity" json:"rarity"`
+	ReleasedAt      string             `bson:"released_at" json:"releasedAt"`
+	Foil            bool               `bson:"foil" json:"foil"`
+	Nonfoil         bool               `bson:"nonfoil" json:"nonfoil"`
+	InBoosters      bool               `bson:"in_boosters" json:"inBoosters"`
+	Prices          map[string]float64 `bson:"prices" json:"prices"`
+	ImageURLs       map[string]string  `bson:"image_urls" json:"imageURLs"`
+}
+
+// PrintingCollection ...
+type PrintingCollection struct {
+	*mongo.Collection
+}
+
+// NewPrintingCollection creates the PrintingCollection using the shared client
+func NewPrintingCollection(client *mongo.Client) *PrintingCollection {
+	return &PrintingCollection{
+		Collection: client.Database(db.GetDatabaseName()).Collection("printings"),
+	}
+}
+
+// CreatePrintingIndexes creates the indexes used to find the printings of a card and of a set
+func CreatePrintingIndexes(ctx context.Context, database *mongo.Database) error {
+	models := []mongo.IndexModel{
+		{Keys: bson.M{"oracle_id": 1}},
+		{Keys: bson.M{"set_code": 1}},
+		{Keys: bson.M{"set_name": 1}},
+	}
+	_, err := database.Collection("printings").Indexes().CreateMany(ctx, models)
+	return err
+}
+
+// GetPrintingsByOracleID retrieves the printings of the card with the oracle ID, ordered by their release
+func (collection *PrintingCollection) GetPrintingsByOracleID(ctx context.Context, oracleID string) ([]*Printing, error) {
+	return collection.find(ctx, bson.M{"oracle_id": oracleID})
+}
+
+// GetPrintingsBySet retrieves the printings of the set, given by its code or its name
+func (collection *PrintingCollection) GetPrintingsBySet(ctx context.Context, set string) ([]*Printing, error) {
+	return collection.find(ctx, bson.M{"$or": bson.A{
+		bson.M{"set_code": set},
+		bson.M{"set_name": set},
+	}})
+}
+
+func (collection *PrintingCollection) find(ctx context.Context, filter bson.M) ([]*Printing, error) {
+	var printings []*Printing = []*Printing{}
+
+	opts := options.Find().SetSort(bson.D{{Key: "released_at", Value: 1}, {Key: "set_code", Value: 1}, {Key: "collector_number", Value: 1}})
+	cursor, err := collection.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &printings)
+	if err != nil {
+		log.Printf("Failed marshalling %v", err)
+		return nil, err
+	}
+	return printings, nil
+}
+
+// CreateMany creates many printings in a mongo, an existing printing with the same scryfall ID is replaced
+func (collection *PrintingCollection) CreateMany(ctx context.Context, printings []*Printing) error {
+	if len(printings) == 0 {
+		return nil
+	}
+	models := []mongo.WriteModel{}
+	for _, printing := range printings {
+		models = append(models, mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": printing.ScryfallID}).SetReplacement(printing).SetUpsert(true))
+	}
+	_, err := collection.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		log.Printf("Could not create Printing: %v", err)
+	}
+	return err
+}
+
+// DeleteAll printings in the collection
+func (collection *PrintingCollection) DeleteAll(ctx context.Context) error {
+	_, err := collection.Collection.DeleteMany(ctx, bson.M{})
+	return err
+}
@@ -0,0 +1,829 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Query is a parsed Scryfall-like search query, e.g. `t:creature o:"draw a card" id<=wub cmc>=3 -is:commander legal:modern`.
+// Terms are combined with AND, `or` combines the terms around it, `-` negates a term and parentheses group terms.
+type Query struct {
+	root queryNode
+}
+
+// QueryError is returned by ParseQuery for an invalid query, Position is the 1-based position of the problem
+type QueryError struct {
+	Message  string `json:"message"`
+	Position int    `json:"position"`
+}
+
+func (err *QueryError) Error() string {
+	return fmt.Sprintf("%s at position %d", err.Message, err.Position)
+}
+
+// queryNode is a part of a query, it is compiled into a bson filter for Mongo and evaluated directly for the memory store
+type queryNode interface {
+	filter() bson.M
+	matches(card *Card) bool
+}
+
+type andNode []queryNode
+
+func (nodes andNode) filter() bson.M {
+	filters := bson.A{}
+	for _, node := range nodes {
+		filters = append(filters, node.filter())
+	}
+	return bson.M{"$and": filters}
+}
+
+func (nodes andNode) matches(card *Card) bool {
+	for _, node := range nodes {
+		if !node.matches(card) {
+			return false
+		}
+	}
+	return true
+}
+
+type orNode []queryNode
+
+func (nodes orNode) filter() bson.M {
+	filters := bson.A{}
+	for _, node := range nodes {
+		filters = append(filters, node.filter())
+	}
+	return bson.M{"$or": filters}
+}
+
+func (nodes orNode) matches(card *Card) bool {
+	for _, node := range nodes {
+		if node.matches(card) {
+			return true
+		}
+	}
+	return false
+}
+
+type notNode struct {
+	node queryNode
+}
+
+func (node notNode) filter() bson.M {
+	return bson.M{"$nor": bson.A{node.node.filter()}}
+}
+
+func (node notNode) matches(card *Card) bool {
+	return !node.node.matches(card)
+}
+
+// condition is a single term of the query, e.g. `cmc>=3`
+type condition struct {
+	bson  bson.M
+	match func(card *Card) bool
+}
+
+func (node condition) filter() bson.M {
+	return node.bson
+}
+
+func (node condition) matches(card *Card) bool {
+	return node.match(card)
+}
+
+// ParseQuery parses the query, an empty query matches all cards
+func ParseQuery(query string) (*Query, error) {
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &queryParser{tokens: tokens, length: len(query)}
+	if len(tokens) == 0 {
+		return &Query{}, nil
+	}
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token, ok := parser.peek(); ok {
+		return nil, &QueryError{Message: fmt.Sprintf("unexpected %q", token.text), Position: token.position}
+	}
+	return &Query{root: root}, nil
+}
+
+// filter returns the bson filter of the query, nil if it matches all cards
+func (query *Query) filter() bson.M {
+	if query == nil || query.root == nil {
+		return nil
+	}
+	return query.root.filter()
+}
+
+func (query *Query) matches(card *Card) bool {
+	if query == nil || query.root == nil {
+		return true
+	}
+	return query.root.matches(card)
+}
+
+type tokenKind int
+
+const (
+	termToken tokenKind = iota
+	orToken
+	andToken
+	notToken
+	openToken
+	closeToken
+)
+
+type queryToken struct {
+	kind     tokenKind
+	text     string
+	position int
+
+	// key, operator and value of a term, the key is empty for a bare word
+	key      string
+	operator string
+	value    string
+}
+
+var queryOperators = []string{"!=", "<=", ">=", ":", "=", "<", ">"}
+
+func tokenizeQuery(query string) ([]queryToken, error) {
+	tokens := []queryToken{}
+	i := 0
+	for i < len(query) {
+		char := query[i]
+		switch {
+		case char == ' ' || char == '\t' || char == '\n':
+			i++
+		case char == '(':
+			tokens = append(tokens, queryToken{kind: openToken, text: "(", position: i + 1})
+			i++
+		case char == ')':
+			tokens = append(tokens, queryToken{kind: closeToken, text: ")", position: i + 1})
+			i++
+		case char == '-':
+			if i+1 >= len(query) || strings.ContainsRune(" \t\n)", rune(query[i+1])) {
+				return nil, &QueryError{Message: "expected a term after -", Position: i + 1}
+			}
+			tokens = append(tokens, queryToken{kind: notToken, text: "-", position: i + 1})
+			i++
+		default:
+			token, next, err := readTerm(query, i)
+			if err != nil {
+				return nil, err
+			}
+			if token.kind != andToken {
+				// Terms are combined with AND anyway
+				tokens = append(tokens, token)
+			}
+			i = next
+		}
+	}
+	return tokens, nil
+}
+
+// readTerm reads a term starting at start, either `key<operator>value` or a bare word or quoted phrase
+func readTerm(query string, start int) (queryToken, int, error) {
+	token := queryToken{kind: termToken, position: start + 1}
+
+	keyEnd := start
+	for keyEnd < len(query) && isKeyChar(query[keyEnd]) {
+		keyEnd++
+	}
+	if keyEnd > start {
+		for _, operator := range queryOperators {
+			if strings.HasPrefix(query[keyEnd:], operator) {
+				token.key = strings.ToLower(query[start:keyEnd])
+				token.operator = operator
+				valueStart := keyEnd + len(operator)
+				value, next, err := readValue(query, valueStart)
+				if err != nil {
+					return token, 0, err
+				}
+				if value == "" {
+					return token, 0, &QueryError{Message: fmt.Sprintf("missing value after %s%s", token.key, operator), Position: valueStart + 1}
+				}
+				token.value = value
+				token.text = query[start:next]
+				return token, next, nil
+			}
+		}
+	}
+
+	value, next, err := readValue(query, start)
+	if err != nil {
+		return token, 0, err
+	}
+	token.value = value
+	token.text = query[start:next]
+	if strings.EqualFold(token.text, "or") {
+		token.kind = orToken
+	} else if strings.EqualFold(token.text, "and") {
+		token.kind = andToken
+	}
+	return token, next, nil
+}
+
+// readValue reads a quoted phrase or a word ending before a space or a closing parenthesis
+func readValue(query string, start int) (string, int, error) {
+	if start < len(query) && query[start] == '"' {
+		end := strings.IndexByte(query[start+1:], '"')
+		if end < 0 {
+			return "", 0, &QueryError{Message: "missing closing quote", Position: start + 1}
+		}
+		return query[start+1 : start+1+end], start + end + 2, nil
+	}
+
+	end := start
+	for end < len(query) && !strings.ContainsRune(" \t\n()", rune(query[end])) {
+		end++
+	}
+	return query[start:end], end, nil
+}
+
+func isKeyChar(char byte) bool {
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+type queryParser struct {
+	tokens []queryToken
+	index  int
+	length int
+}
+
+func (parser *queryParser) peek() (queryToken, bool) {
+	if parser.index >= len(parser.tokens) {
+		return queryToken{}, false
+	}
+	return parser.tokens[parser.index], true
+}
+
+func (parser *queryParser) parseOr() (queryNode, error) {
+	nodes := orNode{}
+	for {
+		node, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+
+		token, ok := parser.peek()
+		if !ok || token.kind != orToken {
+			break
+		}
+		parser.index++
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (parser *queryParser) parseAnd() (queryNode, error) {
+	nodes := andNode{}
+	for {
+		token, ok := parser.peek()
+		if !ok || token.kind == orToken || token.kind == closeToken {
+			break
+		}
+		node, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	if len(nodes) == 0 {
+		token, ok := parser.peek()
+		if !ok {
+			return nil, &QueryError{Message: "expected a term", Position: parser.length + 1}
+		}
+		return nil, &QueryError{Message: fmt.Sprintf("expected a term before %q", token.text), Position: token.position}
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (parser *queryParser) parseUnary() (queryNode, error) {
+	token, _ := parser.peek()
+	parser.index++
+
+	switch token.kind {
+	case notToken:
+		next, ok := parser.peek()
+		if !ok || next.kind == orToken || next.kind == closeToken {
+			return nil, &QueryError{Message: "expected a term after -", Position: token.position}
+		}
+		node, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node: node}, nil
+	case openToken:
+		node, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing, ok := parser.peek()
+		if !ok || closing.kind != closeToken {
+			return nil, &QueryError{Message: "missing closing parenthesis", Position: token.position}
+		}
+		parser.index++
+		return node, nil
+	}
+	return compileTerm(token)
+}
+
+// compileTerm compiles a single term into the condition of its key
+func compileTerm(token queryToken) (queryNode, error) {
+	fail := func(format string, args ...interface{}) (queryNode, error) {
+		return nil, &QueryError{Message: fmt.Sprintf(format, args...), Position: token.position}
+	}
+
+	switch token.key {
+	case "":
+		return textCondition(token.value, func(card *Card) []string { return []string{card.Name} }, "name"), nil
+	case "name", "n":
+		if !isTextOperator(token.operator) {
+			return fail("%s only supports :", token.key)
+		}
+		return textCondition(token.value, func(card *Card) []string { return []string{card.Name} }, "name"), nil
+	case "t", "type":
+		if !isTextOperator(token.operator) {
+			return fail("%s only supports :", token.key)
+		}
+		return textCondition(token.value, func(card *Card) []string { return []string{card.TypeLine} }, "type_line"), nil
+	case "o", "oracle":
+		if !isTextOperator(token.operator) {
+			return fail("%s only supports :", token.key)
+		}
+		return textCondition(token.value, oracleTexts, "oracle_text", "card_faces.oracle_text"), nil
+	case "kw", "keyword":
+		if !isTextOperator(token.operator) {
+			return fail("%s only supports :", token.key)
+		}
+		return equalFoldCondition(token.value, func(card *Card) []string { return card.Keywords }, "keywords"), nil
+	case "s", "e", "set":
+		if !isTextOperator(token.operator) {
+			return fail("%s only supports :", token.key)
+		}
+		return equalFoldCondition(token.value, func(card *Card) []string { return []string{card.SetName} }, "set_name"), nil
+	case "g", "group":
+		if !isTextOperator(token.operator) {
+			return fail("%s only supports :", token.key)
+		}
+		return equalFoldCondition(token.value, func(card *Card) []string { return card.CardGroups }, "card_groups"), nil
+	case "c", "color":
+		operator := token.operator
+		if operator == ":" {
+			operator = ">="
+		}
+		return colorCondition(token, operator, "colors", func(card *Card) []string { return card.Colors })
+	case "id", "identity", "ci":
+		operator := token.operator
+		if operator == ":" {
+			operator = "<="
+		}
+		return colorCondition(token, operator, "coloridentity", func(card *Card) []string { return card.ColorIdentity })
+	case "cmc", "mv":
+		return numberCondition(token, "cmc", func(card *Card) float64 { return card.Cmc })
+	case "usd", "price":
+		return numberCondition(token, "price", func(card *Card) float64 { return card.Price })
+	case "pow", "power":
+		return statCondition(token, "power_value", func(card *Card) *float64 { return card.PowerValue })
+	case "tou", "toughness":
+		return statCondition(token, "toughness_value", func(card *Card) *float64 { return card.ToughnessValue })
+	case "loy", "loyalty":
+		return statCondition(token, "loyalty_value", func(card *Card) *float64 { return card.LoyaltyValue })
+	case "produces":
+		if !isTextOperator(token.operator) {
+			return fail("%s only supports :", token.key)
+		}
+		return producesCondition(token)
+	case "r", "rarity":
+		return rarityCondition(token)
+	case "legal", "f", "format", "banned", "restricted":
+		if !isTextOperator(token.operator) {
+			return fail("%s only supports :", token.key)
+		}
+		format := strings.ToLower(token.value)
+		if !IsFormat(format) {
+			return fail("unknown format %q", token.value)
+		}
+		switch token.key {
+		case "banned":
+			return legalityCondition(format, Banned), nil
+		case "restricted":
+			return legalityCondition(format, Restricted), nil
+		}
+		return legalityCondition(format, Legal, Restricted), nil
+	case "is", "not":
+		if !isTextOperator(token.operator) {
+			return fail("%s only supports :", token.key)
+		}
+		node, err := isCondition(token)
+		if err != nil {
+			return nil, err
+		}
+		if token.key == "not" {
+			return notNode{node: node}, nil
+		}
+		return node, nil
+	}
+	return fail("unknown keyword %q", token.key)
+}
+
+func isTextOperator(operator string) bool {
+	return operator == ":" || operator == "="
+}
+
+func oracleTexts(card *Card) []string {
+	texts := []string{card.OracleText}
+	for _, cardFace := range card.CardFaces {
+		texts = append(texts, cardFace.OracleText)
+	}
+	return texts
+}
+
+// textCondition matches cards where one of the fields contains the value, ignoring the case
+func textCondition(value string, values func(card *Card) []string, fields ...string) queryNode {
+	regex := primitive.Regex{Pattern: regexp.QuoteMeta(value), Options: "i"}
+	lowerValue := strings.ToLower(value)
+	return condition{
+		bson: anyField(fields, regex),
+		match: func(card *Card) bool {
+			for _, text := range values(card) {
+				if strings.Contains(strings.ToLower(text), lowerValue) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// equalFoldCondition matches cards where one of the fields equals the value, ignoring the case
+func equalFoldCondition(value string, values func(card *Card) []string, field string) queryNode {
+	regex := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(value) + "$", Options: "i"}
+	return condition{
+		bson: bson.M{field: regex},
+		match: func(card *Card) bool {
+			for _, text := range values(card) {
+				if strings.EqualFold(text, value) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+func anyField(fields []string, value interface{}) bson.M {
+	if len(fields) == 1 {
+		return bson.M{fields[0]: value}
+	}
+	filters := bson.A{}
+	for _, field := range fields {
+		filters = append(filters, bson.M{field: value})
+	}
+	return bson.M{"$or": filters}
+}
+
+var numberComparisons = map[string]string{
+	":":  "$eq",
+	"=":  "$eq",
+	"!=": "$ne",
+	"<":  "$lt",
+	"<=": "$lte",
+	">":  "$gt",
+	">=": "$gte",
+}
+
+func compareNumbers(operator string, a float64, b float64) bool {
+	switch operator {
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return a == b
+}
+
+func numberCondition(token queryToken, field string, value func(card *Card) float64) (queryNode, error) {
+	number, err := strconv.ParseFloat(token.value, 64)
+	if err != nil {
+		return nil, &QueryError{Message: fmt.Sprintf("%s needs a number, got %q", token.key, token.value), Position: token.position}
+	}
+	return condition{
+		bson: bson.M{field: bson.M{numberComparisons[token.operator]: number}},
+		match: func(card *Card) bool {
+			return compareNumbers(token.operator, value(card), number)
+		},
+	}, nil
+}
+
+// statCondition compares the power, toughness or loyalty of the card or one of its faces, cards without the value never match
+func statCondition(token queryToken, field string, value func(card *Card) *float64) (queryNode, error) {
+	number, err := strconv.ParseFloat(token.value, 64)
+	if err != nil {
+		return nil, &QueryError{Message: fmt.Sprintf("%s needs a number, got %q", token.key, token.value), Position: token.position}
+	}
+	comparison := bson.M{"$exists": true, numberComparisons[token.operator]: number}
+	return condition{
+		bson: anyField([]string{field, "card_faces." + field}, comparison),
+		match: func(card *Card) bool {
+			values := []*float64{value(card)}
+			for i := range card.CardFaces {
+				values = append(values, value(&card.CardFaces[i]))
+			}
+			for _, cardValue := range values {
+				if cardValue != nil && compareNumbers(token.operator, *cardValue, number) {
+					return true
+				}
+			}
+			return false
+		},
+	}, nil
+}
+
+// producesCondition matches the cards producing all of the mana, e.g. `produces:gc` or `produces:green`
+func producesCondition(token queryToken) (queryNode, error) {
+	value := strings.ToLower(token.value)
+	mana := []string{}
+	if color, ok := colorNames[value]; ok {
+		mana = append(mana, color)
+	} else if value == "colorless" {
+		mana = append(mana, "C")
+	} else {
+		for _, symbol := range strings.ToUpper(value) {
+			if !containsString(allColors, string(symbol)) && symbol != 'C' {
+				return nil, &QueryError{Message: fmt.Sprintf("unknown mana %q", token.value), Position: token.position}
+			}
+			mana = append(mana, string(symbol))
+		}
+	}
+	return condition{
+		bson: bson.M{"produced_mana": bson.M{"$all": mana}},
+		match: func(card *Card) bool {
+			for _, symbol := range mana {
+				if !containsString(card.ProducedMana, symbol) {
+					return false
+				}
+			}
+			return true
+		},
+	}, nil
+}
+
+// rarities are ordered from the lowest to the highest rarity
+var rarities = []string{"common", "uncommon", "rare", "special", "mythic", "bonus"}
+
+func rarityCondition(token queryToken) (queryNode, error) {
+	value := strings.ToLower(token.value)
+	rank := -1
+	for i, rarity := range rarities {
+		if value == rarity || value == rarity[:1] {
+			rank = i
+			break
+		}
+	}
+	if rank < 0 {
+		return nil, &QueryError{Message: fmt.Sprintf("unknown rarity %q", token.value), Position: token.position}
+	}
+
+	matching := []string{}
+	for i, rarity := range rarities {
+		if compareNumbers(token.operator, float64(i), float64(rank)) {
+			matching = append(matching, rarity)
+		}
+	}
+	return condition{
+		bson: bson.M{"rarity": bson.M{"$in": matching}},
+		match: func(card *Card) bool {
+			return containsString(matching, card.Rarity)
+		},
+	}, nil
+}
+
+func isCondition(token queryToken) (queryNode, error) {
+	switch strings.ToLower(token.value) {
+	case "commander":
+		return condition{
+			bson:  bson.M{"is_commander": true},
+			match: func(card *Card) bool { return card.IsCommander },
+		}, nil
+	case "land":
+		return condition{
+			bson:  bson.M{"is_land": true},
+			match: func(card *Card) bool { return card.IsLand },
+		}, nil
+	case "reserved":
+		return condition{
+			bson:  bson.M{"reserved": true},
+			match: func(card *Card) bool { return card.Reserved },
+		}, nil
+	case "digital":
+		return condition{
+			bson:  bson.M{"digital": true},
+			match: func(card *Card) bool { return card.Digital },
+		}, nil
+	case "promo":
+		return condition{
+			bson:  bson.M{"promo": true},
+			match: func(card *Card) bool { return card.Promo },
+		}, nil
+	}
+	return nil, &QueryError{Message: fmt.Sprintf("unknown value %q for %s", token.value, token.key), Position: token.position}
+}
+
+var (
+	allColors = []string{"W", "U", "B", "R", "G"}
+
+	colorNames = map[string]string{
+		"white": "W",
+		"blue":  "U",
+		"black": "B",
+		"red":   "R",
+		"green": "G",
+	}
+)
+
+// parseColors parses colors like `wub` or `blue`, colorless and multicolor are returned separately
+func parseColors(value string) (colors []string, colorless bool, multicolor bool, ok bool) {
+	value = strings.ToLower(value)
+	switch value {
+	case "c", "colorless":
+		return []string{}, true, false, true
+	case "m", "multicolor":
+		return []string{}, false, true, true
+	}
+	if color, found := colorNames[value]; found {
+		return []string{color}, false, false, true
+	}
+
+	colors = []string{}
+	for _, char := range strings.ToUpper(value) {
+		color := string(char)
+		if !containsString(allColors, color) {
+			return nil, false, false, false
+		}
+		if !containsString(colors, color) {
+			colors = append(colors, color)
+		}
+	}
+	return colors, false, false, true
+}
+
+// colorCondition compares the colors of the field with the operator, e.g. <= matches cards whose colors are a subset.
+// Colorless cards have no colors, although the colors of the cards contain "C" for them.
+func colorCondition(token queryToken, operator string, field string, values func(card *Card) []string) (queryNode, error) {
+	colors, colorless, multicolor, ok := parseColors(token.value)
+	if !ok {
+		return nil, &QueryError{Message: fmt.Sprintf("unknown color %q", token.value), Position: token.position}
+	}
+
+	if multicolor {
+		if !isTextOperator(token.operator) {
+			return nil, &QueryError{Message: fmt.Sprintf("%s:m only supports : and =", token.key), Position: token.position}
+		}
+		return condition{
+			bson:  bson.M{field + ".1": bson.M{"$exists": true}},
+			match: func(card *Card) bool { return len(cardColors(values(card))) > 1 },
+		}, nil
+	}
+	if colorless && operator == ">=" {
+		// c:c means colorless, not at least no colors
+		operator = "="
+	}
+
+	otherColors := []string{}
+	for _, color := range allColors {
+		if !containsString(colors, color) {
+			otherColors = append(otherColors, color)
+		}
+	}
+	// hasAll and hasOther are the filters and checks the operators are combined of
+	hasAll := bson.M{field: bson.M{"$all": colors}}
+	hasOther := bson.M{field: bson.M{"$elemMatch": bson.M{"$in": otherColors}}}
+	if len(colors) == 0 {
+		hasAll = bson.M{}
+	}
+	if len(otherColors) == 0 {
+		hasOther = bson.M{field: bson.M{"$in": bson.A{}}}
+	}
+	checkAll := func(card *Card) bool {
+		actual := cardColors(values(card))
+		for _, color := range colors {
+			if !containsString(actual, color) {
+				return false
+			}
+		}
+		return true
+	}
+	checkOther := func(card *Card) bool {
+		for _, color := range cardColors(values(card)) {
+			if !containsString(colors, color) {
+				return true
+			}
+		}
+		return false
+	}
+	not := func(filter bson.M) bson.M { return bson.M{"$nor": bson.A{filter}} }
+
+	switch operator {
+	case "=":
+		return condition{
+			bson:  bson.M{"$and": bson.A{hasAll, not(hasOther)}},
+			match: func(card *Card) bool { return checkAll(card) && !checkOther(card) },
+		}, nil
+	case "!=":
+		return condition{
+			bson:  bson.M{"$or": bson.A{not(hasAll), hasOther}},
+			match: func(card *Card) bool { return !checkAll(card) || checkOther(card) },
+		}, nil
+	case ">=":
+		return condition{
+			bson:  hasAll,
+			match: checkAll,
+		}, nil
+	case ">":
+		return condition{
+			bson:  bson.M{"$and": bson.A{hasAll, hasOther}},
+			match: func(card *Card) bool { return checkAll(card) && checkOther(card) },
+		}, nil
+	case "<=":
+		return condition{
+			bson:  not(hasOther),
+			match: func(card *Card) bool { return !checkOther(card) },
+		}, nil
+	}
+	// <
+	return condition{
+		bson:  bson.M{"$and": bson.A{not(hasOther), not(hasAll)}},
+		match: func(card *Card) bool { return !checkOther(card) && !checkAll(card) },
+	}, nil
+}
+
+// cardColors returns the colors without "C", which marks colorless cards
+func cardColors(colors []string) []string {
+	actual := []string{}
+	for _, color := range colors {
+		if color != "C" {
+			actual = append(actual, color)
+		}
+	}
+	return actual
+}
+
+const (
+	// IdentitySubset matches cards whose color identity is within the given one, e.g. the cards allowed in a commander deck
+	IdentitySubset = "subset"
+	// IdentityExact matches cards with exactly the given color identity
+	IdentityExact = "exact"
+)
+
+// ColorIdentityQuery returns a query matching the cards by their color identity, an empty identity is colorless
+func ColorIdentityQuery(colors []string, mode string) (*Query, error) {
+	operator := "<="
+	switch mode {
+	case IdentitySubset, "":
+	case IdentityExact:
+		operator = "="
+	default:
+		return nil, fmt.Errorf("unknown identity mode %q", mode)
+	}
+
+	value := "c"
+	if len(colors) > 0 {
+		value = ""
+		for _, color := range colors {
+			color = strings.ToUpper(color)
+			if !containsString(allColors, color) {
+				return nil, fmt.Errorf("unknown color %q", color)
+			}
+			value += color
+		}
+	}
+	node, err := colorCondition(queryToken{key: "identity", value: value}, operator, "coloridentity", func(card *Card) []string { return card.ColorIdentity })
+	if err != nil {
+		return nil, err
+	}
+	return &Query{root: node}, nil
+}
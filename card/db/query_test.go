@@ -0,0 +1,128 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query    string
+		message  string
+		position int
+	}{
+		{`o:"draw a card`, "missing closing quote", 3},
+		{`cmc>=`, "missing value after cmc>=", 6},
+		{`cmc>=three`, `cmc needs a number, got "three"`, 1},
+		{`t:creature foo:bar`, `unknown keyword "foo"`, 12},
+		{`id<=wxb`, `unknown color "wxb"`, 1},
+		{`r:epic`, `unknown rarity "epic"`, 1},
+		{`is:foil`, `unknown value "foil" for is`, 1},
+		{`legal:casual`, `unknown format "casual"`, 1},
+		{`banned>modern`, "banned only supports :", 1},
+		{`pow>=x`, `pow needs a number, got "x"`, 1},
+		{`produces:gx`, `unknown mana "gx"`, 1},
+		{`t>creature`, "t only supports :", 1},
+		{`(t:creature`, "missing closing parenthesis", 1},
+		{`t:creature)`, `unexpected ")"`, 11},
+		{`t:creature or`, "expected a term", 14},
+		{`or t:creature`, `expected a term before "or"`, 1},
+		{`t:creature -`, "expected a term after -", 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQuery(tt.query)
+			queryErr, ok := err.(*QueryError)
+			if !ok {
+				t.Fatalf("ParseQuery() error = %v, want a QueryError", err)
+			}
+			if queryErr.Message != tt.message || queryErr.Position != tt.position {
+				t.Errorf("ParseQuery() error = %q at %d, want %q at %d", queryErr.Message, queryErr.Position, tt.message, tt.position)
+			}
+		})
+	}
+}
+
+func TestQueryFilter(t *testing.T) {
+	query, err := ParseQuery(`t:creature -is:commander (cmc>=3 or usd<5)`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got := fmt.Sprint(query.filter())
+	want := fmt.Sprint(bson.M{"$and": bson.A{
+		bson.M{"type_line": primitive.Regex{Pattern: "creature", Options: "i"}},
+		bson.M{"$nor": bson.A{bson.M{"is_commander": true}}},
+		bson.M{"$or": bson.A{
+			bson.M{"cmc": bson.M{"$gte": 3.0}},
+			bson.M{"price": bson.M{"$lt": 5.0}},
+		}},
+	}})
+	if got != want {
+		t.Errorf("filter() = %v, want %v", got, want)
+	}
+}
+
+func TestQueryMatches(t *testing.T) {
+	value := func(number float64) *float64 { return &number }
+	cards := []*Card{
+		{Name: "Sol Ring", TypeLine: "Artifact", Colors: []string{"C"}, Cmc: 1, Rarity: "uncommon", Price: 1.5, ProducedMana: []string{"C"},
+			Legalities: map[string]string{"commander": Legal, "vintage": Restricted, "legacy": Banned, "modern": NotLegal}},
+		{Name: "Llanowar Elves", TypeLine: "Creature — Elf Druid", Colors: []string{"G"}, ColorIdentity: []string{"G"}, Cmc: 1, Rarity: "common", Price: 0.2, ProducedMana: []string{"G"}, PowerValue: value(1), ToughnessValue: value(1),
+			Legalities: map[string]string{"commander": Legal, "vintage": Legal, "legacy": Legal, "modern": Legal}},
+		{Name: "Baleful Strix", TypeLine: "Artifact Creature — Bird", OracleText: "Flying, deathtouch\nWhen Baleful Strix enters the battlefield, draw a card.", Colors: []string{"B", "U"}, ColorIdentity: []string{"B", "U"}, Keywords: []string{"Flying", "Deathtouch"}, Cmc: 2, Rarity: "uncommon", Price: 3,
+			Digital: true, CardFaces: []Card{{Name: "Baleful Strix", PowerValue: value(1), ToughnessValue: value(1)}}},
+		{Name: "Atraxa, Praetors' Voice", TypeLine: "Legendary Creature — Phyrexian Angel Horror", Colors: []string{"B", "G", "U", "W"}, ColorIdentity: []string{"B", "G", "U", "W"}, Keywords: []string{"Flying"}, Cmc: 4, Rarity: "mythic", Price: 20, IsCommander: true,
+			PowerValue: value(4), ToughnessValue: value(4), Reserved: true},
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{`t:creature`, []string{"Llanowar Elves", "Baleful Strix", "Atraxa, Praetors' Voice"}},
+		{`o:"draw a card"`, []string{"Baleful Strix"}},
+		{`id<=wub`, []string{"Sol Ring", "Baleful Strix"}},
+		{`c:ub`, []string{"Baleful Strix", "Atraxa, Praetors' Voice"}},
+		{`c=ub`, []string{"Baleful Strix"}},
+		{`c:c`, []string{"Sol Ring"}},
+		{`c:m`, []string{"Baleful Strix", "Atraxa, Praetors' Voice"}},
+		{`cmc>=2 r>=rare`, []string{"Atraxa, Praetors' Voice"}},
+		{`r:u`, []string{"Sol Ring", "Baleful Strix"}},
+		{`kw:flying usd<5`, []string{"Baleful Strix"}},
+		{`t:creature -is:commander`, []string{"Llanowar Elves", "Baleful Strix"}},
+		{`ring or elves`, []string{"Sol Ring", "Llanowar Elves"}},
+		{`-(t:artifact or is:commander)`, []string{"Llanowar Elves"}},
+		{`legal:vintage`, []string{"Sol Ring", "Llanowar Elves"}},
+		{`f:Modern`, []string{"Llanowar Elves"}},
+		{`banned:legacy or restricted:vintage`, []string{"Sol Ring"}},
+		{`pow>=1 tou<4`, []string{"Llanowar Elves", "Baleful Strix"}},
+		{`pow!=1`, []string{"Atraxa, Praetors' Voice"}},
+		{`loy>=0`, []string{}},
+		{`produces:g or produces:colorless`, []string{"Sol Ring", "Llanowar Elves"}},
+		{`produces:gc`, []string{}},
+		{`-is:digital is:reserved`, []string{"Atraxa, Praetors' Voice"}},
+		// Cards without legalities are only known to be legal in commander
+		{`legal:commander t:creature`, []string{"Llanowar Elves", "Baleful Strix", "Atraxa, Praetors' Voice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			got := []string{}
+			for _, card := range cards {
+				if query.matches(card) {
+					got = append(got, card.Name)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
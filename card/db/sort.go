@@ -0,0 +1,371 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// missingSynergy is the synergy of cards without synergy with the main card,
+	// it is lower than all synergies, as MongoDB sorts missing fields first
+	missingSynergy = -1000.0
+
+	landKey  = "is_land"
+	scoreKey = "score"
+	idKey    = "_id"
+
+	// combinedSynergyKey is the field added with the combined synergy of several main cards, which find can't sort by
+	combinedSynergyKey = "combined_synergy"
+)
+
+// SortKey is a field the cards are sorted by
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// sortFields are the fields which can be sorted by, with the name used in the sort spec
+var sortFields = map[string]string{
+	"name":       "name",
+	"cmc":        "cmc",
+	"price":      "price",
+	"edhrecrank": "edhrecrank",
+	"synergy":    "synergy",
+	"score":      scoreKey,
+	"relevance":  scoreKey,
+}
+
+// IsSortByField returns true if the cards can be sorted by the field with sortBy, the text score only with ParseSort
+func IsSortByField(field string) bool {
+	sortField, ok := sortFields[field]
+	return ok && sortField != scoreKey
+}
+
+// ParseSort parses a sort spec like `synergy desc, price asc, name`, keys are ascending by default, except score
+func ParseSort(spec string) ([]SortKey, error) {
+	keys := []SortKey{}
+	if strings.TrimSpace(spec) == "" {
+		return keys, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		words := strings.Fields(strings.ToLower(part))
+		if len(words) == 0 || len(words) > 2 {
+			return nil, fmt.Errorf("invalid sort key %q", strings.TrimSpace(part))
+		}
+		field, ok := sortFields[words[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q", words[0])
+		}
+		key := SortKey{Field: field, Desc: field == scoreKey}
+		if len(words) == 2 {
+			switch words[1] {
+			case "asc":
+				key.Desc = false
+			case "desc":
+				key.Desc = true
+			default:
+				return nil, fmt.Errorf("invalid sort direction %q", words[1])
+			}
+		}
+		if key.Field == scoreKey && !key.Desc {
+			return nil, errors.New("score can only be sorted descending")
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// sortKeys returns all keys the cards of the request are sorted by. Lands come last, unless only sorted by the text score,
+// the text score is added after the requested keys and the ID makes the order stable.
+func sortKeys(request CardSearchRequest) []SortKey {
+	requested := request.Sort
+	if len(requested) == 0 && request.SortBy != "" {
+		if field, ok := sortFields[request.SortBy]; ok && field != scoreKey {
+			requested = []SortKey{{Field: field, Desc: request.SortDir == "desc"}}
+		}
+	}
+	hasText := strings.TrimSpace(request.Text) != ""
+
+	keys := []SortKey{}
+	if len(requested) > 0 || !hasText {
+		keys = append(keys, SortKey{Field: landKey})
+	}
+	hasScore := false
+	for _, key := range requested {
+		if key.Field == scoreKey {
+			if !hasText {
+				continue
+			}
+			hasScore = true
+		}
+		keys = append(keys, key)
+	}
+	if hasText && !hasScore {
+		keys = append(keys, SortKey{Field: scoreKey, Desc: true})
+	}
+	return append(keys, SortKey{Field: idKey})
+}
+
+// getSortOptions returns the sort of the find or paged aggregate query
+func getSortOptions(request CardSearchRequest) bson.D {
+	sort := bson.D{}
+	for _, key := range sortKeys(request) {
+		switch key.Field {
+		case scoreKey:
+			sort = append(sort, primitive.E{Key: scoreKey, Value: bson.M{"$meta": "textScore"}})
+		case "synergy":
+			if spec := synergySpec(request); len(spec.MainCards) > 1 {
+				sort = append(sort, primitive.E{Key: combinedSynergyKey, Value: direction(key)})
+			} else {
+				sort = append(sort, primitive.E{Key: "synergies." + spec.MainCards[0], Value: direction(key)})
+			}
+		default:
+			sort = append(sort, primitive.E{Key: key.Field, Value: direction(key)})
+		}
+	}
+	return sort
+}
+
+// sortsByCombinedSynergy returns true if the cards are sorted by the synergy with several main cards,
+// the query has to be aggregated with the combined synergy added by addCombinedSynergy
+func sortsByCombinedSynergy(request CardSearchRequest) bool {
+	if len(synergySpec(request).MainCards) < 2 {
+		return false
+	}
+	for _, key := range sortKeys(request) {
+		if key.Field == "synergy" {
+			return true
+		}
+	}
+	return false
+}
+
+// addCombinedSynergy returns the projection with the combined synergy sorted by getSortOptions
+func addCombinedSynergy(projection bson.M, request CardSearchRequest) bson.M {
+	result := bson.M{combinedSynergyKey: bson.M{"$ifNull": bson.A{synergySpec(request).expression(), missingSynergy}}}
+	for field, value := range projection {
+		result[field] = value
+	}
+	return result
+}
+
+func direction(key SortKey) int {
+	if key.Desc {
+		return -1
+	}
+	return 1
+}
+
+// sortValueField is the field added to the documents with the value of the i-th sort key, used for the cursor
+func sortValueField(i int) string {
+	return fmt.Sprintf("sort_%d", i)
+}
+
+// sortValueStages returns the stages adding the values of the sort keys as fields and sorting by them
+func sortValueStages(request CardSearchRequest) (addFields bson.M, sort bson.D) {
+	addFields = bson.M{}
+	sort = bson.D{}
+	for i, key := range sortKeys(request) {
+		if key.Field == idKey {
+			sort = append(sort, primitive.E{Key: idKey, Value: direction(key)})
+			continue
+		}
+		var value interface{}
+		switch key.Field {
+		case scoreKey:
+			value = bson.M{"$meta": "textScore"}
+		case "synergy":
+			value = bson.M{"$ifNull": bson.A{synergySpec(request).expression(), missingSynergy}}
+		default:
+			value = "$" + key.Field
+		}
+		addFields[sortValueField(i)] = value
+		sort = append(sort, primitive.E{Key: sortValueField(i), Value: direction(key)})
+	}
+	return addFields, sort
+}
+
+// sortValue returns the value of the card for the sort key, like the value added by sortValueStages
+func sortValue(card *Card, key SortKey, request CardSearchRequest) interface{} {
+	switch key.Field {
+	case landKey:
+		return card.IsLand
+	case "name":
+		return card.Name
+	case "cmc":
+		return card.Cmc
+	case "price":
+		return card.Price
+	case "edhrecrank":
+		return float64(card.EdhrecRank)
+	case "synergy":
+		if synergy, ok := synergySpec(request).combine(card); ok {
+			return synergy
+		}
+		return missingSynergy
+	case scoreKey:
+		return card.Score
+	}
+	return card.ID.Hex()
+}
+
+// compareValues compares two sort values of the same key, like MongoDB for values of the same type
+func compareValues(a interface{}, b interface{}) int {
+	switch a := a.(type) {
+	case bool:
+		b, _ := b.(bool)
+		if a == b {
+			return 0
+		} else if !a {
+			return -1
+		}
+		return 1
+	case float64:
+		b, _ := b.(float64)
+		if a < b {
+			return -1
+		} else if a > b {
+			return 1
+		}
+		return 0
+	case string:
+		b, _ := b.(string)
+		return strings.Compare(a, b)
+	}
+	return 0
+}
+
+// compareCard compares the card with the values of the sort keys, e.g. of a cursor, negative if the card comes first
+func compareCard(card *Card, values []interface{}, keys []SortKey, request CardSearchRequest) int {
+	for i, key := range keys {
+		result := compareValues(sortValue(card, key, request), values[i])
+		if key.Desc {
+			result = -result
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+// sortCards sorts the cards like the options of getSortOptions
+func sortCards(cards []*Card, request CardSearchRequest) {
+	keys := sortKeys(request)
+	sort.SliceStable(cards, func(i, j int) bool {
+		return compareCard(cards[i], sortValues(cards[j], keys, request), keys, request) < 0
+	})
+}
+
+func sortValues(card *Card, keys []SortKey, request CardSearchRequest) []interface{} {
+	values := []interface{}{}
+	for _, key := range keys {
+		values = append(values, sortValue(card, key, request))
+	}
+	return values
+}
+
+// Cursor is the position after the last card of a page, it is given to the client as an opaque token
+type Cursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+// sortSpec identifies the order of the cards, a cursor can only be used with the same order
+func sortSpec(request CardSearchRequest) string {
+	parts := []string{}
+	for _, key := range sortKeys(request) {
+		part := key.Field
+		if key.Field == "synergy" {
+			part += ":" + synergySpec(request).String()
+		}
+		if key.Desc {
+			part += " desc"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ",")
+}
+
+// newCursor returns the token of the cursor after the card
+func newCursor(card *Card, request CardSearchRequest) string {
+	cursor := Cursor{Sort: sortSpec(request), Values: sortValues(card, sortKeys(request), request)}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes the token of a cursor, it fails if the cursor was created for another order than the one of the request
+func DecodeCursor(token string, request CardSearchRequest) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cursor Cursor
+	err = json.Unmarshal(data, &cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	keys := sortKeys(request)
+	if cursor.Sort != sortSpec(request) || len(cursor.Values) != len(keys) {
+		return nil, errors.New("cursor does not match the sort of the search")
+	}
+	for i, key := range keys {
+		valid := false
+		switch cursor.Values[i].(type) {
+		case bool:
+			valid = key.Field == landKey
+		case string:
+			valid = key.Field == "name" || key.Field == idKey
+		case float64:
+			valid = key.Field != landKey && key.Field != "name" && key.Field != idKey
+		}
+		if !valid {
+			return nil, errors.New("invalid cursor")
+		}
+	}
+	if _, err := primitive.ObjectIDFromHex(cursor.Values[len(keys)-1].(string)); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// cursorFilter returns the filter of the cards after the cursor, on the fields added by sortValueStages
+func cursorFilter(cursor *Cursor, request CardSearchRequest) bson.M {
+	keys := sortKeys(request)
+	alternatives := bson.A{}
+	for i, key := range keys {
+		filter := bson.M{}
+		for j := 0; j < i; j++ {
+			filter[cursorField(j, keys[j])] = cursorValue(cursor.Values[j], keys[j])
+		}
+		comparison := "$gt"
+		if key.Desc {
+			comparison = "$lt"
+		}
+		filter[cursorField(i, key)] = bson.M{comparison: cursorValue(cursor.Values[i], key)}
+		alternatives = append(alternatives, filter)
+	}
+	return bson.M{"$or": alternatives}
+}
+
+func cursorField(i int, key SortKey) string {
+	if key.Field == idKey {
+		return idKey
+	}
+	return sortValueField(i)
+}
+
+func cursorValue(value interface{}, key SortKey) interface{} {
+	if key.Field == idKey {
+		id, _ := primitive.ObjectIDFromHex(value.(string))
+		return id
+	}
+	return value
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{"", "[]", false},
+		{"synergy desc, price asc, name", "[{synergy true} {price false} {name false}]", false},
+		{"relevance, cmc DESC", "[{score true} {cmc true}]", false},
+		{"color", "", true},
+		{"name up", "", true},
+		{"name asc desc", "", true},
+		{"score asc", "", true},
+		{"name,", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			keys, err := ParseSort(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && fmt.Sprint(keys) != tt.want {
+				t.Errorf("ParseSort() = %v, want %v", keys, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		request CardSearchRequest
+		want    string
+	}{
+		{"Default", CardSearchRequest{}, "[{is_land false} {_id false}]"},
+		{"Legacy sortBy", CardSearchRequest{SortBy: "price", SortDir: "desc"}, "[{is_land false} {price true} {_id false}]"},
+		{"Text", CardSearchRequest{Text: "draw"}, "[{score true} {_id false}]"},
+		{"Text with sort", CardSearchRequest{Text: "draw", Sort: []SortKey{{Field: "price"}}}, "[{is_land false} {price false} {score true} {_id false}]"},
+		{"Score without text", CardSearchRequest{Sort: []SortKey{{Field: "score", Desc: true}, {Field: "name"}}}, "[{is_land false} {name false} {_id false}]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprint(sortKeys(tt.request)); got != tt.want {
+				t.Errorf("sortKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	request := CardSearchRequest{Sort: []SortKey{{Field: "synergy", Desc: true}, {Field: "name"}}, MainCardForSynergy: "Atraxa, Praetors' Voice"}
+	card := &Card{ID: primitive.NewObjectID(), Name: "Doubling Season", Synergies: map[string]float64{"Atraxa, Praetors' Voice": 0.5}}
+
+	cursor, err := DecodeCursor(newCursor(card, request), request)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got := fmt.Sprint(cursorFilter(cursor, request))
+	want := fmt.Sprint(bson.M{"$or": bson.A{
+		bson.M{"sort_0": bson.M{"$gt": false}},
+		bson.M{"sort_0": false, "sort_1": bson.M{"$lt": 0.5}},
+		bson.M{"sort_0": false, "sort_1": 0.5, "sort_2": bson.M{"$gt": "Doubling Season"}},
+		bson.M{"sort_0": false, "sort_1": 0.5, "sort_2": "Doubling Season", "_id": bson.M{"$gt": card.ID}},
+	}})
+	if got != want {
+		t.Errorf("cursorFilter() = %v, want %v", got, want)
+	}
+
+	other := request
+	other.MainCardForSynergy = "Chulane, Teller of Tales"
+	if _, err := DecodeCursor(newCursor(card, request), other); err == nil {
+		t.Errorf("Expected an error for a cursor of another sort")
+	}
+	if _, err := DecodeCursor("invalid", request); err == nil {
+		t.Errorf("Expected an error for an invalid cursor")
+	}
+}
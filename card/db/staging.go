@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+
+	"github.com/maedu/mtg-cards/db"
+)
+
+// CardStaging collects the cards of a transformation, they replace all cards only on Commit.
+// Until then the previous cards are used by the handlers.
+type CardStaging interface {
+	CreateMany(ctx context.Context, cards []*Card) error
+	Commit(ctx context.Context) error
+	Discard(ctx context.Context) error
+}
+
+// PrintingStaging collects the printings of an import, they replace all printings only on Commit
+type PrintingStaging interface {
+	CreateMany(ctx context.Context, printings []*Printing) error
+	Commit(ctx context.Context) error
+	Discard(ctx context.Context) error
+}
+
+// TokenStaging collects the tokens of a transformation, they replace all tokens only on Commit
+type TokenStaging interface {
+	CreateMany(ctx context.Context, tokens []*Token) error
+	Commit(ctx context.Context) error
+	Discard(ctx context.Context) error
+}
+
+type cardCollectionStaging struct {
+	staging *CardCollection
+	target  *CardCollection
+}
+
+// NewStaging creates an empty staging collection with the same indexes as the cards collection
+func (collection *CardCollection) NewStaging(ctx context.Context) (CardStaging, error) {
+	staging, err := db.NewStagingCollection(ctx, collection.Collection)
+	if err != nil {
+		return nil, err
+	}
+	return &cardCollectionStaging{staging: &CardCollection{Collection: staging}, target: collection}, nil
+}
+
+func (staging *cardCollectionStaging) CreateMany(ctx context.Context, cards []*Card) error {
+	if len(cards) == 0 {
+		return nil
+	}
+	return staging.staging.CreateMany(ctx, cards)
+}
+
+// Commit renames the staging collection to the cards collection, which replaces it atomically
+func (staging *cardCollectionStaging) Commit(ctx context.Context) error {
+	return db.ReplaceCollection(ctx, staging.staging.Collection, staging.target.Collection)
+}
+
+// Discard drops the staging collection, the cards collection stays unchanged
+func (staging *cardCollectionStaging) Discard(ctx context.Context) error {
+	return staging.staging.Drop(ctx)
+}
+
+type printingCollectionStaging struct {
+	staging *PrintingCollection
+	target  *PrintingCollection
+}
+
+// NewStaging creates an empty staging collection with the same indexes as the printings collection
+func (collection *PrintingCollection) NewStaging(ctx context.Context) (PrintingStaging, error) {
+	staging, err := db.NewStagingCollection(ctx, collection.Collection)
+	if err != nil {
+		return nil, err
+	}
+	return &printingCollectionStaging{staging: &PrintingCollection{Collection: staging}, target: collection}, nil
+}
+
+func (staging *printingCollectionStaging) CreateMany(ctx context.Context, printings []*Printing) error {
+	return staging.staging.CreateMany(ctx, printings)
+}
+
+// Commit renames the staging collection to the printings collection, which replaces it atomically
+func (staging *printingCollectionStaging) Commit(ctx context.Context) error {
+	return db.ReplaceCollection(ctx, staging.staging.Collection, staging.target.Collection)
+}
+
+// Discard drops the staging collection, the printings collection stays unchanged
+func (staging *printingCollectionStaging) Discard(ctx context.Context) error {
+	return staging.staging.Drop(ctx)
+}
+
+type tokenCollectionStaging struct {
+	staging *TokenCollection
+	target  *TokenCollection
+}
+
+// NewStaging creates an empty staging collection with the same indexes as the tokens collection
+func (collection *TokenCollection) NewStaging(ctx context.Context) (TokenStaging, error) {
+	staging, err := db.NewStagingCollection(ctx, collection.Collection)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenCollectionStaging{staging: &TokenCollection{Collection: staging}, target: collection}, nil
+}
+
+func (staging *tokenCollectionStaging) CreateMany(ctx context.Context, tokens []*Token) error {
+	return staging.staging.CreateMany(ctx, tokens)
+}
+
+// Commit renames the staging collection to the tokens collection, which replaces it atomically
+func (staging *tokenCollectionStaging) Commit(ctx context.Context) error {
+	return db.ReplaceCollection(ctx, staging.staging.Collection, staging.target.Collection)
+}
+
+// Discard drops the staging collection, the tokens collection stays unchanged
+func (staging *tokenCollectionStaging) Discard(ctx context.Context) error {
+	return staging.staging.Drop(ctx)
+}
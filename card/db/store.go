@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CardStore is the storage of the transformed cards used by the handlers
+type CardStore interface {
+	GetAllCards(ctx context.Context) ([]*Card, error)
+	GetCardsPaginated(ctx context.Context, limit int64, page int64, request CardSearchRequest) (PaginatedResult, error)
+	GetCollectedCardsPaginated(ctx context.Context, limit int64, page int64, request CardSearchRequest) (PaginatedResult, error)
+	GetCardNames(ctx context.Context) ([]*Card, error)
+	GetCardByID(ctx context.Context, id primitive.ObjectID) (*Card, error)
+	GetCardByOracleID(ctx context.Context, oracleID string) (*Card, error)
+	GetCardsByOracleIDs(ctx context.Context, oracleIDs []string) ([]*Card, error)
+	GetCardsByNames(ctx context.Context, names []string) ([]*Card, error)
+	GetCardsBySetName(ctx context.Context, setName string) ([]*Card, error)
+	Create(ctx context.Context, card *Card) (primitive.ObjectID, error)
+	CreateMany(ctx context.Context, cards []*Card) error
+	DeleteAll(ctx context.Context) error
+	ReplaceAll(ctx context.Context, cards []*Card) (*[]primitive.ObjectID, error)
+	NewStaging(ctx context.Context) (CardStaging, error)
+}
+
+// PrintingStore is the storage of the printings of the cards in the sets
+type PrintingStore interface {
+	GetPrintingsByOracleID(ctx context.Context, oracleID string) ([]*Printing, error)
+	GetPrintingsBySet(ctx context.Context, set string) ([]*Printing, error)
+	CreateMany(ctx context.Context, printings []*Printing) error
+	DeleteAll(ctx context.Context) error
+	NewStaging(ctx context.Context) (PrintingStaging, error)
+}
+
+// TokenStore is the storage of the tokens and emblems created by the cards
+type TokenStore interface {
+	GetTokensCreatedBy(ctx context.Context, oracleIDs []string) ([]*Token, error)
+	CreateMany(ctx context.Context, tokens []*Token) error
+	DeleteAll(ctx context.Context) error
+	NewStaging(ctx context.Context) (TokenStaging, error)
+}
+
+var cardStore CardStore
+var printingStore PrintingStore
+var tokenStore TokenStore
+
+// GetCardStore returns the CardStore configured at startup with UseCardStore
+func GetCardStore() CardStore {
+	return cardStore
+}
+
+// UseCardStore sets the CardStore returned by GetCardStore, e.g. a CardCollection or a MemoryCardStore
+func UseCardStore(store CardStore) {
+	cardStore = store
+}
+
+// GetPrintingStore returns the PrintingStore configured at startup with UsePrintingStore
+func GetPrintingStore() PrintingStore {
+	return printingStore
+}
+
+// UsePrintingStore sets the PrintingStore returned by GetPrintingStore, e.g. a PrintingCollection or a MemoryPrintingStore
+func UsePrintingStore(store PrintingStore) {
+	printingStore = store
+}
+
+// GetTokenStore returns the TokenStore configured at startup with UseTokenStore
+func GetTokenStore() TokenStore {
+	return tokenStore
+}
+
+// UseTokenStore sets the TokenStore returned by GetTokenStore, e.g. a TokenCollection or a MemoryTokenStore
+func UseTokenStore(store TokenStore) {
+	tokenStore = store
+}
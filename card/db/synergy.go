@@ -0,0 +1,183 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// SynergyMax combines the synergies with the main cards by their maximum
+	SynergyMax = "max"
+	// SynergyAverage combines the synergies with the main cards by their average, a missing synergy counts as 0
+	SynergyAverage = "avg"
+	// SynergyWeighted combines the synergies with the main cards by their weighted average, a missing synergy counts as 0
+	SynergyWeighted = "weighted"
+
+	// minSynergy is the synergy a card needs for the card group Synergy
+	minSynergy = 0.2
+)
+
+// SynergySpec are the main cards the synergy of a card is searched for, e.g. partner commanders or a commander and its background
+type SynergySpec struct {
+	MainCards []string
+	Mode      string
+	// Weights are the weights of the main cards for SynergyWeighted
+	Weights []float64
+}
+
+// NewSynergySpec validates the main cards, mode and weights
+func NewSynergySpec(mainCards []string, mode string, weights []float64) (SynergySpec, error) {
+	spec := SynergySpec{MainCards: mainCards, Mode: mode, Weights: weights}
+	switch mode {
+	case "":
+		spec.Mode = SynergyMax
+	case SynergyMax, SynergyAverage:
+	case SynergyWeighted:
+		if len(weights) != len(mainCards) {
+			return spec, errors.New("a weight is needed for each main card")
+		}
+		total := 0.0
+		for _, weight := range weights {
+			if weight < 0 {
+				return spec, errors.New("weights can't be negative")
+			}
+			total += weight
+		}
+		if total == 0 {
+			return spec, errors.New("at least one weight must be positive")
+		}
+	default:
+		return spec, fmt.Errorf("unknown synergy mode %q", mode)
+	}
+	if spec.Mode != SynergyWeighted && len(weights) > 0 {
+		return spec, errors.New("weights are only used by the weighted mode")
+	}
+	return spec, nil
+}
+
+// synergySpec returns the synergy spec of the request, MainCardForSynergy is used if no main cards are set
+func synergySpec(request CardSearchRequest) SynergySpec {
+	if len(request.Synergy.MainCards) > 0 {
+		return request.Synergy
+	}
+	return SynergySpec{MainCards: []string{request.MainCardForSynergy}, Mode: SynergyMax}
+}
+
+func (spec SynergySpec) String() string {
+	text := strings.Join(spec.MainCards, "|")
+	if len(spec.MainCards) > 1 {
+		text += fmt.Sprintf(" %s%v", spec.Mode, spec.Weights)
+	}
+	return text
+}
+
+// combine returns the combined synergy of the card, false if it has no synergy with any main card
+func (spec SynergySpec) combine(card *Card) (float64, bool) {
+	found := false
+	max := 0.0
+	sum := 0.0
+	totalWeight := 0.0
+	for i, mainCard := range spec.MainCards {
+		weight := 1.0
+		if spec.Mode == SynergyWeighted {
+			weight = spec.Weights[i]
+		}
+		totalWeight += weight
+
+		synergy, ok := card.Synergies[mainCard]
+		if !ok {
+			continue
+		}
+		if !found || synergy > max {
+			max = synergy
+		}
+		found = true
+		sum += weight * synergy
+	}
+	if !found {
+		return 0, false
+	}
+	if spec.Mode == SynergyMax {
+		return max, true
+	}
+	return sum / totalWeight, true
+}
+
+// expression returns the aggregation expression of the combined synergy, null if there is no synergy with any main card
+func (spec SynergySpec) expression() interface{} {
+	fields := bson.A{}
+	for _, mainCard := range spec.MainCards {
+		fields = append(fields, "$synergies."+mainCard)
+	}
+	if len(fields) == 1 {
+		return fields[0]
+	}
+	if spec.Mode == SynergyMax {
+		// $max ignores missing synergies
+		return bson.M{"$max": fields}
+	}
+
+	weighted := bson.A{}
+	totalWeight := 0.0
+	for i, field := range fields {
+		weight := 1.0
+		if spec.Mode == SynergyWeighted {
+			weight = spec.Weights[i]
+		}
+		totalWeight += weight
+		weighted = append(weighted, bson.M{"$multiply": bson.A{weight, bson.M{"$ifNull": bson.A{field, 0}}}})
+	}
+	return bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$max": fields}, nil}},
+		nil,
+		bson.M{"$divide": bson.A{bson.M{"$add": weighted}, totalWeight}},
+	}}
+}
+
+// synergyFilter returns the filter of the cards with at least the synergy
+func (spec SynergySpec) synergyFilter(min float64) bson.M {
+	if len(spec.MainCards) == 1 {
+		return bson.M{"synergies." + spec.MainCards[0]: bson.M{"$gte": min}}
+	}
+	return bson.M{"$expr": bson.M{"$gte": bson.A{spec.expression(), min}}}
+}
+
+// relatedFilter returns the filter of the cards with a synergy with any main card
+func (spec SynergySpec) relatedFilter() bson.M {
+	filters := bson.A{}
+	for _, mainCard := range spec.MainCards {
+		filters = append(filters, bson.M{"synergies." + mainCard: bson.M{"$exists": true}})
+	}
+	if len(filters) == 1 {
+		return filters[0].(bson.M)
+	}
+	return bson.M{"$or": filters}
+}
+
+// isRelated returns true if the card has a synergy with any main card
+func (spec SynergySpec) isRelated(card *Card) bool {
+	_, ok := spec.combine(card)
+	return ok
+}
+
+// AddMainCardSynergies sets the synergies of the cards with the main cards of the request and the combined synergy
+func AddMainCardSynergies(cards []*Card, request CardSearchRequest) {
+	spec := synergySpec(request)
+	if len(spec.MainCards) == 1 && spec.MainCards[0] == "" {
+		return
+	}
+	for _, card := range cards {
+		card.MainCardSynergies = map[string]float64{}
+		for _, mainCard := range spec.MainCards {
+			if synergy, ok := card.Synergies[mainCard]; ok {
+				card.MainCardSynergies[mainCard] = synergy
+			}
+		}
+		if synergy, ok := spec.combine(card); ok {
+			card.Synergy = &synergy
+		}
+	}
+}
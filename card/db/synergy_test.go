@@ -0,0 +1,75 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewSynergySpec(t *testing.T) {
+	mainCards := []string{"Tymna the Weaver", "Thrasios, Triton Hero"}
+	tests := []struct {
+		name    string
+		mode    string
+		weights []float64
+		wantErr bool
+	}{
+		{"Default", "", nil, false},
+		{"Average", SynergyAverage, nil, false},
+		{"Weighted", SynergyWeighted, []float64{2, 1}, false},
+		{"Weighted without weights", SynergyWeighted, nil, true},
+		{"Negative weight", SynergyWeighted, []float64{-1, 2}, true},
+		{"Zero weights", SynergyWeighted, []float64{0, 0}, true},
+		{"Weights without weighted", SynergyMax, []float64{1, 1}, true},
+		{"Unknown mode", "sum", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := NewSynergySpec(mainCards, tt.mode, tt.weights)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSynergySpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && spec.Mode == "" {
+				t.Errorf("expected a mode")
+			}
+		})
+	}
+}
+
+func TestSynergySpecCombine(t *testing.T) {
+	card := &Card{Name: "Esper Sentinel", Synergies: map[string]float64{"Tymna the Weaver": 0.6}}
+	mainCards := []string{"Tymna the Weaver", "Thrasios, Triton Hero"}
+	tests := []struct {
+		name string
+		spec SynergySpec
+		want string
+	}{
+		{"Max", SynergySpec{MainCards: mainCards, Mode: SynergyMax}, "0.6 true"},
+		{"Average", SynergySpec{MainCards: mainCards, Mode: SynergyAverage}, "0.3 true"},
+		{"Weighted", SynergySpec{MainCards: mainCards, Mode: SynergyWeighted, Weights: []float64{1, 3}}, "0.15 true"},
+		{"No synergy", SynergySpec{MainCards: []string{"Thrasios, Triton Hero"}, Mode: SynergyMax}, "0 false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			synergy, ok := tt.spec.combine(card)
+			if got := fmt.Sprint(synergy, ok); got != tt.want {
+				t.Errorf("combine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSynergySpecFilter(t *testing.T) {
+	single := synergySpec(CardSearchRequest{MainCardForSynergy: "Tymna the Weaver"})
+	if got := fmt.Sprint(single.synergyFilter(minSynergy)); got != "map[synergies.Tymna the Weaver:map[$gte:0.2]]" {
+		t.Errorf("synergyFilter() = %v", got)
+	}
+	if got := fmt.Sprint(single.relatedFilter()); got != "map[synergies.Tymna the Weaver:map[$exists:true]]" {
+		t.Errorf("relatedFilter() = %v", got)
+	}
+
+	partners := SynergySpec{MainCards: []string{"Tymna the Weaver", "Thrasios, Triton Hero"}, Mode: SynergyMax}
+	want := "map[$expr:map[$gte:[map[$max:[$synergies.Tymna the Weaver $synergies.Thrasios, Triton Hero]] 0.2]]]"
+	if got := fmt.Sprint(partners.synergyFilter(minSynergy)); got != want {
+		t.Errorf("synergyFilter() = %v, want %v", got, want)
+	}
+}
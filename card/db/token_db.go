@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"log"
+
+	"github.com/maedu/mtg-cards/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Token is a token or an emblem, CreatedBy are the oracle IDs of the cards creating it
+type Token struct {
+	ScryfallID string            `bson:"_id" json:"scryfallId"`
+	OracleID   string            `bson:"oracle_id" json:"oracleId"`
+	Name       string            `bson:"name" json:"name"`
+	Layout     string            `bson:"layout" json:"layout"`
+	TypeLine   string            `bson:"type_line" json:"typeLine"`
+	OracleText string            `bson:"oracle_text" json:"oracleText"`
+	Colors     []string          `bson:"colors" json:"colors"`
+	Power      string            `bson:"power" json:"power"`
+	Toughness  string            `bson:"toughness" json:"toughness"`
+	IsEmblem   bool              `bson:"is_emblem" json:"isEmblem"`
+	ImageURLs  map[string]string `bson:"image_urls" json:"imageURLs"`
+	CreatedBy  []string          `bson:"created_by" json:"-"`
+}
+
+// TokenCollection ...
+type TokenCollection struct {
+	*mongo.Collection
+}
+
+// NewTokenCollection creates the TokenCollection using the shared client
+func NewTokenCollection(client *mongo.Client) *TokenCollection {
+	return &TokenCollection{
+		Collection: client.Database(db.GetDatabaseName()).Collection("tokens"),
+	}
+}
+
+// CreateTokenIndexes creates the index used to find the tokens created by cards
+func CreateTokenIndexes(ctx context.Context, database *mongo.Database) error {
+	model := mongo.IndexModel{
+		Keys: bson.M{"created_by": 1},
+	}
+	_, err := database.Collection("tokens").Indexes().CreateOne(ctx, model)
+	return err
+}
+
+// GetTokensCreatedBy retrieves the tokens and emblems created by one of the cards with the oracle IDs, ordered by their name
+func (collection *TokenCollection) GetTokensCreatedBy(ctx context.Context, oracleIDs []string) ([]*Token, error) {
+	var tokens []*Token = []*Token{}
+
+	opts := options.Find().SetSort(bson.D{{Key: "is_emblem", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := collection.Collection.Find(ctx, bson.M{"created_by": bson.M{"$in": oracleIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &tokens)
+	if err != nil {
+		log.Printf("Failed marshalling %v", err)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// CreateMany creates many tokens in a mongo, an existing token with the same scryfall ID is replaced
+func (collection *TokenCollection) CreateMany(ctx context.Context, tokens []*Token) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	models := []mongo.WriteModel{}
+	for _, token := range tokens {
+		models = append(models, mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": token.ScryfallID}).SetReplacement(token).SetUpsert(true))
+	}
+	_, err := collection.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		log.Printf("Could not create Token: %v", err)
+	}
+	return err
+}
+
+// DeleteAll tokens in the collection
+func (collection *TokenCollection) DeleteAll(ctx context.Context) error {
+	_, err := collection.Collection.DeleteMany(ctx, bson.M{})
+	return err
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"flag"
+	"strconv"
 	"time"
 
 	"context"
@@ -18,8 +19,11 @@ const (
 	connectTimeout = 60
 )
 
-// GetConnection Creates and returns the connection
-func GetConnection() (*mongo.Client, context.Context, context.CancelFunc) {
+var client *mongo.Client
+
+// Connect creates the client shared by all collections and verifies the connection.
+// It has to be called once at startup, the client keeps a pool of connections.
+func Connect() (*mongo.Client, error) {
 	protocol := os.Getenv("MONGODB_PROTOCOL")
 	username := os.Getenv("MONGODB_USERNAME")
 	password := os.Getenv("MONGODB_PASSWORD")
@@ -27,27 +31,45 @@ func GetConnection() (*mongo.Client, context.Context, context.CancelFunc) {
 	log.Printf("Connection to %s\n", clusterEndpoint)
 
 	connectionURI := fmt.Sprintf("%s://%s:%s@%s", protocol, username, password, clusterEndpoint)
-
-	client, err := mongo.NewClient(options.Client().ApplyURI(connectionURI))
-	if err != nil {
-		log.Printf("Failed to create client: %v", err)
+	clientOptions := options.Client().ApplyURI(connectionURI)
+	if maxPoolSize, err := strconv.ParseUint(os.Getenv("MONGODB_MAX_POOL_SIZE"), 10, 64); err == nil {
+		clientOptions.SetMaxPoolSize(maxPoolSize)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
 
-	err = client.Connect(ctx)
+	newClient, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		log.Printf("Failed to connect to cluster: %v", err)
+		return nil, fmt.Errorf("failed to connect to cluster: %w", err)
 	}
 
 	// Force a connection to verify our connection string
-	err = client.Ping(ctx, nil)
+	err = newClient.Ping(ctx, nil)
 	if err != nil {
-		log.Printf("Failed to ping cluster: %v", err)
+		newClient.Disconnect(ctx)
+		return nil, fmt.Errorf("failed to ping cluster: %w", err)
 	}
 
 	fmt.Println("Connected to MongoDB!")
-	return client, ctx, cancel
+	client = newClient
+	return client, nil
+}
+
+// GetClient returns the shared client created by Connect
+func GetClient() *mongo.Client {
+	return client
+}
+
+// Disconnect closes the shared client and all its connections
+func Disconnect() {
+	if client == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+	client.Disconnect(ctx)
+	client = nil
 }
 
 // GetDatabaseName ...
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const migrationsCollectionName = "migrations"
+
+// Migration is a change of the schema or the data, e.g. an index, a renamed field or a backfill.
+// Once released, a migration must not be changed anymore, add a new one with a higher version instead.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, database *mongo.Database) error
+}
+
+// ErrMigrationNotReady is returned by a migration which can't run yet, e.g. a backfill needing transformed cards.
+// The migration is not recorded as applied and runs again with the next Migrate, the later migrations are applied anyway.
+var ErrMigrationNotReady = errors.New("migration is not ready yet")
+
+// AppliedMigration is the record of a migration which ran successfully
+type AppliedMigration struct {
+	Version     int       `bson:"_id" json:"version"`
+	Description string    `bson:"description" json:"description"`
+	AppliedAt   time.Time `bson:"applied_at" json:"appliedAt"`
+}
+
+// Migrate runs all migrations which have not been applied yet to the database, ordered by their version.
+// Every applied migration is recorded in the migrations collection, so it runs only once.
+// It returns true if migrations are still waiting, as they returned ErrMigrationNotReady.
+func Migrate(ctx context.Context, database *mongo.Database, migrations []Migration) (bool, error) {
+	collection := database.Collection(migrationsCollectionName)
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return false, fmt.Errorf("failed to load applied migrations: %w", err)
+	}
+	var appliedMigrations []AppliedMigration
+	err = cursor.All(ctx, &appliedMigrations)
+	if err != nil {
+		return false, fmt.Errorf("failed to load applied migrations: %w", err)
+	}
+	applied := map[int]bool{}
+	for _, appliedMigration := range appliedMigrations {
+		applied[appliedMigration.Version] = true
+	}
+
+	pending, err := PendingMigrations(migrations, applied)
+	if err != nil {
+		return false, err
+	}
+
+	waiting := 0
+	for _, migration := range pending {
+		log.Printf("Applying migration %d: %s\n", migration.Version, migration.Description)
+		err = migration.Up(ctx, database)
+		if errors.Is(err, ErrMigrationNotReady) {
+			log.Printf("Migration %d is not ready yet, it stays pending\n", migration.Version)
+			waiting++
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+
+		_, err = collection.InsertOne(ctx, AppliedMigration{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+	}
+	log.Printf("Migrations done, %d applied\n", len(pending)-waiting)
+	return waiting > 0, nil
+}
+
+// PendingMigrations returns the migrations which are not applied yet, ordered by their version.
+// It fails if a version is used twice or is not positive.
+func PendingMigrations(migrations []Migration, applied map[int]bool) ([]Migration, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	pending := []Migration{}
+	for i, migration := range sorted {
+		if migration.Version < 1 {
+			return nil, fmt.Errorf("migration %q has invalid version %d", migration.Description, migration.Version)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("migration version %d is used more than once", migration.Version)
+		}
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func noop(ctx context.Context, database *mongo.Database) error {
+	return nil
+}
+
+func TestPendingMigrations(t *testing.T) {
+	migrations := []Migration{
+		{Version: 3, Description: "third", Up: noop},
+		{Version: 1, Description: "first", Up: noop},
+		{Version: 2, Description: "second", Up: noop},
+	}
+
+	pending, err := PendingMigrations(migrations, map[int]bool{2: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Version != 1 || pending[1].Version != 3 {
+		t.Errorf("expected versions 1 and 3, got %v", pending)
+	}
+}
+
+func TestPendingMigrationsInvalidVersions(t *testing.T) {
+	tests := []struct {
+		name       string
+		migrations []Migration
+	}{
+		{"duplicate", []Migration{{Version: 1, Up: noop}, {Version: 1, Up: noop}}},
+		{"zero", []Migration{{Version: 0, Up: noop}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := PendingMigrations(test.migrations, map[int]bool{})
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
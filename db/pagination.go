@@ -0,0 +1,217 @@
+package db
+
+import (
+	"context"
+	"math"
+
+	pagination "github.com/maedu/mongo-go-pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PaginatedQuery describes a page of a find or aggregate query.
+// Unlike mongo-go-pagination it runs all queries with the given context, so they are cancelled with the request.
+type PaginatedQuery struct {
+	Limit      int64
+	Page       int64
+	Filter     interface{}
+	Projection interface{}
+	Sort       interface{}
+}
+
+func (query *PaginatedQuery) normalize() {
+	if query.Limit < 1 {
+		query.Limit = 10
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+}
+
+func (query *PaginatedQuery) skip() int64 {
+	return (query.Page - 1) * query.Limit
+}
+
+// Find returns the documents of the page and the pagination data
+func (query PaginatedQuery) Find(ctx context.Context, collection *mongo.Collection) ([]bson.Raw, pagination.PaginationData, error) {
+	query.normalize()
+	filter := query.Filter
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, pagination.PaginationData{}, err
+	}
+
+	opts := options.Find().SetSkip(query.skip()).SetLimit(query.Limit)
+	if query.Projection != nil {
+		opts.SetProjection(query.Projection)
+	}
+	if query.Sort != nil {
+		opts.SetSort(query.Sort)
+	}
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, pagination.PaginationData{}, err
+	}
+	defer cursor.Close(ctx)
+
+	docs := []bson.Raw{}
+	for cursor.Next(ctx) {
+		docs = append(docs, append(bson.Raw{}, cursor.Current...))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, pagination.PaginationData{}, err
+	}
+	return docs, PaginationData(total, query.Page, query.Limit), nil
+}
+
+// Aggregate runs the pipeline, followed by the sorting and paging, and returns the documents of the page and the pagination data.
+// The filter is not used, it has to be part of the pipeline.
+func (query PaginatedQuery) Aggregate(ctx context.Context, collection *mongo.Collection, pipeline ...bson.M) ([]bson.Raw, pagination.PaginationData, error) {
+	query.normalize()
+
+	facetData := []bson.M{}
+	if query.Sort != nil {
+		facetData = append(facetData, bson.M{"$sort": query.Sort})
+	}
+	facetData = append(facetData, bson.M{"$skip": query.skip()}, bson.M{"$limit": query.Limit})
+
+	stages := append([]bson.M{}, pipeline...)
+	if projection, ok := query.Projection.(bson.M); query.Projection != nil && (!ok || len(projection) > 0) {
+		// Added as fields, a $project stage would drop all fields not mentioned
+		stages = append(stages, bson.M{"$addFields": query.Projection})
+	}
+	stages = append(stages, bson.M{"$facet": bson.M{
+		"data":  facetData,
+		"total": []bson.M{{"$count": "count"}},
+	}})
+
+	cursor, err := collection.Aggregate(ctx, stages, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, pagination.PaginationData{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Data  []bson.Raw `bson:"data"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, pagination.PaginationData{}, err
+	}
+
+	docs := []bson.Raw{}
+	var total int64
+	if len(result) > 0 && len(result[0].Total) > 0 {
+		docs = result[0].Data
+		total = result[0].Total[0].Count
+	}
+	return docs, PaginationData(total, query.Page, query.Limit), nil
+}
+
+// PaginationData calculates the pagination data like mongo-go-pagination
+func PaginationData(total int64, page int64, limit int64) pagination.PaginationData {
+	totalPage := int64(math.Ceil(float64(total) / float64(limit)))
+	data := pagination.PaginationData{
+		Total:     total,
+		Page:      page,
+		PerPage:   limit,
+		TotalPage: totalPage,
+	}
+	if page > 1 && total > 0 {
+		data.Prev = page - 1
+	}
+	if page < totalPage {
+		data.Next = page + 1
+	}
+	return data
+}
+
+// CursorQuery describes the page after a cursor of an aggregate query.
+// The sort has to be unique, e.g. end with the ID, so the pages don't shift when documents are added or removed.
+type CursorQuery struct {
+	Limit int64
+	// AddFields are added to the documents before they are filtered by After and sorted, e.g. computed sort values
+	AddFields bson.M
+	// After filters the documents after the cursor, nil for the first page
+	After interface{}
+	Sort  interface{}
+	// Exclude are the fields removed from the returned documents, e.g. the computed sort values
+	Exclude []string
+}
+
+// Aggregate runs the pipeline, followed by the filter of the cursor, the sorting and the limit.
+// It returns the documents of the page, the total of the pipeline and if there are more documents after the page.
+func (query CursorQuery) Aggregate(ctx context.Context, collection *mongo.Collection, pipeline ...bson.M) ([]bson.Raw, int64, bool, error) {
+	if query.Limit < 1 {
+		query.Limit = 10
+	}
+
+	facetData := []bson.M{}
+	if query.After != nil {
+		facetData = append(facetData, bson.M{"$match": query.After})
+	}
+	if query.Sort != nil {
+		facetData = append(facetData, bson.M{"$sort": query.Sort})
+	}
+	// One more document is loaded to know if there are more
+	facetData = append(facetData, bson.M{"$limit": query.Limit + 1})
+	if len(query.Exclude) > 0 {
+		exclude := bson.M{}
+		for _, field := range query.Exclude {
+			exclude[field] = 0
+		}
+		facetData = append(facetData, bson.M{"$project": exclude})
+	}
+
+	stages := append([]bson.M{}, pipeline...)
+	if len(query.AddFields) > 0 {
+		stages = append(stages, bson.M{"$addFields": query.AddFields})
+	}
+	stages = append(stages, bson.M{"$facet": bson.M{
+		"data":  facetData,
+		"total": []bson.M{{"$count": "count"}},
+	}})
+
+	cursor, err := collection.Aggregate(ctx, stages, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Data  []bson.Raw `bson:"data"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, 0, false, err
+	}
+
+	docs := []bson.Raw{}
+	var total int64
+	if len(result) > 0 && len(result[0].Total) > 0 {
+		docs = result[0].Data
+		total = result[0].Total[0].Count
+	}
+	hasMore := int64(len(docs)) > query.Limit
+	if hasMore {
+		docs = docs[:query.Limit]
+	}
+	return docs, total, hasMore, nil
+}
+
+// CursorPaginationData returns the pagination data of a page after a cursor, which has no page number
+func CursorPaginationData(total int64, limit int64) pagination.PaginationData {
+	data := PaginationData(total, 1, limit)
+	data.Page = 0
+	data.Next = 0
+	return data
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// namespaceNotFound is the error code of MongoDB if a collection does not exist
+const namespaceNotFound = 26
+
+// NewStagingCollection creates the empty collection <name>_staging with the same indexes as the collection,
+// it replaces the collection with ReplaceCollection once it is filled
+func NewStagingCollection(ctx context.Context, collection *mongo.Collection) (*mongo.Collection, error) {
+	database := collection.Database()
+	staging := database.Collection(collection.Name() + "_staging")
+
+	// Remove the leftovers of a failed import
+	err := staging.Drop(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes, err := indexSpecifications(ctx, collection)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing indexes of %s: %w", collection.Name(), err)
+	}
+	if len(indexes) > 0 {
+		err = database.RunCommand(ctx, bson.D{
+			{Key: "createIndexes", Value: staging.Name()},
+			{Key: "indexes", Value: indexes},
+		}).Err()
+		if err != nil {
+			return nil, fmt.Errorf("failed creating indexes on %s: %w", staging.Name(), err)
+		}
+	}
+	return staging, nil
+}
+
+// indexSpecifications returns the specifications of all indexes except the one on _id, to create them on another collection
+func indexSpecifications(ctx context.Context, collection *mongo.Collection) (bson.A, error) {
+	cursor, err := collection.Indexes().List(ctx)
+	if cmdErr, ok := err.(mongo.CommandError); ok && cmdErr.Code == namespaceNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var specifications []bsonx.Doc
+	err = cursor.All(ctx, &specifications)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := bson.A{}
+	for _, specification := range specifications {
+		if name, err := specification.LookupErr("name"); err == nil && name.StringValue() == "_id_" {
+			continue
+		}
+		indexes = append(indexes, specification.Delete("ns").Delete("v"))
+	}
+	return indexes, nil
+}
+
+// ReplaceCollection renames the staging collection to the target collection, which replaces it atomically
+func ReplaceCollection(ctx context.Context, staging *mongo.Collection, target *mongo.Collection) error {
+	database := target.Database()
+	err := database.Client().Database("admin").RunCommand(ctx, bson.D{
+		{Key: "renameCollection", Value: database.Name() + "." + staging.Name()},
+		{Key: "to", Value: database.Name() + "." + target.Name()},
+		{Key: "dropTarget", Value: true},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed renaming %s to %s: %w", staging.Name(), target.Name(), err)
+	}
+	log.Printf("Replaced %s with %s\n", target.Name(), staging.Name())
+	return nil
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sort"
@@ -18,48 +19,55 @@ type Deck struct {
 	Deck       []*cardDB.Card `json:"deck"`
 	Library    []*cardDB.Card `json:"library"`
 	Settings   db.Settings    `json:"settings"`
+	// Validation and Statistics are only returned with a single deck
+	Validation *DeckValidation `json:"validation,omitempty"`
+	Statistics *DeckStatistics `json:"statistics,omitempty"`
 }
 
 // Setup Setup REST API
 func Setup(r *gin.Engine) {
-	r.GET("/api/decks/:urlHash", handlGetDeck)
-	r.POST("/api/decks", handleUpsertDeck)
-	r.POST("/api/decks/:urlHash/publish", handlePublishDeck)
-	r.POST("/api/decks/:urlHash/unpublish", handleUnpublishDeck)
-	r.DELETE("/api/decks/:urlHash", handleDeleteDeck)
-	r.GET("/api/decks", handleGetUserDecks)
+	r.GET("/api/decks/:urlHash", auth.RequireScope(userDB.ReadDecks), handlGetDeck)
+	r.GET("/api/decks/:urlHash/tokens", auth.RequireScope(userDB.ReadDecks), handleGetDeckTokens)
+	r.POST("/api/decks", auth.RequireScope(userDB.WriteDecks), handleUpsertDeck)
+	r.POST("/api/decks/:urlHash/publish", auth.RequireScope(userDB.WriteDecks), handlePublishDeck)
+	r.POST("/api/decks/:urlHash/unpublish", auth.RequireScope(userDB.WriteDecks), handleUnpublishDeck)
+	r.DELETE("/api/decks/:urlHash", auth.RequireScope(userDB.WriteDecks), handleDeleteDeck)
+	r.GET("/api/decks", auth.RequireScope(userDB.ReadDecks), handleGetUserDecks)
 }
 func handlGetDeck(c *gin.Context) {
-	urlHash := c.Param("urlHash")
+	ctx := c.Request.Context()
+	deck, ok := getVisibleDeck(c)
+	if !ok {
+		return
+	}
 
-	collection, err := db.GetDeckCollection()
-	defer collection.Disconnect()
+	deckWithCards, err := dbDeckToDeck(ctx, deck)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return
 	}
-	deck, err := collection.GetDeckByURLHash(urlHash)
+
+	c.JSON(http.StatusOK, deckWithCards)
+}
+
+// getVisibleDeck returns the deck of the urlHash parameter, if the user is allowed to see it, otherwise the error is sent
+func getVisibleDeck(c *gin.Context) (*db.Deck, bool) {
+	collection := db.GetDeckStore()
+	deck, err := collection.GetDeckByURLHash(c.Request.Context(), c.Param("urlHash"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err)
-		return
+		return nil, false
 	}
 	if deck == nil {
 		c.JSON(http.StatusNotFound, "Deck not found")
-		return
+		return nil, false
 	}
 	userID, _ := auth.GetUserIDFromAccessToken(c, false)
 	if !canUserSeeDeck(deck, userID) {
 		c.JSON(http.StatusForbidden, "You are not allowed to see this deck")
-		return
-	}
-
-	deckWithCards, err := dbDeckToDeck(deck)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, err)
-		return
+		return nil, false
 	}
-
-	c.JSON(http.StatusOK, deckWithCards)
+	return deck, true
 }
 
 func canUserSeeDeck(deck *db.Deck, userID string) bool {
@@ -67,17 +75,13 @@ func canUserSeeDeck(deck *db.Deck, userID string) bool {
 }
 
 func handleGetUserDecks(c *gin.Context) {
+	ctx := c.Request.Context()
 	selectedUserName := c.Query("user")
 	var selectedUserID string
 	userID, loggedIn := auth.GetUserIDFromAccessToken(c, false)
 	if selectedUserName != "" {
-		userCollection, err := userDB.GetUserCollection()
-		defer userCollection.Disconnect()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, err)
-			return
-		}
-		user, err := userCollection.GetUserByUserName(selectedUserName)
+		userCollection := userDB.GetUserStore()
+		user, err := userCollection.GetUserByUserName(ctx, selectedUserName)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, err)
 			return
@@ -96,21 +100,16 @@ func handleGetUserDecks(c *gin.Context) {
 		selectedUserID = userID
 	}
 
-	collection, err := db.GetDeckCollection()
-	defer collection.Disconnect()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, err)
-		return
-	}
+	collection := db.GetDeckStore()
 	publishedOnly := selectedUserID != userID
-	decks, err := collection.GetDecksByUserID(selectedUserID, publishedOnly)
+	decks, err := collection.GetDecksByUserID(ctx, selectedUserID, publishedOnly)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return
 	}
 	decksWithCards := []Deck{}
 	for _, deck := range decks {
-		deckWithCards, err := dbDeckToDeckForOverview(deck)
+		deckWithCards, err := dbDeckToDeckForOverview(ctx, deck)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, err)
 			return
@@ -131,6 +130,7 @@ func handleUnpublishDeck(c *gin.Context) {
 }
 
 func setPublish(c *gin.Context, publish bool) {
+	ctx := c.Request.Context()
 	if userID, ok := auth.GetUserIDFromAccessToken(c, true); ok {
 		urlHash := c.Param("urlHash")
 		if urlHash == "" {
@@ -138,14 +138,9 @@ func setPublish(c *gin.Context, publish bool) {
 			return
 		}
 
-		collection, err := db.GetDeckCollection()
-		defer collection.Disconnect()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, err)
-			return
-		}
+		collection := db.GetDeckStore()
 
-		storedDeck, err := collection.GetDeckByURLHash(urlHash)
+		storedDeck, err := collection.GetDeckByURLHash(ctx, urlHash)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, err)
 			return
@@ -161,7 +156,7 @@ func setPublish(c *gin.Context, publish bool) {
 		}
 
 		storedDeck.Settings.Published = publish
-		storedDeck, err = collection.Update(storedDeck)
+		storedDeck, err = collection.Update(ctx, storedDeck)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, err)
 			return
@@ -176,6 +171,7 @@ func setPublish(c *gin.Context, publish bool) {
 }
 
 func handleUpsertDeck(c *gin.Context) {
+	ctx := c.Request.Context()
 	if userID, ok := auth.GetUserIDFromAccessToken(c, true); ok {
 		var inputDeck Deck
 		err := c.BindJSON(&inputDeck)
@@ -191,25 +187,25 @@ func handleUpsertDeck(c *gin.Context) {
 
 		deck := deckToDBDeck(&inputDeck)
 		deck.UserID = userID
-
-		collection, err := db.GetDeckCollection()
-		defer collection.Disconnect()
+		err = setOracleIDs(ctx, &deck, &inputDeck)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, err)
 			return
 		}
 
+		collection := db.GetDeckStore()
+
 		var storedDeck *db.Deck
 		if deck.Settings.URLHash == "" {
 			// New deck
 			fmt.Println("New Deck")
-			hash, err := generateUniqueURLHash(&collection)
+			hash, err := generateUniqueURLHash(ctx, collection)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, err)
 				return
 			}
 			deck.Settings.URLHash = hash
-			_, err = collection.Create(&deck)
+			_, err = collection.Create(ctx, &deck)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, err)
 				return
@@ -217,7 +213,7 @@ func handleUpsertDeck(c *gin.Context) {
 			storedDeck = &deck
 		} else {
 			fmt.Printf("Update Deck: url = %s\n", deck.Settings.URLHash)
-			storedDeck, err = collection.GetDeckByURLHash(deck.Settings.URLHash)
+			storedDeck, err = collection.GetDeckByURLHash(ctx, deck.Settings.URLHash)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, err)
 				return
@@ -229,7 +225,7 @@ func handleUpsertDeck(c *gin.Context) {
 					return
 				}
 				deck.ID = storedDeck.ID
-				storedDeck, err = collection.Update(&deck)
+				storedDeck, err = collection.Update(ctx, &deck)
 				if err != nil {
 					c.JSON(http.StatusInternalServerError, err)
 					return
@@ -237,7 +233,7 @@ func handleUpsertDeck(c *gin.Context) {
 			} else {
 				// Special case: Deck does not exist in DB
 				fmt.Println("Special case")
-				_, err = collection.Create(&deck)
+				_, err = collection.Create(ctx, &deck)
 				if err != nil {
 					c.JSON(http.StatusInternalServerError, err)
 					return
@@ -255,6 +251,7 @@ func handleUpsertDeck(c *gin.Context) {
 }
 
 func handleDeleteDeck(c *gin.Context) {
+	ctx := c.Request.Context()
 	if userID, ok := auth.GetUserIDFromAccessToken(c, true); ok {
 
 		urlHash := c.Param("urlHash")
@@ -263,14 +260,9 @@ func handleDeleteDeck(c *gin.Context) {
 			return
 		}
 
-		collection, err := db.GetDeckCollection()
-		defer collection.Disconnect()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, err)
-			return
-		}
+		collection := db.GetDeckStore()
 
-		storedDeck, err := collection.GetDeckByURLHash(urlHash)
+		storedDeck, err := collection.GetDeckByURLHash(ctx, urlHash)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, err)
 			return
@@ -282,7 +274,7 @@ func handleDeleteDeck(c *gin.Context) {
 				return
 			}
 
-			err = collection.Delete(storedDeck)
+			err = collection.Delete(ctx, storedDeck)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, err)
 				return
@@ -307,10 +299,10 @@ func deckIsEmpty(deck *Deck) bool {
 	return true
 }
 
-func generateUniqueURLHash(collection *db.DeckCollection) (string, error) {
+func generateUniqueURLHash(ctx context.Context, collection db.DeckStore) (string, error) {
 	hash := util.RandomString(10)
 	for {
-		checkedDeck, err := collection.GetDeckByURLHash(hash)
+		checkedDeck, err := collection.GetDeckByURLHash(ctx, hash)
 		if err != nil {
 			return "", err
 		}
@@ -339,13 +331,70 @@ func cardListToNames(cards []*cardDB.Card) []string {
 	return names
 }
 
-func dbDeckToDeckForOverview(deck *db.Deck) (Deck, error) {
-	collection, err := cardDB.GetCardCollection()
-	defer collection.Disconnect()
+// setOracleIDs stores the oracle IDs of the cards with the deck, so the cards are still found if they are renamed.
+// They are looked up by the card names, the oracle IDs sent by the client are only used for unknown names.
+func setOracleIDs(ctx context.Context, deck *db.Deck, inputDeck *Deck) error {
+	oracleIDs, err := cardDB.OracleIDsByName(ctx, cardDB.GetCardStore(), deck.CardNames())
 	if err != nil {
-		return Deck{}, err
+		return err
+	}
+	for _, cards := range [][]*cardDB.Card{inputDeck.Commanders, inputDeck.Deck, inputDeck.Library} {
+		for _, card := range cards {
+			if _, ok := oracleIDs[card.Name]; !ok && card.OracleID != "" {
+				oracleIDs[card.Name] = card.OracleID
+			}
+		}
+	}
+	deck.SetOracleIDs(oracleIDs)
+	return nil
+}
+
+// getDeckCards returns the cards with the names, found by their oracle ID if it is known, otherwise by their name
+func getDeckCards(ctx context.Context, names []string, oracleIDs []string) ([]*cardDB.Card, error) {
+	collection := cardDB.GetCardStore()
+	byOracleID := []string{}
+	byName := []string{}
+	for i, name := range names {
+		if i < len(oracleIDs) && oracleIDs[i] != "" {
+			byOracleID = append(byOracleID, oracleIDs[i])
+		} else {
+			byName = append(byName, name)
+		}
+	}
+
+	cards := []*cardDB.Card{}
+	if len(byOracleID) > 0 {
+		found, err := collection.GetCardsByOracleIDs(ctx, byOracleID)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, found...)
+	}
+	if len(byName) > 0 {
+		found, err := collection.GetCardsByNames(ctx, byName)
+		if err != nil {
+			return nil, err
+		}
+		for _, card := range found {
+			if !containsCard(cards, card) {
+				cards = append(cards, card)
+			}
+		}
 	}
-	commanders, err := collection.GetCardsByNames(deck.Commanders)
+	return cards, nil
+}
+
+func containsCard(cards []*cardDB.Card, card *cardDB.Card) bool {
+	for _, other := range cards {
+		if other.ID == card.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func dbDeckToDeckForOverview(ctx context.Context, deck *db.Deck) (Deck, error) {
+	commanders, err := getDeckCards(ctx, deck.Commanders, deck.CommanderOracleIDs)
 	if err != nil {
 		return Deck{}, err
 	}
@@ -358,31 +407,29 @@ func dbDeckToDeckForOverview(deck *db.Deck) (Deck, error) {
 	}, nil
 }
 
-func dbDeckToDeck(deck *db.Deck) (Deck, error) {
-	collection, err := cardDB.GetCardCollection()
-	defer collection.Disconnect()
-	if err != nil {
-		return Deck{}, err
-	}
-	commanders, err := collection.GetCardsByNames(deck.Commanders)
+func dbDeckToDeck(ctx context.Context, deck *db.Deck) (Deck, error) {
+	commanders, err := getDeckCards(ctx, deck.Commanders, deck.CommanderOracleIDs)
 	if err != nil {
 		return Deck{}, err
 	}
 	sort.Sort(ByName(commanders))
 
-	deckCards, err := collection.GetCardsByNames(deck.Deck)
+	deckCards, err := getDeckCards(ctx, deck.Deck, deck.DeckOracleIDs)
 	if err != nil {
 		return Deck{}, err
 	}
-	library, err := collection.GetCardsByNames(deck.Library)
+	library, err := getDeckCards(ctx, deck.Library, deck.LibraryOracleIDs)
 	if err != nil {
 		return Deck{}, err
 	}
 
+	cards := append(append([]*cardDB.Card{}, commanders...), deckCards...)
 	return Deck{
 		Commanders: commanders,
 		Deck:       deckCards,
 		Library:    library,
 		Settings:   deck.Settings,
+		Validation: validateDeck(deck, cards),
+		Statistics: deckStatistics(deck, cards),
 	}, nil
 }
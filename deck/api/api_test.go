@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cardDB "github.com/maedu/mtg-cards/card/db"
+	"github.com/maedu/mtg-cards/deck/db"
+	"github.com/maedu/mtg-cards/server"
+	"github.com/maedu/mtg-cards/user/auth"
+	userDB "github.com/maedu/mtg-cards/user/db"
+)
+
+func setupMemoryDecks() *httptest.Server {
+	cardDB.UseCardStore(cardDB.NewMemoryCardStore(
+		&cardDB.Card{Name: "Atraxa, Praetors' Voice"},
+		&cardDB.Card{Name: "Sol Ring"},
+		&cardDB.Card{Name: "Doubling Season"},
+	))
+	db.UseDeckStore(db.NewMemoryDeckStore(
+		&db.Deck{
+			UserID:     "someone@example.com",
+			Commanders: []string{"Atraxa, Praetors' Voice"},
+			Deck:       []string{"Sol Ring", "Doubling Season"},
+			Settings:   db.Settings{URLHash: "published", Published: true},
+		},
+		&db.Deck{
+			UserID:     "someone@example.com",
+			Commanders: []string{"Atraxa, Praetors' Voice"},
+			Settings:   db.Settings{URLHash: "private"},
+		},
+	))
+	userDB.UseUserStore(userDB.NewMemoryUserStore(
+		&userDB.User{UserID: "someone@example.com", UserName: "someone"},
+	))
+
+	server := server.Configure()
+	Setup(server)
+	return httptest.NewServer(server)
+}
+
+func TestHandleGetDeck(t *testing.T) {
+	ts := setupMemoryDecks()
+	defer ts.Close()
+
+	res, err := http.Get(fmt.Sprintf("%s/api/decks/published", ts.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("bad status: %s", res.Status)
+	}
+
+	var deck Deck
+	if err := json.NewDecoder(res.Body).Decode(&deck); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(deck.Commanders) != 1 || len(deck.Deck) != 2 {
+		t.Errorf("Expected 1 commander and 2 cards, got %d and %d", len(deck.Commanders), len(deck.Deck))
+	}
+	if deck.Validation == nil || deck.Validation.Format != "commander" || deck.Validation.Valid {
+		t.Errorf("Expected the deck to be validated as too small commander deck, got %v", deck.Validation)
+	}
+
+	for urlHash, want := range map[string]int{"private": http.StatusForbidden, "missing": http.StatusNotFound} {
+		res, err := http.Get(fmt.Sprintf("%s/api/decks/%s", ts.URL, urlHash))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		res.Body.Close()
+		if res.StatusCode != want {
+			t.Errorf("Expected status %d for %s, got %s", want, urlHash, res.Status)
+		}
+	}
+}
+
+func TestHandleGetUserDecks(t *testing.T) {
+	ts := setupMemoryDecks()
+	defer ts.Close()
+
+	res, err := http.Get(fmt.Sprintf("%s/api/decks?user=someone", ts.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer res.Body.Close()
+
+	var decks []Deck
+	if err := json.NewDecoder(res.Body).Decode(&decks); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(decks) != 1 || decks[0].Settings.URLHash != "published" {
+		t.Errorf("Expected only the published deck, got %v", decks)
+	}
+}
+
+func TestDeckOracleIDs(t *testing.T) {
+	ts := setupMemoryDecks()
+	defer ts.Close()
+	cardDB.UseCardStore(cardDB.NewMemoryCardStore(
+		&cardDB.Card{Name: "Atraxa, Praetors' Voice", OracleID: "atraxa"},
+		&cardDB.Card{Name: "Sol Ring", OracleID: "sol-ring"},
+	))
+	previous := auth.GetAuthenticator()
+	auth.UseAuthenticator(auth.StaticAuthenticator{"token": "someone@example.com"})
+	defer auth.UseAuthenticator(previous)
+
+	body := `{"commanders": [{"name": "Atraxa, Praetors' Voice"}], "deck": [{"name": "Sol Ring"}, {"name": "Unknown Card", "oracleId": "unknown"}], "settings": {"urlHash": "new"}}`
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/decks", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer token")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("bad status: %s", res.Status)
+	}
+
+	stored, _ := db.GetDeckStore().GetDeckByURLHash(context.Background(), "new")
+	if fmt.Sprint(stored.CommanderOracleIDs, stored.DeckOracleIDs) != "[atraxa] [sol-ring unknown]" {
+		t.Errorf("unexpected oracle IDs %v %v", stored.CommanderOracleIDs, stored.DeckOracleIDs)
+	}
+
+	// A renamed card is still found by its oracle ID
+	cardDB.UseCardStore(cardDB.NewMemoryCardStore(
+		&cardDB.Card{Name: "Atraxa, Praetors' Voice", OracleID: "atraxa"},
+		&cardDB.Card{Name: "Sol Ring, Renamed", OracleID: "sol-ring"},
+	))
+	deck, err := dbDeckToDeck(context.Background(), stored)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(deck.Deck) != 1 || deck.Deck[0].Name != "Sol Ring, Renamed" {
+		t.Errorf("Expected the renamed card, got %v", deck.Deck)
+	}
+}
+
+func TestHandleGetDeckTokens(t *testing.T) {
+	ts := setupMemoryDecks()
+	defer ts.Close()
+	cardDB.UseCardStore(cardDB.NewMemoryCardStore(
+		&cardDB.Card{Name: "Atraxa, Praetors' Voice", OracleID: "atraxa"},
+		&cardDB.Card{Name: "Sol Ring", OracleID: "sol-ring"},
+		&cardDB.Card{Name: "Doubling Season", OracleID: "doubling-season"},
+	))
+	cardDB.UseTokenStore(cardDB.NewMemoryTokenStore(
+		&cardDB.Token{ScryfallID: "emblem", Name: "Atraxa Emblem", IsEmblem: true, CreatedBy: []string{"atraxa"}},
+		&cardDB.Token{ScryfallID: "treasure", Name: "Treasure", CreatedBy: []string{"sol-ring", "atraxa", "other"}},
+		&cardDB.Token{ScryfallID: "goblin", Name: "Goblin", CreatedBy: []string{"other"}},
+	))
+
+	res, err := http.Get(fmt.Sprintf("%s/api/decks/published/tokens", ts.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("bad status: %s", res.Status)
+	}
+
+	var tokens []DeckToken
+	if err := json.NewDecoder(res.Body).Decode(&tokens); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got := []string{}
+	for _, token := range tokens {
+		got = append(got, fmt.Sprintf("%s %v", token.Name, token.Cards))
+	}
+	if fmt.Sprint(got) != "[Treasure [Atraxa, Praetors' Voice Sol Ring] Atraxa Emblem [Atraxa, Praetors' Voice]]" {
+		t.Errorf("unexpected tokens %v", got)
+	}
+
+	res, err = http.Get(fmt.Sprintf("%s/api/decks/private/tokens", ts.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %s", http.StatusForbidden, res.Status)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	cardDB "github.com/maedu/mtg-cards/card/db"
+	"github.com/maedu/mtg-cards/deck/db"
+)
+
+// DeckStatistics summarizes the commanders and the cards of a deck, every copy is counted
+type DeckStatistics struct {
+	Cards          int            `json:"cards"`
+	Lands          int            `json:"lands"`
+	Creatures      int            `json:"creatures"`
+	Planeswalkers  int            `json:"planeswalkers"`
+	ManaCurve      map[int]int    `json:"manaCurve"`
+	ManaSources    map[string]int `json:"manaSources"`
+	TotalPower     float64        `json:"totalPower"`
+	TotalToughness float64        `json:"totalToughness"`
+	ReservedCards  int            `json:"reservedCards"`
+}
+
+// deckStatistics calculates the statistics of the deck, the basic lands of the settings are only counted as lands
+func deckStatistics(deck *db.Deck, cards []*cardDB.Card) *DeckStatistics {
+	statistics := &DeckStatistics{
+		Cards:       deck.Settings.Lands,
+		Lands:       deck.Settings.Lands,
+		ManaCurve:   map[int]int{},
+		ManaSources: map[string]int{},
+	}
+
+	names := append(append([]string{}, deck.Commanders...), deck.Deck...)
+	oracleIDs := append(paddedOracleIDs(deck.CommanderOracleIDs, len(deck.Commanders)), deck.DeckOracleIDs...)
+	for i, name := range names {
+		statistics.Cards++
+		card := findDeckCard(cards, name, oracleIDAt(oracleIDs, i))
+		if card == nil {
+			continue
+		}
+
+		if hasCardType(card, cardDB.Land) {
+			statistics.Lands++
+		} else {
+			statistics.ManaCurve[int(card.Cmc)]++
+		}
+		for _, mana := range card.ProducedMana {
+			statistics.ManaSources[mana]++
+		}
+		if hasCardType(card, cardDB.Planeswalker) {
+			statistics.Planeswalkers++
+		}
+		if hasCardType(card, cardDB.Creature) {
+			statistics.Creatures++
+			// Double-faced creatures only have a power and toughness on their faces, the front face is used
+			stats := card
+			if stats.PowerValue == nil && len(card.CardFaces) > 0 {
+				stats = &card.CardFaces[0]
+			}
+			if stats.PowerValue != nil {
+				statistics.TotalPower += *stats.PowerValue
+			}
+			if stats.ToughnessValue != nil {
+				statistics.TotalToughness += *stats.ToughnessValue
+			}
+		}
+		if card.Reserved {
+			statistics.ReservedCards++
+		}
+	}
+	return statistics
+}
+
+func hasCardType(card *cardDB.Card, cardType cardDB.CardType) bool {
+	for _, other := range card.CardTypes {
+		if other == cardType {
+			return true
+		}
+	}
+	return false
+}
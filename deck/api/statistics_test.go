@@ -0,0 +1,36 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	cardDB "github.com/maedu/mtg-cards/card/db"
+	"github.com/maedu/mtg-cards/deck/db"
+)
+
+func TestDeckStatistics(t *testing.T) {
+	value := func(number float64) *float64 { return &number }
+	cards := []*cardDB.Card{
+		{Name: "Tymna the Weaver", OracleID: "tymna", Cmc: 3, CardTypes: []cardDB.CardType{cardDB.Creature}, PowerValue: value(2), ToughnessValue: value(2)},
+		{Name: "Llanowar Elves", Cmc: 1, CardTypes: []cardDB.CardType{cardDB.Creature}, ProducedMana: []string{"G"}, PowerValue: value(1), ToughnessValue: value(1)},
+		{Name: "Delver of Secrets // Insectile Aberration", Cmc: 1, CardTypes: []cardDB.CardType{cardDB.Creature},
+			CardFaces: []cardDB.Card{{PowerValue: value(1), ToughnessValue: value(1)}, {PowerValue: value(3), ToughnessValue: value(2)}}},
+		{Name: "Teferi, Time Raveler", Cmc: 3, CardTypes: []cardDB.CardType{cardDB.Planeswalker}, LoyaltyValue: value(4)},
+		{Name: "Bayou", CardTypes: []cardDB.CardType{cardDB.Land}, ProducedMana: []string{"B", "G"}, Reserved: true},
+	}
+	deck := &db.Deck{
+		Commanders:         []string{"Tymna the Weaver"},
+		CommanderOracleIDs: []string{"tymna"},
+		Deck:               []string{"Llanowar Elves", "Llanowar Elves", "Delver of Secrets // Insectile Aberration", "Teferi, Time Raveler", "Bayou", "Unknown Card"},
+		Settings:           db.Settings{Lands: 30},
+	}
+
+	statistics := deckStatistics(deck, cards)
+	got := fmt.Sprintf("%d cards, %d lands, %d creatures, %d planeswalkers, curve %v, sources %v, %v/%v, %d reserved",
+		statistics.Cards, statistics.Lands, statistics.Creatures, statistics.Planeswalkers, statistics.ManaCurve, statistics.ManaSources,
+		statistics.TotalPower, statistics.TotalToughness, statistics.ReservedCards)
+	want := "37 cards, 31 lands, 4 creatures, 1 planeswalkers, curve map[1:3 3:2], sources map[B:1 G:3], 5/5, 1 reserved"
+	if got != want {
+		t.Errorf("deckStatistics() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	cardDB "github.com/maedu/mtg-cards/card/db"
+)
+
+// DeckToken is a token or an emblem the deck can produce, Cards are the names of the cards of the deck creating it
+type DeckToken struct {
+	*cardDB.Token
+	Cards []string `json:"cards"`
+}
+
+// handleGetDeckTokens lists the tokens and emblems created by the commanders and the cards of the deck
+func handleGetDeckTokens(c *gin.Context) {
+	ctx := c.Request.Context()
+	deck, ok := getVisibleDeck(c)
+	if !ok {
+		return
+	}
+
+	commanders, err := getDeckCards(ctx, deck.Commanders, deck.CommanderOracleIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+	deckCards, err := getDeckCards(ctx, deck.Deck, deck.DeckOracleIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	namesByOracleID := map[string]string{}
+	oracleIDs := []string{}
+	for _, card := range append(commanders, deckCards...) {
+		if _, ok := namesByOracleID[card.OracleID]; !ok && card.OracleID != "" {
+			namesByOracleID[card.OracleID] = card.Name
+			oracleIDs = append(oracleIDs, card.OracleID)
+		}
+	}
+
+	tokens, err := cardDB.GetTokenStore().GetTokensCreatedBy(ctx, oracleIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+	deckTokens := []DeckToken{}
+	for _, token := range tokens {
+		names := []string{}
+		for _, oracleID := range token.CreatedBy {
+			if name, ok := namesByOracleID[oracleID]; ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		deckTokens = append(deckTokens, DeckToken{Token: token, Cards: names})
+	}
+
+	c.JSON(http.StatusOK, deckTokens)
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	cardDB "github.com/maedu/mtg-cards/card/db"
+	"github.com/maedu/mtg-cards/deck/db"
+)
+
+// DeckValidation is the result of checking a deck against the rules of the format of its type
+type DeckValidation struct {
+	Format   string   `json:"format"`
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
+}
+
+// formatRules are the deck construction rules of a format
+type formatRules struct {
+	minCards  int
+	maxCards  int
+	maxCopies int
+	commander bool
+}
+
+var formatRulesByName = map[string]formatRules{
+	"commander": {minCards: 100, maxCards: 100, maxCopies: 1, commander: true},
+	"standard":  {minCards: 60, maxCopies: 4},
+	"pioneer":   {minCards: 60, maxCopies: 4},
+	"modern":    {minCards: 60, maxCopies: 4},
+	"legacy":    {minCards: 60, maxCopies: 4},
+	"vintage":   {minCards: 60, maxCopies: 4},
+	"pauper":    {minCards: 60, maxCopies: 4},
+}
+
+// deckFormat returns the format of the deck type, decks without a type are commander decks
+func deckFormat(deckType string) string {
+	format := strings.ToLower(strings.TrimSpace(deckType))
+	if format == "" || format == "edh" {
+		return cardDB.DefaultFormat
+	}
+	return format
+}
+
+// validateDeck checks the commanders and the cards of the deck, the basic lands of the settings included.
+// Decks of a type without known rules are not validated.
+func validateDeck(deck *db.Deck, cards []*cardDB.Card) *DeckValidation {
+	format := deckFormat(deck.Settings.DeckType)
+	rules, ok := formatRulesByName[format]
+	if !ok {
+		return nil
+	}
+
+	problems := []string{}
+	size := len(deck.Commanders) + len(deck.Deck) + deck.Settings.Lands
+	if size < rules.minCards {
+		problems = append(problems, fmt.Sprintf("The deck has %d cards, at least %d are needed", size, rules.minCards))
+	}
+	if rules.maxCards > 0 && size > rules.maxCards {
+		problems = append(problems, fmt.Sprintf("The deck has %d cards, at most %d are allowed", size, rules.maxCards))
+	}
+
+	identity := map[string]bool{}
+	if rules.commander {
+		if len(deck.Commanders) == 0 {
+			problems = append(problems, "The deck has no commander")
+		}
+		for i, name := range deck.Commanders {
+			commander := findDeckCard(cards, name, oracleIDAt(deck.CommanderOracleIDs, i))
+			if commander == nil {
+				continue
+			}
+			if !commander.IsCommander {
+				problems = append(problems, fmt.Sprintf("%s can't be your commander", name))
+			}
+			for _, color := range commander.ColorIdentity {
+				identity[color] = true
+			}
+		}
+	}
+
+	copies := map[string]int{}
+	names := append(append([]string{}, deck.Commanders...), deck.Deck...)
+	oracleIDs := append(paddedOracleIDs(deck.CommanderOracleIDs, len(deck.Commanders)), deck.DeckOracleIDs...)
+	cardsByName := map[string]*cardDB.Card{}
+	for i, name := range names {
+		copies[name]++
+		if copies[name] > 1 {
+			continue
+		}
+
+		card := findDeckCard(cards, name, oracleIDAt(oracleIDs, i))
+		cardsByName[name] = card
+		if card == nil {
+			problems = append(problems, fmt.Sprintf("%s is unknown", name))
+			continue
+		}
+		switch card.Legality(format) {
+		case cardDB.Banned:
+			problems = append(problems, fmt.Sprintf("%s is banned in %s", name, format))
+		case cardDB.NotLegal:
+			problems = append(problems, fmt.Sprintf("%s is not legal in %s", name, format))
+		}
+		if rules.commander && i >= len(deck.Commanders) {
+			for _, color := range card.ColorIdentity {
+				if !identity[color] {
+					problems = append(problems, fmt.Sprintf("%s is outside the color identity of the commander", name))
+					break
+				}
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(copies) {
+		card := cardsByName[name]
+		if card != nil && allowsAnyNumber(card) {
+			continue
+		}
+		maxCopies := rules.maxCopies
+		if card != nil && card.Legality(format) == cardDB.Restricted {
+			maxCopies = 1
+		}
+		if copies[name] > maxCopies {
+			problems = append(problems, fmt.Sprintf("The deck has %d copies of %s, at most %d are allowed", copies[name], name, maxCopies))
+		}
+	}
+
+	return &DeckValidation{
+		Format:   format,
+		Valid:    len(problems) == 0,
+		Problems: problems,
+	}
+}
+
+// findDeckCard returns the card with the oracle ID, or with the name if the oracle ID is unknown
+func findDeckCard(cards []*cardDB.Card, name string, oracleID string) *cardDB.Card {
+	for _, card := range cards {
+		if (oracleID != "" && card.OracleID == oracleID) || (oracleID == "" && card.Name == name) {
+			return card
+		}
+	}
+	if oracleID != "" {
+		return findDeckCard(cards, name, "")
+	}
+	return nil
+}
+
+// allowsAnyNumber returns whether a deck may contain any number of copies of the card, like basic lands
+func allowsAnyNumber(card *cardDB.Card) bool {
+	return strings.HasPrefix(card.TypeLine, "Basic") || strings.Contains(card.OracleText, "A deck can have any number of cards named")
+}
+
+func oracleIDAt(oracleIDs []string, i int) string {
+	if i < len(oracleIDs) {
+		return oracleIDs[i]
+	}
+	return ""
+}
+
+func paddedOracleIDs(oracleIDs []string, length int) []string {
+	padded := make([]string, length)
+	copy(padded, oracleIDs)
+	return padded
+}
+
+func sortedKeys(values map[string]int) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	cardDB "github.com/maedu/mtg-cards/card/db"
+	"github.com/maedu/mtg-cards/deck/db"
+)
+
+func repeat(name string, count int) []string {
+	names := []string{}
+	for i := 0; i < count; i++ {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestValidateDeck(t *testing.T) {
+	legal := func(formats ...string) map[string]string {
+		legalities := map[string]string{}
+		for _, format := range formats {
+			legalities[format] = cardDB.Legal
+		}
+		return legalities
+	}
+	cards := []*cardDB.Card{
+		{Name: "Tymna the Weaver", OracleID: "tymna", TypeLine: "Legendary Creature — Human Cleric", ColorIdentity: []string{"W", "B"}, IsCommander: true, Legalities: legal("commander", "legacy")},
+		{Name: "Swords to Plowshares", ColorIdentity: []string{"W"}, Legalities: legal("commander", "legacy")},
+		{Name: "Counterspell", ColorIdentity: []string{"U"}, Legalities: map[string]string{"commander": cardDB.Legal, "legacy": cardDB.Legal, "modern": cardDB.NotLegal}},
+		{Name: "Ragavan, Nimble Pilferer", ColorIdentity: []string{"R"}, Legalities: map[string]string{"commander": cardDB.Legal, "modern": cardDB.Banned}},
+		{Name: "Lightning Bolt", ColorIdentity: []string{"R"}, Legalities: legal("commander", "modern", "pauper")},
+		{Name: "Ancestral Recall", ColorIdentity: []string{"U"}, Legalities: map[string]string{"vintage": cardDB.Restricted}},
+		{Name: "Relentless Rats", OracleText: "A deck can have any number of cards named Relentless Rats.", Legalities: legal("modern")},
+		{Name: "Plains", TypeLine: "Basic Land — Plains", Legalities: legal("commander", "modern")},
+	}
+
+	tests := []struct {
+		name string
+		deck db.Deck
+		want []string
+	}{
+		{
+			name: "Valid commander deck",
+			deck: db.Deck{Commanders: []string{"Tymna the Weaver"}, Deck: append([]string{"Swords to Plowshares"}, repeat("Plains", 8)...), Settings: db.Settings{Lands: 90}},
+		},
+		{
+			name: "Commander deck",
+			deck: db.Deck{Commanders: []string{"Swords to Plowshares"}, Deck: []string{"Counterspell", "Counterspell", "Unknown Card"}, Settings: db.Settings{DeckType: "Commander", Lands: 90}},
+			want: []string{
+				"The deck has 94 cards, at least 100 are needed",
+				"Swords to Plowshares can't be your commander",
+				"Counterspell is outside the color identity of the commander",
+				"Unknown Card is unknown",
+				"The deck has 2 copies of Counterspell, at most 1 are allowed",
+			},
+		},
+		{
+			name: "Modern deck",
+			deck: db.Deck{Deck: append(append(repeat("Lightning Bolt", 5), "Ragavan, Nimble Pilferer", "Counterspell"), repeat("Relentless Rats", 20)...), Settings: db.Settings{DeckType: "modern", Lands: 24}},
+			want: []string{
+				"The deck has 51 cards, at least 60 are needed",
+				"Ragavan, Nimble Pilferer is banned in modern",
+				"Counterspell is not legal in modern",
+				"The deck has 5 copies of Lightning Bolt, at most 4 are allowed",
+			},
+		},
+		{
+			name: "Restricted card in vintage",
+			deck: db.Deck{Deck: repeat("Ancestral Recall", 2), Settings: db.Settings{DeckType: "vintage", Lands: 58}},
+			want: []string{"The deck has 2 copies of Ancestral Recall, at most 1 are allowed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validation := validateDeck(&tt.deck, cards)
+			if validation.Valid != (len(tt.want) == 0) || fmt.Sprintf("%q", validation.Problems) != fmt.Sprintf("%q", append([]string{}, tt.want...)) {
+				t.Errorf("validateDeck() = %v %q, want %q", validation.Valid, validation.Problems, tt.want)
+			}
+		})
+	}
+
+	if validation := validateDeck(&db.Deck{Settings: db.Settings{DeckType: "Cube"}}, cards); validation != nil {
+		t.Errorf("Expected no validation of an unknown format, got %v", validation)
+	}
+}
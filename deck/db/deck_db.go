@@ -28,47 +28,101 @@ type Deck struct {
 	Deck       []string           `bson:"deck" json:"deck"`
 	Library    []string           `bson:"library" json:"library"`
 	Settings   Settings           `bson:"settings" json:"settings"`
+	// CommanderOracleIDs, DeckOracleIDs and LibraryOracleIDs are the oracle IDs of the cards in the order of their names, empty for unknown cards
+	CommanderOracleIDs []string `bson:"commander_oracle_ids" json:"-"`
+	DeckOracleIDs      []string `bson:"deck_oracle_ids" json:"-"`
+	LibraryOracleIDs   []string `bson:"library_oracle_ids" json:"-"`
+}
+
+// CardNames returns the names of all cards of the deck
+func (deck *Deck) CardNames() []string {
+	names := append([]string{}, deck.Commanders...)
+	names = append(names, deck.Deck...)
+	return append(names, deck.Library...)
+}
+
+// SetOracleIDs sets the oracle IDs of the cards from the oracle IDs by card name, oracle IDs already known are kept
+func (deck *Deck) SetOracleIDs(oracleIDs map[string]string) {
+	toOracleIDs := func(names []string, existing []string) []string {
+		ids := []string{}
+		for i, name := range names {
+			if i < len(existing) && existing[i] != "" {
+				ids = append(ids, existing[i])
+			} else {
+				ids = append(ids, oracleIDs[name])
+			}
+		}
+		return ids
+	}
+	deck.CommanderOracleIDs = toOracleIDs(deck.Commanders, deck.CommanderOracleIDs)
+	deck.DeckOracleIDs = toOracleIDs(deck.Deck, deck.DeckOracleIDs)
+	deck.LibraryOracleIDs = toOracleIDs(deck.Library, deck.LibraryOracleIDs)
 }
 
 // DeckCollection ...
 type DeckCollection struct {
-	*mongo.Client
 	*mongo.Collection
-	context.Context
-	context.CancelFunc
 }
 
-// GetDeckCollection ...
-func GetDeckCollection() (DeckCollection, error) {
-	client, ctx, cancel := db.GetConnection()
-	db := client.Database(db.GetDatabaseName())
-	collection := db.Collection("decks")
+// NewDeckCollection creates the DeckCollection using the shared client
+func NewDeckCollection(client *mongo.Client) *DeckCollection {
+	return &DeckCollection{
+		Collection: client.Database(db.GetDatabaseName()).Collection("decks"),
+	}
+}
 
+// CreateUserIDIndex creates the index used to find the decks of a user
+func CreateUserIDIndex(ctx context.Context, database *mongo.Database) error {
 	model := mongo.IndexModel{
 		Keys: bson.M{
 			"user_id": 1,
 		}, Options: nil,
 	}
-	_, err := collection.Indexes().CreateOne(ctx, model)
-
-	return DeckCollection{
-		Client:     client,
-		Collection: collection,
-		Context:    ctx,
-		CancelFunc: cancel,
-	}, err
+	_, err := database.Collection("decks").Indexes().CreateOne(ctx, model)
+	return err
 }
 
-// Disconnect ...
-func (collection *DeckCollection) Disconnect() {
-	collection.CancelFunc()
-	collection.Client.Disconnect(collection.Context)
+// BackfillOracleIDs sets the missing oracle IDs of the cards of the decks, oracleIDs returns them by card name.
+// It can run repeatedly, only the oracle IDs which are still empty are looked up.
+func BackfillOracleIDs(ctx context.Context, database *mongo.Database, oracleIDs func(ctx context.Context, names []string) (map[string]string, error)) error {
+	collection := database.Collection("decks")
+	cursor, err := collection.Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"deck_oracle_ids": bson.M{"$exists": false}},
+		bson.M{"commander_oracle_ids": ""},
+		bson.M{"deck_oracle_ids": ""},
+		bson.M{"library_oracle_ids": ""},
+	}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var deck Deck
+		err = cursor.Decode(&deck)
+		if err != nil {
+			return err
+		}
+		ids, err := oracleIDs(ctx, deck.CardNames())
+		if err != nil {
+			return err
+		}
+		deck.SetOracleIDs(ids)
+		_, err = collection.UpdateOne(ctx, bson.M{"_id": deck.ID}, bson.M{"$set": bson.M{
+			"commander_oracle_ids": deck.CommanderOracleIDs,
+			"deck_oracle_ids":      deck.DeckOracleIDs,
+			"library_oracle_ids":   deck.LibraryOracleIDs,
+		}})
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
 }
 
 // GetAllDecks Retrives all decks from the db
-func (collection *DeckCollection) GetAllDecks() ([]*Deck, error) {
+func (collection *DeckCollection) GetAllDecks(ctx context.Context) ([]*Deck, error) {
 	var decks []*Deck = []*Deck{}
-	ctx := collection.Context
 
 	cursor, err := collection.Collection.Find(ctx, bson.D{})
 	if err != nil {
@@ -84,8 +138,7 @@ func (collection *DeckCollection) GetAllDecks() ([]*Deck, error) {
 }
 
 // GetDecksByUserID retrieves decks for the user from the db
-func (collection *DeckCollection) GetDecksByUserID(userID string, publishedOnly bool) ([]*Deck, error) {
-	ctx := collection.Context
+func (collection *DeckCollection) GetDecksByUserID(ctx context.Context, userID string, publishedOnly bool) ([]*Deck, error) {
 	var decks []*Deck = []*Deck{}
 
 	filter := bson.M{"user_id": userID}
@@ -116,9 +169,8 @@ func (collection *DeckCollection) GetDecksByUserID(userID string, publishedOnly
 }
 
 // GetDeckByKey Retrives a scryfallcard by its key from the db
-func (collection *DeckCollection) GetDeckByURLHash(urlHash string) (*Deck, error) {
+func (collection *DeckCollection) GetDeckByURLHash(ctx context.Context, urlHash string) (*Deck, error) {
 	var deck *Deck
-	ctx := collection.Context
 
 	result := collection.Collection.FindOne(ctx, bson.D{bson.E{Key: "settings.urlHash", Value: urlHash}})
 	if result == nil {
@@ -138,8 +190,7 @@ func (collection *DeckCollection) GetDeckByURLHash(urlHash string) (*Deck, error
 }
 
 // Create creating a deck in a mongo
-func (collection *DeckCollection) Create(deck *Deck) (primitive.ObjectID, error) {
-	ctx := collection.Context
+func (collection *DeckCollection) Create(ctx context.Context, deck *Deck) (primitive.ObjectID, error) {
 	deck.ID = primitive.NewObjectID()
 
 	result, err := collection.Collection.InsertOne(ctx, deck)
@@ -151,8 +202,7 @@ func (collection *DeckCollection) Create(deck *Deck) (primitive.ObjectID, error)
 	return oid, nil
 }
 
-func (collection *DeckCollection) Update(deck *Deck) (*Deck, error) {
-	ctx := collection.Context
+func (collection *DeckCollection) Update(ctx context.Context, deck *Deck) (*Deck, error) {
 	var updatedDeck *Deck
 
 	update := bson.M{
@@ -174,8 +224,7 @@ func (collection *DeckCollection) Update(deck *Deck) (*Deck, error) {
 	return updatedDeck, nil
 }
 
-func (collection *DeckCollection) Delete(deck *Deck) error {
-	ctx := collection.Context
+func (collection *DeckCollection) Delete(ctx context.Context, deck *Deck) error {
 
 	_, err := collection.Collection.DeleteOne(ctx, bson.M{"_id": deck.ID})
 	if err != nil {
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryDeckStore is a DeckStore keeping all decks in memory, used by tests and when running without MongoDB
+type MemoryDeckStore struct {
+	mutex sync.RWMutex
+	decks []*Deck
+}
+
+// NewMemoryDeckStore creates a MemoryDeckStore containing the given decks
+func NewMemoryDeckStore(decks ...*Deck) *MemoryDeckStore {
+	store := &MemoryDeckStore{}
+	for _, deck := range decks {
+		store.Create(context.Background(), deck)
+	}
+	return store
+}
+
+// GetAllDecks returns copies of all decks
+func (store *MemoryDeckStore) GetAllDecks(ctx context.Context) ([]*Deck, error) {
+	return store.find(func(deck *Deck) bool { return true }), nil
+}
+
+// GetDecksByUserID returns the decks of the user
+func (store *MemoryDeckStore) GetDecksByUserID(ctx context.Context, userID string, publishedOnly bool) ([]*Deck, error) {
+	return store.find(func(deck *Deck) bool {
+		return deck.UserID == userID && (!publishedOnly || deck.Settings.Published)
+	}), nil
+}
+
+// GetDeckByURLHash returns the deck with the url hash or nil if there is none
+func (store *MemoryDeckStore) GetDeckByURLHash(ctx context.Context, urlHash string) (*Deck, error) {
+	decks := store.find(func(deck *Deck) bool { return deck.Settings.URLHash == urlHash })
+	if len(decks) == 0 {
+		return nil, nil
+	}
+	return decks[0], nil
+}
+
+// Create adds a deck
+func (store *MemoryDeckStore) Create(ctx context.Context, deck *Deck) (primitive.ObjectID, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	deck.ID = primitive.NewObjectID()
+	store.decks = append(store.decks, copyDeck(deck))
+	return deck.ID, nil
+}
+
+// Update replaces the deck with the same ID, or adds it if there is none
+func (store *MemoryDeckStore) Update(ctx context.Context, deck *Deck) (*Deck, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for i, storedDeck := range store.decks {
+		if storedDeck.ID == deck.ID {
+			store.decks[i] = copyDeck(deck)
+			return copyDeck(deck), nil
+		}
+	}
+	store.decks = append(store.decks, copyDeck(deck))
+	return copyDeck(deck), nil
+}
+
+// Delete removes the deck with the same ID
+func (store *MemoryDeckStore) Delete(ctx context.Context, deck *Deck) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for i, storedDeck := range store.decks {
+		if storedDeck.ID == deck.ID {
+			store.decks = append(store.decks[:i], store.decks[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (store *MemoryDeckStore) find(matches func(deck *Deck) bool) []*Deck {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	decks := []*Deck{}
+	for _, deck := range store.decks {
+		if matches(deck) {
+			decks = append(decks, copyDeck(deck))
+		}
+	}
+	return decks
+}
+
+// copyDeck copies the deck, so callers can't modify the stored one
+func copyDeck(deck *Deck) *Deck {
+	copied := *deck
+	copied.Commanders = append([]string{}, deck.Commanders...)
+	copied.Deck = append([]string{}, deck.Deck...)
+	copied.Library = append([]string{}, deck.Library...)
+	copied.CommanderOracleIDs = append([]string{}, deck.CommanderOracleIDs...)
+	copied.DeckOracleIDs = append([]string{}, deck.DeckOracleIDs...)
+	copied.LibraryOracleIDs = append([]string{}, deck.LibraryOracleIDs...)
+	return &copied
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeckStore is the storage of the decks used by the handlers
+type DeckStore interface {
+	GetAllDecks(ctx context.Context) ([]*Deck, error)
+	GetDecksByUserID(ctx context.Context, userID string, publishedOnly bool) ([]*Deck, error)
+	GetDeckByURLHash(ctx context.Context, urlHash string) (*Deck, error)
+	Create(ctx context.Context, deck *Deck) (primitive.ObjectID, error)
+	Update(ctx context.Context, deck *Deck) (*Deck, error)
+	Delete(ctx context.Context, deck *Deck) error
+}
+
+var deckStore DeckStore
+
+// GetDeckStore returns the DeckStore configured at startup with UseDeckStore
+func GetDeckStore() DeckStore {
+	return deckStore
+}
+
+// UseDeckStore sets the DeckStore returned by GetDeckStore, e.g. a DeckCollection or a MemoryDeckStore
+func UseDeckStore(store DeckStore) {
+	deckStore = store
+}
@@ -14,13 +14,14 @@ func Setup(r *gin.Engine) {
 }
 
 func handleGetSealed(c *gin.Context) {
+	ctx := c.Request.Context()
 	setNames := c.QueryArray("set")
 	boosterType := c.Query("type")
 	if boosterType == "" {
 		boosterType = booster.Commander
 	}
 
-	boosters, err := booster.GenerateBoosters(boosterType, setNames)
+	boosters, err := booster.GenerateBoosters(ctx, boosterType, setNames)
 	if err != nil {
 		c.Error(err)
 		return
@@ -1,59 +1,128 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"sort"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	cardAPI "github.com/maedu/mtg-cards/card/api"
 	edhrecDB "github.com/maedu/mtg-cards/edhrec/db"
 	"github.com/maedu/mtg-cards/edhrec/parser"
+	jobDB "github.com/maedu/mtg-cards/job/db"
+	"github.com/maedu/mtg-cards/user/auth"
+	userDB "github.com/maedu/mtg-cards/user/db"
 )
 
 // Setup Setup REST API
 func Setup(r *gin.Engine) {
-	r.GET("/api/edhrec/synergy/:name", handleSynergy)
+	r.GET("/api/edhrec/synergy/:name", requireRoleToUpdate, handleSynergy)
+	r.POST("/api/edhrec/synergy/:name/update", auth.RequireRole(userDB.AdminRole), handleUpdateSynergy)
 }
 
-func handleSynergy(c *gin.Context) {
-	mainCard := c.Param("name")
-	update := c.Query("update")
+// requireRoleToUpdate only lets admins pass if the synergies are to be updated with ?update=
+func requireRoleToUpdate(c *gin.Context) {
+	if c.Query("update") == "" {
+		c.Next()
+		return
+	}
+	auth.RequireRole(userDB.AdminRole)(c)
+}
 
-	collection, err := edhrecDB.GetEdhrecSynergyCollection()
-	defer collection.Disconnect()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, err)
+// handleSynergy returns the synergies of the main card, they are fetched from EDHREC on the first request.
+// The cards get the fetched synergies with their next transformation, only admins update them right away with ?update=.
+func handleSynergy(c *gin.Context) {
+	if c.Query("update") != "" {
+		handleUpdateSynergy(c)
 		return
 	}
-	edhRecCards, err := collection.GetEdhrecSynergysByMainCard(mainCard)
-	collection.Disconnect()
+
+	ctx := c.Request.Context()
+	mainCard := c.Param("name")
+
+	collection := edhrecDB.GetSynergyStore()
+	edhRecCards, err := collection.GetEdhrecSynergysByMainCard(ctx, mainCard)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return
 	}
-
-	if len(edhRecCards) == 0 || update != "" {
-		edhRecCards, err := parser.FetchCommander(mainCard)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, err)
-			return
-		}
-		collection, err = edhrecDB.GetEdhrecSynergyCollection()
-		err = collection.ReplaceAllOfMainCard(mainCard, edhRecCards)
+	if len(edhRecCards) == 0 {
+		edhRecCards, err = parser.FetchCommander(mainCard)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, err)
 			return
 		}
-		err = cardAPI.TransformCards()
+		err = collection.ReplaceAllOfMainCard(ctx, mainCard, edhRecCards)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, err)
 			return
 		}
+	}
 
+	c.JSON(http.StatusOK, synergiesByCard(edhRecCards))
+}
+
+// handleUpdateSynergy enqueues a job fetching the synergies of the main card from EDHREC and transforming the cards with them
+func handleUpdateSynergy(c *gin.Context) {
+	updateJob, err := EnqueueUpdateSynergy(c.Param("name"))
+	if err != nil {
+		fmt.Printf("Error enqueuing synergy update: %v\n", err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
 	}
 
+	c.JSON(http.StatusAccepted, updateJob)
+}
+
+// UpdateSynergyJob is the type of the jobs updating the synergies of main cards
+const UpdateSynergyJob = "update_synergy"
+
+var (
+	pendingMutex sync.Mutex
+	// pendingMainCards are the main cards whose synergies are updated by the next job, a queued job updates all of them
+	pendingMainCards = map[string]bool{}
+)
+
+// EnqueueUpdateSynergy adds a job fetching the synergies of the main card from EDHREC, storing them and transforming the cards
+func EnqueueUpdateSynergy(mainCard string) (*jobDB.Job, error) {
+	pendingMutex.Lock()
+	pendingMainCards[mainCard] = true
+	pendingMutex.Unlock()
+
+	return cardAPI.EnqueueCardsJob(UpdateSynergyJob, func(ctx context.Context, report func(progress jobDB.Progress)) error {
+		pendingMutex.Lock()
+		mainCards := []string{}
+		for pending := range pendingMainCards {
+			mainCards = append(mainCards, pending)
+		}
+		pendingMainCards = map[string]bool{}
+		pendingMutex.Unlock()
+		sort.Strings(mainCards)
+
+		for i, mainCard := range mainCards {
+			report(jobDB.Progress{Current: int64(i), Total: int64(len(mainCards)), Message: fmt.Sprintf("Fetching synergies of %s", mainCard)})
+			edhRecCards, err := parser.FetchCommander(mainCard)
+			if err != nil {
+				return fmt.Errorf("fetching synergies of %s: %w", mainCard, err)
+			}
+			err = edhrecDB.GetSynergyStore().ReplaceAllOfMainCard(ctx, mainCard, edhRecCards)
+			if err != nil {
+				return fmt.Errorf("storing synergies of %s: %w", mainCard, err)
+			}
+		}
+		if len(mainCards) == 0 {
+			return nil
+		}
+		return cardAPI.TransformCards(ctx, report)
+	})
+}
+
+func synergiesByCard(edhRecCards []edhrecDB.EdhrecSynergy) map[string]float64 {
 	cards := map[string]float64{}
 	for _, edhRecCard := range edhRecCards {
 		cards[edhRecCard.CardWithSynergy] = edhRecCard.Synergy
 	}
-	c.JSON(http.StatusOK, cards)
+	return cards
 }
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cardAPI "github.com/maedu/mtg-cards/card/api"
+	edhrecDB "github.com/maedu/mtg-cards/edhrec/db"
+	"github.com/maedu/mtg-cards/job"
+	jobDB "github.com/maedu/mtg-cards/job/db"
+	"github.com/maedu/mtg-cards/server"
+	"github.com/maedu/mtg-cards/user/auth"
+	userDB "github.com/maedu/mtg-cards/user/db"
+)
+
+func TestHandleSynergyUpdateRequiresAdmin(t *testing.T) {
+	edhrecDB.UseSynergyStore(edhrecDB.NewMemorySynergyStore(
+		edhrecDB.EdhrecSynergy{MainCard: "Tymna the Weaver", CardWithSynergy: "Esper Sentinel", Synergy: 0.6},
+	))
+	userDB.UseUserStore(userDB.NewMemoryUserStore(&userDB.User{UserID: "user@example.com", UserName: "user"}))
+	previous := auth.GetAuthenticator()
+	auth.UseAuthenticator(auth.StaticAuthenticator{"user": "user@example.com"})
+	defer auth.UseAuthenticator(previous)
+
+	server := server.Configure()
+	Setup(server)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	tests := []struct {
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{http.MethodGet, "/api/edhrec/synergy/Tymna%20the%20Weaver", "", http.StatusOK},
+		{http.MethodGet, "/api/edhrec/synergy/Tymna%20the%20Weaver?update=1", "", http.StatusUnauthorized},
+		{http.MethodGet, "/api/edhrec/synergy/Tymna%20the%20Weaver?update=1", "user", http.StatusForbidden},
+		{http.MethodPost, "/api/edhrec/synergy/Thrasios,%20Triton%20Hero/update", "", http.StatusUnauthorized},
+		{http.MethodPost, "/api/edhrec/synergy/Thrasios,%20Triton%20Hero/update", "user", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s %s", tt.method, tt.path, tt.token), func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, ts.URL+tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			res.Body.Close()
+			if res.StatusCode != tt.status {
+				t.Errorf("Expected status %d, got %s", tt.status, res.Status)
+			}
+		})
+	}
+}
+
+func TestEnqueueUpdateSynergyUpdatesAllPendingMainCards(t *testing.T) {
+	jobDB.UseJobStore(jobDB.NewMemoryJobStore())
+
+	// A running job replacing the cards keeps the synergy updates queued
+	release := make(chan bool)
+	running, err := cardAPI.EnqueueCardsJob("test", func(ctx context.Context, report func(progress jobDB.Progress)) error {
+		<-release
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tymna, err := EnqueueUpdateSynergy("Tymna the Weaver")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	thrasios, err := EnqueueUpdateSynergy("Thrasios, Triton Hero")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tymna.ID != thrasios.ID || tymna.Status != jobDB.Queued {
+		t.Errorf("Expected one queued job for both main cards, got %+v and %+v", tymna, thrasios)
+	}
+
+	pendingMutex.Lock()
+	if !pendingMainCards["Tymna the Weaver"] || !pendingMainCards["Thrasios, Triton Hero"] {
+		t.Errorf("Expected both main cards to be pending, got %v", pendingMainCards)
+	}
+	// Nothing is fetched from EDHREC once the job runs
+	pendingMainCards = map[string]bool{}
+	pendingMutex.Unlock()
+
+	close(release)
+	for _, id := range []string{running.ID, tymna.ID} {
+		done, err := job.Wait(context.Background(), id)
+		if err != nil || done.Status != jobDB.Succeeded {
+			t.Errorf("Expected job %s to succeed, got %+v %v", id, done, err)
+		}
+	}
+}
@@ -20,43 +20,30 @@ type EdhrecSynergy struct {
 
 // EdhrecSynergyCollection ...
 type EdhrecSynergyCollection struct {
-	*mongo.Client
 	*mongo.Collection
-	context.Context
-	context.CancelFunc
 }
 
-// GetEdhrecSynergyCollection ...
-func GetEdhrecSynergyCollection() (EdhrecSynergyCollection, error) {
-	client, ctx, cancel := db.GetConnection()
-	db := client.Database(db.GetDatabaseName())
-	collection := db.Collection("edhrec_synergies")
+// NewEdhrecSynergyCollection creates the EdhrecSynergyCollection using the shared client
+func NewEdhrecSynergyCollection(client *mongo.Client) *EdhrecSynergyCollection {
+	return &EdhrecSynergyCollection{
+		Collection: client.Database(db.GetDatabaseName()).Collection("edhrec_synergies"),
+	}
+}
 
+// CreateMainCardIndex creates the index used to find the synergies of a main card
+func CreateMainCardIndex(ctx context.Context, database *mongo.Database) error {
 	model := mongo.IndexModel{
 		Keys: bson.M{
 			"main_card": 1,
 		}, Options: nil,
 	}
-	_, err := collection.Indexes().CreateOne(ctx, model)
-
-	return EdhrecSynergyCollection{
-		Client:     client,
-		Collection: collection,
-		Context:    ctx,
-		CancelFunc: cancel,
-	}, err
-}
-
-// Disconnect ...
-func (collection *EdhrecSynergyCollection) Disconnect() {
-	collection.CancelFunc()
-	collection.Client.Disconnect(collection.Context)
+	_, err := database.Collection("edhrec_synergies").Indexes().CreateOne(ctx, model)
+	return err
 }
 
 // GetAllEdhrecSynergys Retrives all edhrecsynergys from the db
-func (collection *EdhrecSynergyCollection) GetAllEdhrecSynergys() ([]*EdhrecSynergy, error) {
+func (collection *EdhrecSynergyCollection) GetAllEdhrecSynergys(ctx context.Context) ([]*EdhrecSynergy, error) {
 	var edhrecsynergys []*EdhrecSynergy = []*EdhrecSynergy{}
-	ctx := collection.Context
 
 	cursor, err := collection.Collection.Find(ctx, bson.D{})
 	if err != nil {
@@ -72,8 +59,7 @@ func (collection *EdhrecSynergyCollection) GetAllEdhrecSynergys() ([]*EdhrecSyne
 }
 
 // GetEdhrecSynergysByMainCard retrieves edhrecsynergys by their main card from the db
-func (collection *EdhrecSynergyCollection) GetEdhrecSynergysByMainCard(mainCard string) ([]EdhrecSynergy, error) {
-	ctx := collection.Context
+func (collection *EdhrecSynergyCollection) GetEdhrecSynergysByMainCard(ctx context.Context, mainCard string) ([]EdhrecSynergy, error) {
 	var edhrecsynergys []EdhrecSynergy = []EdhrecSynergy{}
 	cursor, err := collection.Collection.Find(ctx, bson.D{bson.E{Key: "main_card", Value: mainCard}})
 	if err != nil {
@@ -90,8 +76,7 @@ func (collection *EdhrecSynergyCollection) GetEdhrecSynergysByMainCard(mainCard
 }
 
 // ReplaceAllOfMainCard first delete all for main card and then create many cards in a mongo db
-func (collection *EdhrecSynergyCollection) ReplaceAllOfMainCard(mainCard string, edhrecSynergys []EdhrecSynergy) error {
-	ctx := collection.Context
+func (collection *EdhrecSynergyCollection) ReplaceAllOfMainCard(ctx context.Context, mainCard string, edhrecSynergys []EdhrecSynergy) error {
 
 	filter := bson.M{"main_card": bson.M{"$eq": mainCard}}
 	result, err := collection.Collection.DeleteMany(ctx, filter)
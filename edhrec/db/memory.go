@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemorySynergyStore is a SynergyStore keeping all synergies in memory, used by tests and when running without MongoDB
+type MemorySynergyStore struct {
+	mutex     sync.RWMutex
+	synergies []EdhrecSynergy
+}
+
+// NewMemorySynergyStore creates a MemorySynergyStore containing the given synergies
+func NewMemorySynergyStore(synergies ...EdhrecSynergy) *MemorySynergyStore {
+	store := &MemorySynergyStore{}
+	for _, synergy := range synergies {
+		if synergy.ID == "" {
+			synergy.ID = primitive.NewObjectID().Hex()
+		}
+		store.synergies = append(store.synergies, synergy)
+	}
+	return store
+}
+
+// GetAllEdhrecSynergys returns copies of all synergies
+func (store *MemorySynergyStore) GetAllEdhrecSynergys(ctx context.Context) ([]*EdhrecSynergy, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	synergies := []*EdhrecSynergy{}
+	for _, synergy := range store.synergies {
+		copied := synergy
+		synergies = append(synergies, &copied)
+	}
+	return synergies, nil
+}
+
+// GetEdhrecSynergysByMainCard returns the synergies of the main card
+func (store *MemorySynergyStore) GetEdhrecSynergysByMainCard(ctx context.Context, mainCard string) ([]EdhrecSynergy, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	synergies := []EdhrecSynergy{}
+	for _, synergy := range store.synergies {
+		if synergy.MainCard == mainCard {
+			synergies = append(synergies, synergy)
+		}
+	}
+	return synergies, nil
+}
+
+// ReplaceAllOfMainCard removes all synergies of the main card and adds the given ones
+func (store *MemorySynergyStore) ReplaceAllOfMainCard(ctx context.Context, mainCard string, edhrecSynergys []EdhrecSynergy) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	kept := []EdhrecSynergy{}
+	for _, synergy := range store.synergies {
+		if synergy.MainCard != mainCard {
+			kept = append(kept, synergy)
+		}
+	}
+	for _, synergy := range edhrecSynergys {
+		if synergy.ID == "" {
+			synergy.ID = primitive.NewObjectID().Hex()
+		}
+		kept = append(kept, synergy)
+	}
+	store.synergies = kept
+	return nil
+}
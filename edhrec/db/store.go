@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+)
+
+// SynergyStore is the storage of the EDHREC synergies used by the handlers
+type SynergyStore interface {
+	GetAllEdhrecSynergys(ctx context.Context) ([]*EdhrecSynergy, error)
+	GetEdhrecSynergysByMainCard(ctx context.Context, mainCard string) ([]EdhrecSynergy, error)
+	ReplaceAllOfMainCard(ctx context.Context, mainCard string, edhrecSynergys []EdhrecSynergy) error
+}
+
+var synergyStore SynergyStore
+
+// GetSynergyStore returns the SynergyStore configured at startup with UseSynergyStore
+func GetSynergyStore() SynergyStore {
+	return synergyStore
+}
+
+// UseSynergyStore sets the SynergyStore returned by GetSynergyStore, e.g. an EdhrecSynergyCollection or a MemorySynergyStore
+func UseSynergyStore(store SynergyStore) {
+	synergyStore = store
+}
@@ -11,5 +11,6 @@ require (
 	github.com/gorilla/websocket v1.4.2
 	github.com/maedu/mongo-go-pagination v0.0.5
 	go.mongodb.org/mongo-driver v1.4.3
+	golang.org/x/text v0.3.5
 	google.golang.org/api v0.44.0
 )
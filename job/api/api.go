@@ -0,0 +1,68 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maedu/mtg-cards/job"
+	"github.com/maedu/mtg-cards/job/db"
+	"github.com/maedu/mtg-cards/user/auth"
+	userDB "github.com/maedu/mtg-cards/user/db"
+)
+
+// Setup Setup REST API
+func Setup(r *gin.Engine) {
+	r.GET("/api/jobs/:id", auth.RequireRole(userDB.AdminRole), handleGetJob)
+	r.GET("/api/jobs/:id/events", auth.RequireRole(userDB.AdminRole), handleJobEvents)
+}
+
+func handleGetJob(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	loadedJob, err := db.GetJobStore().GetJobByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+	if loadedJob == nil {
+		c.JSON(http.StatusNotFound, "Job not found")
+		return
+	}
+	c.JSON(http.StatusOK, loadedJob)
+}
+
+// handleJobEvents streams the job as server-sent events until it is done
+func handleJobEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	// Subscribe before loading the job, so no change gets lost in between
+	updates, unsubscribe := job.Subscribe(id)
+	defer unsubscribe()
+
+	loadedJob, err := db.GetJobStore().GetJobByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+	if loadedJob == nil {
+		c.JSON(http.StatusNotFound, "Job not found")
+		return
+	}
+
+	c.SSEvent("job", loadedJob)
+	if loadedJob.IsDone() {
+		return
+	}
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case update := <-updates:
+			c.SSEvent("job", update)
+			return !update.IsDone()
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
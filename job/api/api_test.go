@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maedu/mtg-cards/job/db"
+	"github.com/maedu/mtg-cards/server"
+	"github.com/maedu/mtg-cards/user/auth"
+	userDB "github.com/maedu/mtg-cards/user/db"
+)
+
+func setupMemoryJobs(t *testing.T) *httptest.Server {
+	previous := auth.GetAuthenticator()
+	auth.UseAuthenticator(auth.StaticAuthenticator{"admin": "admin@example.com", "user": "user@example.com"})
+	t.Cleanup(func() { auth.UseAuthenticator(previous) })
+	userDB.UseUserStore(userDB.NewMemoryUserStore(
+		&userDB.User{UserID: "admin@example.com", UserName: "admin", Roles: []userDB.Role{userDB.AdminRole}},
+		&userDB.User{UserID: "user@example.com", UserName: "user"},
+	))
+
+	db.UseJobStore(db.NewMemoryJobStore())
+	db.GetJobStore().Save(context.Background(), &db.Job{
+		ID:       "done",
+		Type:     "update_cards",
+		Key:      "cards",
+		Status:   db.Succeeded,
+		Progress: db.Progress{Current: 10, Total: 10},
+	})
+
+	server := server.Configure()
+	Setup(server)
+	return httptest.NewServer(server)
+}
+
+func get(url string, token string) (*http.Response, error) {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func TestHandleGetJobRequiresAdmin(t *testing.T) {
+	ts := setupMemoryJobs(t)
+	defer ts.Close()
+
+	tests := []struct {
+		path   string
+		token  string
+		status int
+	}{
+		{"/api/jobs/done", "", http.StatusUnauthorized},
+		{"/api/jobs/done", "user", http.StatusForbidden},
+		{"/api/jobs/done/events", "", http.StatusUnauthorized},
+		{"/api/jobs/done/events", "user", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		res, err := get(ts.URL+tt.path, tt.token)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.status {
+			t.Errorf("%s with %q: expected status %d, got %s", tt.path, tt.token, tt.status, res.Status)
+		}
+	}
+}
+
+func TestHandleGetJob(t *testing.T) {
+	ts := setupMemoryJobs(t)
+	defer ts.Close()
+
+	res, err := get(ts.URL+"/api/jobs/done", "admin")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("bad status: %s", res.Status)
+	}
+
+	var job db.Job
+	if err := json.NewDecoder(res.Body).Decode(&job); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if job.Status != db.Succeeded || job.Progress.Current != 10 {
+		t.Errorf("unexpected job %+v", job)
+	}
+
+	res, err = get(ts.URL+"/api/jobs/missing", "admin")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status %d, got %s", http.StatusNotFound, res.Status)
+	}
+}
+
+func TestHandleJobEvents(t *testing.T) {
+	ts := setupMemoryJobs(t)
+	defer ts.Close()
+
+	res, err := get(ts.URL+"/api/jobs/done/events", "admin")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer res.Body.Close()
+	if !strings.HasPrefix(res.Header.Get("Content-Type"), "text/event-stream") {
+		t.Errorf("unexpected content type %s", res.Header.Get("Content-Type"))
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	events := []string{}
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "event:") {
+			events = append(events, scanner.Text())
+		}
+	}
+	if len(events) != 1 || events[0] != "event:job" {
+		t.Errorf("expected one job event for a finished job, got %v", events)
+	}
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/maedu/mtg-cards/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Status of a job
+type Status string
+
+const (
+	Queued    Status = "queued"
+	Running   Status = "running"
+	Succeeded Status = "succeeded"
+	Failed    Status = "failed"
+)
+
+// Progress of a running job, Total is 0 if unknown
+type Progress struct {
+	Current int64  `bson:"current" json:"current"`
+	Total   int64  `bson:"total" json:"total"`
+	Message string `bson:"message" json:"message"`
+}
+
+// Job is a long running operation like a card update, which runs in the background
+type Job struct {
+	ID         string     `bson:"_id" json:"id"`
+	Type       string     `bson:"type" json:"type"`
+	Key        string     `bson:"key" json:"key"`
+	Status     Status     `bson:"status" json:"status"`
+	Progress   Progress   `bson:"progress" json:"progress"`
+	Error      string     `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"createdAt"`
+	StartedAt  *time.Time `bson:"started_at,omitempty" json:"startedAt,omitempty"`
+	FinishedAt *time.Time `bson:"finished_at,omitempty" json:"finishedAt,omitempty"`
+}
+
+// IsDone returns true if the job succeeded or failed
+func (job *Job) IsDone() bool {
+	return job.Status == Succeeded || job.Status == Failed
+}
+
+// JobCollection ...
+type JobCollection struct {
+	*mongo.Collection
+}
+
+// NewJobCollection creates the JobCollection using the shared client
+func NewJobCollection(client *mongo.Client) *JobCollection {
+	return &JobCollection{
+		Collection: client.Database(db.GetDatabaseName()).Collection("jobs"),
+	}
+}
+
+// CreateKeyIndex creates the index used to find the active jobs of a key
+func CreateKeyIndex(ctx context.Context, database *mongo.Database) error {
+	model := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "key", Value: 1},
+			{Key: "status", Value: 1},
+		}, Options: nil,
+	}
+	_, err := database.Collection("jobs").Indexes().CreateOne(ctx, model)
+	return err
+}
+
+// GetJobByID retrieves the job with the id, nil if there is none
+func (collection *JobCollection) GetJobByID(ctx context.Context, id string) (*Job, error) {
+	var job *Job
+	err := collection.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Failed marshalling %v", err)
+		return nil, err
+	}
+	return job, nil
+}
+
+// GetActiveJobs retrieves all queued and running jobs
+func (collection *JobCollection) GetActiveJobs(ctx context.Context) ([]*Job, error) {
+	var jobs []*Job = []*Job{}
+
+	cursor, err := collection.Collection.Find(ctx, bson.M{"status": bson.M{"$in": []Status{Queued, Running}}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &jobs)
+	if err != nil {
+		log.Printf("Failed marshalling %v", err)
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Save creates or replaces the job
+func (collection *JobCollection) Save(ctx context.Context, job *Job) error {
+	_, err := collection.Collection.ReplaceOne(ctx, bson.M{"_id": job.ID}, job, options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Printf("Could not save Job: %v", err)
+	}
+	return err
+}
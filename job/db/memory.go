@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryJobStore is a JobStore keeping all jobs in memory, used by tests and when running without MongoDB
+type MemoryJobStore struct {
+	mutex sync.RWMutex
+	jobs  map[string]*Job
+}
+
+// NewMemoryJobStore creates an empty MemoryJobStore
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{jobs: map[string]*Job{}}
+}
+
+// GetJobByID returns a copy of the job with the id, nil if there is none
+func (store *MemoryJobStore) GetJobByID(ctx context.Context, id string) (*Job, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	job, ok := store.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// GetActiveJobs returns copies of all queued and running jobs
+func (store *MemoryJobStore) GetActiveJobs(ctx context.Context) ([]*Job, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	jobs := []*Job{}
+	for _, job := range store.jobs {
+		if !job.IsDone() {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	return jobs, nil
+}
+
+// Save creates or replaces the job
+func (store *MemoryJobStore) Save(ctx context.Context, job *Job) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	copied := *job
+	store.jobs[job.ID] = &copied
+	return nil
+}
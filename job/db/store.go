@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+)
+
+// JobStore is the storage of the background jobs
+type JobStore interface {
+	GetJobByID(ctx context.Context, id string) (*Job, error)
+	GetActiveJobs(ctx context.Context) ([]*Job, error)
+	Save(ctx context.Context, job *Job) error
+}
+
+var jobStore JobStore
+
+// GetJobStore returns the JobStore configured at startup with UseJobStore
+func GetJobStore() JobStore {
+	return jobStore
+}
+
+// UseJobStore sets the JobStore returned by GetJobStore, e.g. a JobCollection or a MemoryJobStore
+func UseJobStore(store JobStore) {
+	jobStore = store
+}
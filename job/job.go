@@ -0,0 +1,283 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/maedu/mtg-cards/job/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Run is the work of a job, it reports its progress with report while running
+type Run func(ctx context.Context, report func(progress db.Progress)) error
+
+type pendingJob struct {
+	job *db.Job
+	run Run
+	// saved is set once the queued job is persisted, it is only started afterwards
+	saved bool
+}
+
+// jobsOfKey are the jobs which must not run at the same time, e.g. all jobs replacing the cards
+type jobsOfKey struct {
+	running *db.Job
+	queue   []*pendingJob
+}
+
+var (
+	mutex       sync.Mutex
+	keys        = map[string]*jobsOfKey{}
+	subscribers = map[string][]chan db.Job{}
+)
+
+// Enqueue adds a job, which runs in the background once no other job with the same key is running.
+// If a job of the same type and key is still queued, no new job is added and the queued one is returned.
+func Enqueue(jobType string, key string, run Run) (*db.Job, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	jobs := keys[key]
+	if jobs == nil {
+		jobs = &jobsOfKey{}
+		keys[key] = jobs
+	}
+	for _, pending := range jobs.queue {
+		if pending.job.Type == jobType {
+			copied := *pending.job
+			return &copied, nil
+		}
+	}
+
+	job := &db.Job{
+		ID:        primitive.NewObjectID().Hex(),
+		Type:      jobType,
+		Key:       key,
+		Status:    db.Queued,
+		CreatedAt: time.Now(),
+	}
+	// The job is queued right away, so it is found by the next Enqueue, but saved without holding the mutex
+	pending := &pendingJob{job: job, run: run}
+	jobs.queue = append(jobs.queue, pending)
+	snapshot := *job
+	mutex.Unlock()
+
+	err := db.GetJobStore().Save(context.Background(), &snapshot)
+
+	mutex.Lock()
+	if err != nil {
+		remaining := []*pendingJob{}
+		for _, other := range jobs.queue {
+			if other != pending {
+				remaining = append(remaining, other)
+			}
+		}
+		jobs.queue = remaining
+	} else {
+		pending.saved = true
+	}
+	if jobs.running == nil && len(jobs.queue) > 0 && jobs.queue[0].saved {
+		startNext(jobs)
+	} else if jobs.running == nil && len(jobs.queue) == 0 {
+		delete(keys, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// startNext starts the first queued job, the mutex must be locked
+func startNext(jobs *jobsOfKey) {
+	next := jobs.queue[0]
+	jobs.queue = jobs.queue[1:]
+	jobs.running = next.job
+	go execute(next.job, next.run)
+}
+
+func execute(job *db.Job, run Run) {
+	ctx := context.Background()
+
+	// saving keeps the updates of the job in order, while the mutex is only held to change the job
+	var saving sync.Mutex
+	update := func(change func(job *db.Job)) {
+		saving.Lock()
+		defer saving.Unlock()
+
+		mutex.Lock()
+		change(job)
+		snapshot := *job
+		mutex.Unlock()
+
+		save(ctx, snapshot)
+	}
+
+	update(func(job *db.Job) {
+		startedAt := time.Now()
+		job.Status = db.Running
+		job.StartedAt = &startedAt
+	})
+
+	err := runSafely(ctx, run, func(progress db.Progress) {
+		update(func(job *db.Job) {
+			job.Progress = progress
+		})
+	})
+
+	update(func(job *db.Job) {
+		finishedAt := time.Now()
+		job.FinishedAt = &finishedAt
+		if err != nil {
+			log.Printf("Job %s (%s) failed: %v", job.ID, job.Type, err)
+			job.Status = db.Failed
+			job.Error = err.Error()
+		} else {
+			log.Printf("Job %s (%s) succeeded", job.ID, job.Type)
+			job.Status = db.Succeeded
+		}
+	})
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	jobs := keys[job.Key]
+	jobs.running = nil
+	if len(jobs.queue) > 0 {
+		// A job which is not saved yet is started by Enqueue once it is
+		if jobs.queue[0].saved {
+			startNext(jobs)
+		}
+	} else {
+		delete(keys, job.Key)
+	}
+}
+
+func runSafely(ctx context.Context, run Run, report func(progress db.Progress)) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return run(ctx, report)
+}
+
+// save persists the snapshot of the job and publishes it to its subscribers, the mutex must not be locked
+func save(ctx context.Context, job db.Job) {
+	err := db.GetJobStore().Save(ctx, &job)
+	if err != nil {
+		log.Printf("Saving job %s failed: %v", job.ID, err)
+	}
+
+	mutex.Lock()
+	jobSubscribers := append([]chan db.Job{}, subscribers[job.ID]...)
+	mutex.Unlock()
+
+	for _, subscriber := range jobSubscribers {
+		select {
+		case subscriber <- job:
+		default:
+			// The subscriber is too slow, drop its oldest update so it gets the latest one
+			select {
+			case <-subscriber:
+			default:
+			}
+			subscriber <- job
+		}
+	}
+}
+
+// Subscribe returns a channel receiving every change of the job, unsubscribe has to be called when done
+func Subscribe(id string) (updates <-chan db.Job, unsubscribe func()) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	subscriber := make(chan db.Job, 16)
+	subscribers[id] = append(subscribers[id], subscriber)
+	return subscriber, func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		remaining := []chan db.Job{}
+		for _, other := range subscribers[id] {
+			if other != subscriber {
+				remaining = append(remaining, other)
+			}
+		}
+		if len(remaining) == 0 {
+			delete(subscribers, id)
+		} else {
+			subscribers[id] = remaining
+		}
+	}
+}
+
+// isTracked returns true if the job is queued or running in this process, the mutex must be locked
+func isTracked(job *db.Job) bool {
+	jobs, ok := keys[job.Key]
+	if !ok {
+		return false
+	}
+	if jobs.running != nil && jobs.running.ID == job.ID {
+		return true
+	}
+	for _, pending := range jobs.queue {
+		if pending.job.ID == job.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// FailInterruptedJobs marks the jobs as failed which were queued or running when the server stopped
+func FailInterruptedJobs(ctx context.Context) error {
+	jobs, err := db.GetJobStore().GetActiveJobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	interrupted := []*db.Job{}
+	for _, job := range jobs {
+		if !isTracked(job) {
+			interrupted = append(interrupted, job)
+		}
+	}
+	mutex.Unlock()
+
+	for _, job := range interrupted {
+		finishedAt := time.Now()
+		job.Status = db.Failed
+		job.Error = "interrupted by a restart of the server"
+		job.FinishedAt = &finishedAt
+		err = db.GetJobStore().Save(ctx, job)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Wait blocks until the job is done and returns it, or until ctx is done
+func Wait(ctx context.Context, id string) (*db.Job, error) {
+	updates, unsubscribe := Subscribe(id)
+	defer unsubscribe()
+
+	job, err := db.GetJobStore().GetJobByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, fmt.Errorf("job %s not found", id)
+	}
+
+	for !job.IsDone() {
+		select {
+		case update := <-updates:
+			job = &update
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return job, nil
+}
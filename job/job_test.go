@@ -0,0 +1,174 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maedu/mtg-cards/job/db"
+)
+
+func waitUntilDone(t *testing.T, id string) *db.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := db.GetJobStore().GetJobByID(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if job != nil && job.IsDone() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s is not done", id)
+	return nil
+}
+
+func TestEnqueue(t *testing.T) {
+	db.UseJobStore(db.NewMemoryJobStore())
+
+	enqueued, err := Enqueue("test", "test", func(ctx context.Context, report func(progress db.Progress)) error {
+		report(db.Progress{Current: 1, Total: 2, Message: "half way"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	done := waitUntilDone(t, enqueued.ID)
+	if done.Status != db.Succeeded {
+		t.Errorf("expected status %s, got %s", db.Succeeded, done.Status)
+	}
+	if done.Progress.Current != 1 || done.Progress.Total != 2 {
+		t.Errorf("unexpected progress %+v", done.Progress)
+	}
+	if done.StartedAt == nil || done.FinishedAt == nil {
+		t.Errorf("expected start and finish time, got %+v", done)
+	}
+}
+
+func TestEnqueueFailure(t *testing.T) {
+	db.UseJobStore(db.NewMemoryJobStore())
+
+	failing, err := Enqueue("test", "test", func(ctx context.Context, report func(progress db.Progress)) error {
+		return errors.New("broken")
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	panicking, err := Enqueue("other", "test", func(ctx context.Context, report func(progress db.Progress)) error {
+		panic("very broken")
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, id := range []string{failing.ID, panicking.ID} {
+		done := waitUntilDone(t, id)
+		if done.Status != db.Failed || done.Error == "" {
+			t.Errorf("expected failed job with error, got %+v", done)
+		}
+	}
+}
+
+func TestEnqueueOneAtATime(t *testing.T) {
+	db.UseJobStore(db.NewMemoryJobStore())
+
+	release := make(chan bool)
+	running, err := Enqueue("test", "test", func(ctx context.Context, report func(progress db.Progress)) error {
+		<-release
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	runs := 0
+	queued, err := Enqueue("test", "test", func(ctx context.Context, report func(progress db.Progress)) error {
+		runs++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	coalesced, err := Enqueue("test", "test", func(ctx context.Context, report func(progress db.Progress)) error {
+		runs++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if queued.ID == running.ID || coalesced.ID != queued.ID {
+		t.Errorf("expected a new queued job which is reused, got %s, %s and %s", running.ID, queued.ID, coalesced.ID)
+	}
+
+	queuedJob, _ := db.GetJobStore().GetJobByID(context.Background(), queued.ID)
+	if queuedJob.Status != db.Queued {
+		t.Errorf("expected status %s while the other job runs, got %s", db.Queued, queuedJob.Status)
+	}
+
+	close(release)
+	waitUntilDone(t, running.ID)
+	waitUntilDone(t, queued.ID)
+	if runs != 1 {
+		t.Errorf("expected the queued job to run once, got %d", runs)
+	}
+}
+
+func TestFailInterruptedJobs(t *testing.T) {
+	db.UseJobStore(db.NewMemoryJobStore())
+	db.GetJobStore().Save(context.Background(), &db.Job{ID: "interrupted", Key: "test", Status: db.Running})
+
+	err := FailInterruptedJobs(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	interrupted, _ := db.GetJobStore().GetJobByID(context.Background(), "interrupted")
+	if interrupted.Status != db.Failed {
+		t.Errorf("expected status %s, got %s", db.Failed, interrupted.Status)
+	}
+}
+
+// blockingJobStore blocks saving the jobs of the key until released
+type blockingJobStore struct {
+	*db.MemoryJobStore
+	key     string
+	release chan bool
+}
+
+func (store blockingJobStore) Save(ctx context.Context, job *db.Job) error {
+	if job.Key == store.key {
+		<-store.release
+	}
+	return store.MemoryJobStore.Save(ctx, job)
+}
+
+func TestSavingDoesNotBlockOtherJobs(t *testing.T) {
+	store := blockingJobStore{MemoryJobStore: db.NewMemoryJobStore(), key: "slow", release: make(chan bool)}
+	db.UseJobStore(store)
+
+	slow := make(chan *db.Job)
+	go func() {
+		enqueued, _ := Enqueue("test", "slow", func(ctx context.Context, report func(progress db.Progress)) error {
+			return nil
+		})
+		slow <- enqueued
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	enqueued, err := Enqueue("test", "fast", func(ctx context.Context, report func(progress db.Progress)) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if done := waitUntilDone(t, enqueued.ID); done.Status != db.Succeeded {
+		t.Errorf("expected status %s, got %s", db.Succeeded, done.Status)
+	}
+
+	close(store.release)
+	waitUntilDone(t, (<-slow).ID)
+}
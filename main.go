@@ -1,20 +1,89 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"os"
+	"time"
 
 	env "bitbucket.org/spinnerweb/accounting_common/env"
 	cardApi "github.com/maedu/mtg-cards/card/api"
+	cardDB "github.com/maedu/mtg-cards/card/db"
+	"github.com/maedu/mtg-cards/db"
 	deckApi "github.com/maedu/mtg-cards/deck/api"
+	deckDB "github.com/maedu/mtg-cards/deck/db"
 	"github.com/maedu/mtg-cards/draft/sealed"
 	edhrecApi "github.com/maedu/mtg-cards/edhrec/api"
+	edhrecDB "github.com/maedu/mtg-cards/edhrec/db"
+	"github.com/maedu/mtg-cards/job"
+	jobApi "github.com/maedu/mtg-cards/job/api"
+	jobDB "github.com/maedu/mtg-cards/job/db"
+	"github.com/maedu/mtg-cards/migration"
+	"github.com/maedu/mtg-cards/scheduler"
+	schedulerApi "github.com/maedu/mtg-cards/scheduler/api"
+	schedulerDB "github.com/maedu/mtg-cards/scheduler/db"
 	"github.com/maedu/mtg-cards/server"
 	setApi "github.com/maedu/mtg-cards/set/api"
+	setDB "github.com/maedu/mtg-cards/set/db"
 	userApi "github.com/maedu/mtg-cards/user/api"
+	"github.com/maedu/mtg-cards/user/auth"
+	userDB "github.com/maedu/mtg-cards/user/db"
 	userUpload "github.com/maedu/mtg-cards/user/upload"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// migrationTimeout is the time migrations like backfills may take
+const migrationTimeout = 30 * time.Minute
+
 func main() {
+	// The sync needs the scryfall collections in MongoDB
+	defaultSchedule := scheduler.DefaultSchedule
+	if env.GetEnv("STORAGE", "mongodb") == "memory" {
+		useMemoryStores()
+		defaultSchedule = "off"
+	} else {
+		client, err := db.Connect()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Disconnect()
+
+		// "mtg-cards migrate" only applies the migrations, without starting the server
+		migrateOnly := len(os.Args) > 1 && os.Args[1] == "migrate"
+		waiting := false
+		if migrateOnly || env.GetEnv("MIGRATE_ON_STARTUP", "true") == "true" {
+			waiting, err = runMigrations(context.Background(), client)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		if migrateOnly {
+			return
+		}
+		useMongoStores(client)
+		if waiting {
+			runWaitingMigrationsAfterTransformation(client)
+		}
+
+		err = job.FailInterruptedJobs(context.Background())
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	authenticator, err := auth.NewAuthenticatorFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	auth.UseAuthenticator(authenticator)
+
+	schedules, err := scheduler.ParseSchedules(env.GetEnv("SYNC_SCHEDULE", defaultSchedule))
+	if err != nil {
+		log.Fatal(err)
+	}
+	scheduler.Start(context.Background(), schedules)
+
 	server := server.Configure()
 	cardApi.Setup(server)
 	deckApi.Setup(server)
@@ -23,5 +92,75 @@ func main() {
 	edhrecApi.Setup(server)
 	userApi.Setup(server)
 	userUpload.Setup(server)
+	jobApi.Setup(server)
+	schedulerApi.Setup(server)
 	server.Run(fmt.Sprintf("0.0.0.0:%s", env.GetEnv("SERVER_PORT", "4004"))) // listen and serve on 0.0.0.0:8080
 }
+
+// useMemoryStores keeps all data in memory instead of MongoDB, it is lost on restart
+func useMemoryStores() {
+	log.Println("Using in-memory stores")
+	userCards := userDB.NewMemoryUserCardStore()
+	cards := cardDB.NewMemoryCardStore()
+	cards.CollectedCardNames = func(ctx context.Context, userID string) (map[string]bool, error) {
+		collected, err := userCards.GetUserCardsByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		names := map[string]bool{}
+		for _, userCard := range collected {
+			names[userCard.Name] = true
+		}
+		return names, nil
+	}
+
+	cardDB.UseCardStore(cards)
+	cardDB.UsePrintingStore(cardDB.NewMemoryPrintingStore())
+	cardDB.UseTokenStore(cardDB.NewMemoryTokenStore())
+	deckDB.UseDeckStore(deckDB.NewMemoryDeckStore())
+	edhrecDB.UseSynergyStore(edhrecDB.NewMemorySynergyStore())
+	setDB.UseSetStore(setDB.NewMemorySetStore())
+	userDB.UseUserStore(userDB.NewMemoryUserStore())
+	userDB.UseUserCardStore(userCards)
+	userDB.UseAPIKeyStore(userDB.NewMemoryAPIKeyStore())
+	jobDB.UseJobStore(jobDB.NewMemoryJobStore())
+	schedulerDB.UseSyncRunStore(schedulerDB.NewMemorySyncRunStore())
+}
+
+// useMongoStores uses the collections of the MongoDB client, which is shared by all of them
+func useMongoStores(client *mongo.Client) {
+	cardDB.UseCardStore(cardDB.NewCardCollection(client))
+	cardDB.UsePrintingStore(cardDB.NewPrintingCollection(client))
+	cardDB.UseTokenStore(cardDB.NewTokenCollection(client))
+	deckDB.UseDeckStore(deckDB.NewDeckCollection(client))
+	edhrecDB.UseSynergyStore(edhrecDB.NewEdhrecSynergyCollection(client))
+	setDB.UseSetStore(setDB.NewSetCollection(client))
+	userDB.UseUserStore(userDB.NewUserCollection(client))
+	userDB.UseUserCardStore(userDB.NewUserCardCollection(client))
+	userDB.UseAPIKeyStore(userDB.NewAPIKeyCollection(client))
+	jobDB.UseJobStore(jobDB.NewJobCollection(client))
+	schedulerDB.UseSyncRunStore(schedulerDB.NewSyncRunCollection(client))
+}
+
+// runMigrations applies the pending migrations, which creates the indexes and updates existing documents.
+// It returns true if migrations are still waiting, e.g. for transformed cards.
+func runMigrations(ctx context.Context, client *mongo.Client) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, migrationTimeout)
+	defer cancel()
+	return migration.Run(ctx, client)
+}
+
+// runWaitingMigrationsAfterTransformation applies the waiting migrations after the cards are transformed, until none are waiting anymore.
+// E.g. the decks and user cards saved before the cards had oracle IDs get them after the first transformation keeping them.
+func runWaitingMigrationsAfterTransformation(client *mongo.Client) {
+	// The transformations run one at a time, so waiting needs no lock
+	waiting := true
+	cardApi.OnCardsTransformed(func(ctx context.Context) error {
+		if !waiting {
+			return nil
+		}
+		var err error
+		waiting, err = runMigrations(ctx, client)
+		return err
+	})
+}
@@ -0,0 +1,35 @@
+package migration
+
+import (
+	"context"
+
+	cardDB "github.com/maedu/mtg-cards/card/db"
+	"github.com/maedu/mtg-cards/db"
+	deckDB "github.com/maedu/mtg-cards/deck/db"
+	edhrecDB "github.com/maedu/mtg-cards/edhrec/db"
+	jobDB "github.com/maedu/mtg-cards/job/db"
+	schedulerDB "github.com/maedu/mtg-cards/scheduler/db"
+	userDB "github.com/maedu/mtg-cards/user/db"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migrations contains all migrations of the database, new ones are appended with the next version
+var Migrations = []db.Migration{
+	{Version: 1, Description: "create text index on cards", Up: cardDB.CreateTextIndex},
+	{Version: 2, Description: "create user_id index on decks", Up: deckDB.CreateUserIDIndex},
+	{Version: 3, Description: "create user_id index on users", Up: userDB.CreateUserIDIndex},
+	{Version: 4, Description: "create user_id index on user_cards", Up: userDB.CreateUserCardUserIDIndex},
+	{Version: 5, Description: "create main_card index on edhrec_synergies", Up: edhrecDB.CreateMainCardIndex},
+	{Version: 6, Description: "create key and status index on jobs", Up: jobDB.CreateKeyIndex},
+	{Version: 7, Description: "create started_at index on sync_runs", Up: schedulerDB.CreateStartedAtIndex},
+	{Version: 8, Description: "create hash and user_id indexes on api_keys", Up: userDB.CreateAPIKeyIndexes},
+	{Version: 9, Description: "create oracle_id index on cards", Up: cardDB.CreateOracleIDIndex},
+	{Version: 10, Description: "create oracle_id and set indexes on printings", Up: cardDB.CreatePrintingIndexes},
+	{Version: 11, Description: "backfill oracle IDs of decks and user cards", Up: BackfillOracleIDs},
+	{Version: 12, Description: "create created_by index on tokens", Up: cardDB.CreateTokenIndexes},
+}
+
+// Run applies all pending migrations to the database of the client, it returns true if migrations are still waiting
+func Run(ctx context.Context, client *mongo.Client) (bool, error) {
+	return db.Migrate(ctx, client.Database(db.GetDatabaseName()), Migrations)
+}
@@ -0,0 +1,38 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	cardDB "github.com/maedu/mtg-cards/card/db"
+	"github.com/maedu/mtg-cards/db"
+	deckDB "github.com/maedu/mtg-cards/deck/db"
+	userDB "github.com/maedu/mtg-cards/user/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BackfillOracleIDs stores the oracle IDs of the cards with the decks and the user cards, which only had the card names.
+// Cards transformed before the oracle IDs were kept have none, the backfill then stays pending until the next transformation.
+func BackfillOracleIDs(ctx context.Context, database *mongo.Database) error {
+	collection := database.Collection("cards")
+	withOracleID, err := collection.CountDocuments(ctx, bson.M{"oracle_id": bson.M{"$nin": bson.A{nil, ""}}}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if withOracleID == 0 {
+		return fmt.Errorf("the cards have no oracle IDs yet: %w", db.ErrMigrationNotReady)
+	}
+
+	cards := &cardDB.CardCollection{Collection: collection}
+	oracleIDs := func(ctx context.Context, names []string) (map[string]string, error) {
+		return cardDB.OracleIDsByName(ctx, cards, names)
+	}
+
+	err = deckDB.BackfillOracleIDs(ctx, database, oracleIDs)
+	if err != nil {
+		return err
+	}
+	return userDB.BackfillUserCardOracleIDs(ctx, database, oracleIDs)
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maedu/mtg-cards/scheduler"
+	"github.com/maedu/mtg-cards/scheduler/db"
+)
+
+// Setup Setup REST API
+func Setup(r *gin.Engine) {
+	r.GET("/api/sync/status", handleGetStatus)
+	r.GET("/api/sync/runs", handleGetRuns)
+}
+
+func handleGetStatus(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	status, err := scheduler.GetStatus(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+func handleGetRuns(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, "limit must be a positive number")
+		return
+	}
+
+	syncRuns, err := db.GetSyncRunStore().GetLatestSyncRuns(ctx, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, syncRuns)
+}
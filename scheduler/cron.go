@@ -0,0 +1,179 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression with the fields minute, hour, day of month, month and day of week
+type Schedule struct {
+	expression string
+	minutes    map[int]bool
+	hours      map[int]bool
+	days       map[int]bool
+	months     map[int]bool
+	weekdays   map[int]bool
+	// anyDay and anyWeekday are set if the field is "*", a day then matches if both or either of the restricted ones match
+	anyDay     bool
+	anyWeekday bool
+}
+
+var descriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// ParseSchedule parses a cron expression like "30 3 * * *" (every day at 03:30) or "0 */6 * * 1-5".
+// The fields support "*", lists, ranges and steps, the day of week 0 and 7 are Sunday.
+func ParseSchedule(expression string) (*Schedule, error) {
+	expression = strings.TrimSpace(expression)
+	fieldsExpression := expression
+	if descriptor, ok := descriptors[expression]; ok {
+		fieldsExpression = descriptor
+	}
+
+	fields := strings.Fields(fieldsExpression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q must have 5 fields: minute hour day-of-month month day-of-week", expression)
+	}
+
+	schedule := &Schedule{expression: expression}
+	var err error
+	if schedule.minutes, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("schedule %q has an invalid minute: %w", expression, err)
+	}
+	if schedule.hours, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("schedule %q has an invalid hour: %w", expression, err)
+	}
+	if schedule.days, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("schedule %q has an invalid day of month: %w", expression, err)
+	}
+	if schedule.months, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("schedule %q has an invalid month: %w", expression, err)
+	}
+	if schedule.weekdays, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("schedule %q has an invalid day of week: %w", expression, err)
+	}
+	if schedule.weekdays[7] {
+		schedule.weekdays[0] = true
+	}
+	schedule.anyDay = fields[2] == "*"
+	schedule.anyWeekday = fields[4] == "*"
+	if !schedule.anyDay && schedule.anyWeekday && !schedule.hasDayInMonths() {
+		return nil, fmt.Errorf("schedule %q never runs, the days of month don't occur in its months", expression)
+	}
+	return schedule, nil
+}
+
+// daysInMonth are the most days of the months, February has 29 in leap years
+var daysInMonth = map[int]int{1: 31, 2: 29, 3: 31, 4: 30, 5: 31, 6: 30, 7: 31, 8: 31, 9: 30, 10: 31, 11: 30, 12: 31}
+
+// hasDayInMonths returns whether one of the days of month occurs in one of the months
+func (schedule *Schedule) hasDayInMonths() bool {
+	for month := range schedule.months {
+		for day := range schedule.days {
+			if day <= daysInMonth[month] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseField(field string, min int, max int) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if index := strings.Index(part, "/"); index >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[index+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:index]
+		}
+
+		from, to := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+			if to, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			value, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			from = value
+			if step == 1 {
+				to = value
+			}
+		}
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("%q is not within %d-%d", part, min, max)
+		}
+
+		for value := from; value <= to; value += step {
+			values[value] = true
+		}
+	}
+	return values, nil
+}
+
+// Next returns the first time after t matching the schedule, in the location of t, zero if there is none
+func (schedule *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Every schedule matches at least once within 5 years, e.g. on the 29th of February
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !schedule.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !schedule.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !schedule.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !schedule.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (schedule *Schedule) matchesDay(t time.Time) bool {
+	day := schedule.days[t.Day()]
+	weekday := schedule.weekdays[int(t.Weekday())]
+	switch {
+	case schedule.anyDay && schedule.anyWeekday:
+		return true
+	case schedule.anyDay:
+		return weekday
+	case schedule.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+func (schedule *Schedule) String() string {
+	return schedule.expression
+}
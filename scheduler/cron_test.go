@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// Wednesday
+	now := time.Date(2021, 3, 10, 14, 25, 30, 0, time.UTC)
+
+	tests := []struct {
+		expression string
+		want       time.Time
+	}{
+		{"0 3 * * *", time.Date(2021, 3, 11, 3, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2021, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, 3, 10, 14, 30, 0, 0, time.UTC)},
+		{"30 14 * * *", time.Date(2021, 3, 10, 14, 30, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2021, 3, 10, 15, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either the day of month or the day of week has to match
+		{"0 0 20 * 5", time.Date(2021, 3, 12, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			schedule, err := ParseSchedule(test.expression)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := schedule.Next(now); !got.Equal(test.want) {
+				t.Errorf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "0 0 30 2 *", "0 0 31 4,6 *"} {
+		t.Run(expression, func(t *testing.T) {
+			_, err := ParseSchedule(expression)
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestParseSchedules(t *testing.T) {
+	schedules, err := ParseSchedules("0 3 * * *; 0 15 * * *")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(schedules) != 2 {
+		t.Errorf("expected 2 schedules, got %d", len(schedules))
+	}
+
+	schedules, err = ParseSchedules("off")
+	if err != nil || len(schedules) != 0 {
+		t.Errorf("expected no schedules, got %v, %v", schedules, err)
+	}
+}
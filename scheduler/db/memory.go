@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemorySyncRunStore is a SyncRunStore keeping all sync runs in memory, used by tests and when running without MongoDB
+type MemorySyncRunStore struct {
+	mutex    sync.RWMutex
+	syncRuns map[string]*SyncRun
+}
+
+// NewMemorySyncRunStore creates an empty MemorySyncRunStore
+func NewMemorySyncRunStore() *MemorySyncRunStore {
+	return &MemorySyncRunStore{syncRuns: map[string]*SyncRun{}}
+}
+
+// GetLatestSyncRuns returns copies of the latest sync runs, the newest first
+func (store *MemorySyncRunStore) GetLatestSyncRuns(ctx context.Context, limit int64) ([]*SyncRun, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	syncRuns := []*SyncRun{}
+	for _, syncRun := range store.syncRuns {
+		syncRuns = append(syncRuns, copySyncRun(syncRun))
+	}
+	sort.Slice(syncRuns, func(i, j int) bool { return syncRuns[i].StartedAt.After(syncRuns[j].StartedAt) })
+	if int64(len(syncRuns)) > limit {
+		syncRuns = syncRuns[:limit]
+	}
+	return syncRuns, nil
+}
+
+// Save creates or replaces the sync run
+func (store *MemorySyncRunStore) Save(ctx context.Context, syncRun *SyncRun) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.syncRuns[syncRun.ID] = copySyncRun(syncRun)
+	return nil
+}
+
+func copySyncRun(syncRun *SyncRun) *SyncRun {
+	copied := *syncRun
+	copied.Jobs = append([]SyncRunJob{}, syncRun.Jobs...)
+	return &copied
+}
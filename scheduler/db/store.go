@@ -0,0 +1,23 @@
+package db
+
+import (
+	"context"
+)
+
+// SyncRunStore is the storage of the run history of the scheduled sync
+type SyncRunStore interface {
+	GetLatestSyncRuns(ctx context.Context, limit int64) ([]*SyncRun, error)
+	Save(ctx context.Context, syncRun *SyncRun) error
+}
+
+var syncRunStore SyncRunStore
+
+// GetSyncRunStore returns the SyncRunStore configured at startup with UseSyncRunStore
+func GetSyncRunStore() SyncRunStore {
+	return syncRunStore
+}
+
+// UseSyncRunStore sets the SyncRunStore returned by GetSyncRunStore, e.g. a SyncRunCollection or a MemorySyncRunStore
+func UseSyncRunStore(store SyncRunStore) {
+	syncRunStore = store
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/maedu/mtg-cards/db"
+	jobDB "github.com/maedu/mtg-cards/job/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SyncRunJob is a job started by a sync run
+type SyncRunJob struct {
+	ID     string       `bson:"id" json:"id"`
+	Type   string       `bson:"type" json:"type"`
+	Status jobDB.Status `bson:"status" json:"status"`
+	Error  string       `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// SyncRun is a run of the sync of the scryfall sets and cards, started by the scheduler
+type SyncRun struct {
+	ID         string       `bson:"_id" json:"id"`
+	Schedule   string       `bson:"schedule" json:"schedule"`
+	Status     jobDB.Status `bson:"status" json:"status"`
+	Error      string       `bson:"error,omitempty" json:"error,omitempty"`
+	Jobs       []SyncRunJob `bson:"jobs" json:"jobs"`
+	StartedAt  time.Time    `bson:"started_at" json:"startedAt"`
+	FinishedAt *time.Time   `bson:"finished_at,omitempty" json:"finishedAt,omitempty"`
+}
+
+// SyncRunCollection ...
+type SyncRunCollection struct {
+	*mongo.Collection
+}
+
+// NewSyncRunCollection creates the SyncRunCollection using the shared client
+func NewSyncRunCollection(client *mongo.Client) *SyncRunCollection {
+	return &SyncRunCollection{
+		Collection: client.Database(db.GetDatabaseName()).Collection("sync_runs"),
+	}
+}
+
+// CreateStartedAtIndex creates the index used to find the latest sync runs
+func CreateStartedAtIndex(ctx context.Context, database *mongo.Database) error {
+	model := mongo.IndexModel{
+		Keys: bson.M{
+			"started_at": -1,
+		}, Options: nil,
+	}
+	_, err := database.Collection("sync_runs").Indexes().CreateOne(ctx, model)
+	return err
+}
+
+// GetLatestSyncRuns retrieves the latest sync runs, the newest first
+func (collection *SyncRunCollection) GetLatestSyncRuns(ctx context.Context, limit int64) ([]*SyncRun, error) {
+	var syncRuns []*SyncRun = []*SyncRun{}
+
+	opts := options.Find().SetSort(bson.M{"started_at": -1}).SetLimit(limit)
+	cursor, err := collection.Collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &syncRuns)
+	if err != nil {
+		log.Printf("Failed marshalling %v", err)
+		return nil, err
+	}
+	return syncRuns, nil
+}
+
+// Save creates or replaces the sync run
+func (collection *SyncRunCollection) Save(ctx context.Context, syncRun *SyncRun) error {
+	_, err := collection.Collection.ReplaceOne(ctx, bson.M{"_id": syncRun.ID}, syncRun, options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Printf("Could not save SyncRun: %v", err)
+	}
+	return err
+}
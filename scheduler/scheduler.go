@@ -0,0 +1,218 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	cardAPI "github.com/maedu/mtg-cards/card/api"
+	"github.com/maedu/mtg-cards/job"
+	jobDB "github.com/maedu/mtg-cards/job/db"
+	"github.com/maedu/mtg-cards/scheduler/db"
+	setAPI "github.com/maedu/mtg-cards/set/api"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultSchedule syncs every night at 03:00
+const DefaultSchedule = "0 3 * * *"
+
+// Step of the sync, it enqueues a job and the next step starts once the job is done
+type Step struct {
+	Type    string
+	Enqueue func() (*jobDB.Job, error)
+}
+
+// steps of the sync, the sets and their transformation first, then the cards with their prices and transformation
+var steps = []Step{
+	{Type: setAPI.UpdateSetsJob, Enqueue: setAPI.EnqueueUpdateSets},
+	{Type: cardAPI.UpdateCardsJob, Enqueue: func() (*jobDB.Job, error) { return cardAPI.EnqueueUpdateCards(false) }},
+}
+
+// Status is the state of the scheduler
+type Status struct {
+	Schedules []string    `json:"schedules"`
+	NextRunAt *time.Time  `json:"nextRunAt,omitempty"`
+	Running   bool        `json:"running"`
+	LastRun   *db.SyncRun `json:"lastRun,omitempty"`
+}
+
+var (
+	mutex     sync.Mutex
+	schedules []*Schedule
+	nextRunAt *time.Time
+	running   bool
+)
+
+// ParseSchedules parses the cron expressions separated by ";", "off" or an empty text disables the sync
+func ParseSchedules(expressions string) ([]*Schedule, error) {
+	parsed := []*Schedule{}
+	if strings.TrimSpace(expressions) == "off" {
+		return parsed, nil
+	}
+	for _, expression := range strings.Split(expressions, ";") {
+		if strings.TrimSpace(expression) == "" {
+			continue
+		}
+		schedule, err := ParseSchedule(expression)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, schedule)
+	}
+	return parsed, nil
+}
+
+// Start runs the sync in the background at the times of the schedules, until ctx is done
+func Start(ctx context.Context, startSchedules []*Schedule) {
+	mutex.Lock()
+	schedules = startSchedules
+	mutex.Unlock()
+	if len(startSchedules) == 0 {
+		log.Println("No sync scheduled")
+		return
+	}
+
+	go func() {
+		for {
+			schedule, next := nextRun(time.Now())
+			if next.IsZero() {
+				log.Println("No sync scheduled anymore, the schedules never match")
+				mutex.Lock()
+				nextRunAt = nil
+				mutex.Unlock()
+				return
+			}
+			log.Printf("Next sync at %v (%s)", next, schedule)
+			mutex.Lock()
+			nextRunAt = &next
+			mutex.Unlock()
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-timer.C:
+				_, err := Run(ctx, schedule.String())
+				if err != nil {
+					log.Printf("Sync failed: %v", err)
+				}
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// nextRun returns the schedule running next after now, the time is zero if none of the schedules matches anymore
+func nextRun(now time.Time) (*Schedule, time.Time) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var first *Schedule
+	var firstTime time.Time
+	for _, schedule := range schedules {
+		next := schedule.Next(now)
+		if next.IsZero() {
+			continue
+		}
+		if first == nil || next.Before(firstTime) {
+			first = schedule
+			firstTime = next
+		}
+	}
+	return first, firstTime
+}
+
+// Run runs all steps of the sync in order and records the run, it stops at the first failing step.
+// If a sync is already running, it returns nil without running another one.
+func Run(ctx context.Context, schedule string) (*db.SyncRun, error) {
+	mutex.Lock()
+	if running {
+		mutex.Unlock()
+		log.Println("Sync is already running")
+		return nil, nil
+	}
+	running = true
+	mutex.Unlock()
+	defer func() {
+		mutex.Lock()
+		running = false
+		mutex.Unlock()
+	}()
+
+	store := db.GetSyncRunStore()
+	syncRun := &db.SyncRun{
+		ID:        primitive.NewObjectID().Hex(),
+		Schedule:  schedule,
+		Status:    jobDB.Running,
+		Jobs:      []db.SyncRunJob{},
+		StartedAt: time.Now(),
+	}
+	err := store.Save(ctx, syncRun)
+	if err != nil {
+		return nil, err
+	}
+
+	err = runSteps(ctx, syncRun)
+
+	finishedAt := time.Now()
+	syncRun.FinishedAt = &finishedAt
+	syncRun.Status = jobDB.Succeeded
+	if err != nil {
+		syncRun.Status = jobDB.Failed
+		syncRun.Error = err.Error()
+	}
+	saveErr := store.Save(ctx, syncRun)
+	if err != nil {
+		return syncRun, err
+	}
+	return syncRun, saveErr
+}
+
+func runSteps(ctx context.Context, syncRun *db.SyncRun) error {
+	for _, step := range steps {
+		enqueued, err := step.Enqueue()
+		if err != nil {
+			return fmt.Errorf("enqueuing %s failed: %w", step.Type, err)
+		}
+		syncRun.Jobs = append(syncRun.Jobs, db.SyncRunJob{ID: enqueued.ID, Type: step.Type, Status: enqueued.Status})
+		db.GetSyncRunStore().Save(ctx, syncRun)
+
+		done, err := job.Wait(ctx, enqueued.ID)
+		if err != nil {
+			return fmt.Errorf("waiting for %s failed: %w", step.Type, err)
+		}
+		syncRun.Jobs[len(syncRun.Jobs)-1].Status = done.Status
+		syncRun.Jobs[len(syncRun.Jobs)-1].Error = done.Error
+		db.GetSyncRunStore().Save(ctx, syncRun)
+		if done.Status == jobDB.Failed {
+			return fmt.Errorf("%s failed: %s", step.Type, done.Error)
+		}
+	}
+	return nil
+}
+
+// GetStatus returns the schedules, the next and the last run
+func GetStatus(ctx context.Context) (Status, error) {
+	mutex.Lock()
+	status := Status{
+		Schedules: []string{},
+		NextRunAt: nextRunAt,
+		Running:   running,
+	}
+	for _, schedule := range schedules {
+		status.Schedules = append(status.Schedules, schedule.String())
+	}
+	mutex.Unlock()
+
+	lastRuns, err := db.GetSyncRunStore().GetLatestSyncRuns(ctx, 1)
+	if err != nil {
+		return status, err
+	}
+	if len(lastRuns) > 0 {
+		status.LastRun = lastRuns[0]
+	}
+	return status, nil
+}
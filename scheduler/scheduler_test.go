@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maedu/mtg-cards/job"
+	jobDB "github.com/maedu/mtg-cards/job/db"
+	"github.com/maedu/mtg-cards/scheduler/db"
+)
+
+func useSteps(t *testing.T, testSteps []Step) {
+	previous := steps
+	steps = testSteps
+	t.Cleanup(func() { steps = previous })
+}
+
+func step(jobType string, err error, ran *[]string) Step {
+	return Step{Type: jobType, Enqueue: func() (*jobDB.Job, error) {
+		return job.Enqueue(jobType, jobType, func(ctx context.Context, report func(progress jobDB.Progress)) error {
+			*ran = append(*ran, jobType)
+			return err
+		})
+	}}
+}
+
+func TestRun(t *testing.T) {
+	jobDB.UseJobStore(jobDB.NewMemoryJobStore())
+	db.UseSyncRunStore(db.NewMemorySyncRunStore())
+	ran := []string{}
+	useSteps(t, []Step{step("sets", nil, &ran), step("cards", nil, &ran)})
+
+	syncRun, err := Run(context.Background(), "0 3 * * *")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if syncRun.Status != jobDB.Succeeded || len(syncRun.Jobs) != 2 || syncRun.FinishedAt == nil {
+		t.Errorf("unexpected sync run %+v", syncRun)
+	}
+	if len(ran) != 2 || ran[0] != "sets" || ran[1] != "cards" {
+		t.Errorf("expected sets and then cards, got %v", ran)
+	}
+
+	status, err := GetStatus(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.LastRun == nil || status.LastRun.ID != syncRun.ID || status.Running {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+func TestRunStopsAtFailingStep(t *testing.T) {
+	jobDB.UseJobStore(jobDB.NewMemoryJobStore())
+	db.UseSyncRunStore(db.NewMemorySyncRunStore())
+	ran := []string{}
+	useSteps(t, []Step{step("sets", errors.New("scryfall is down"), &ran), step("cards", nil, &ran)})
+
+	syncRun, err := Run(context.Background(), "manual")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if syncRun.Status != jobDB.Failed || len(syncRun.Jobs) != 1 || syncRun.Jobs[0].Error != "scryfall is down" {
+		t.Errorf("unexpected sync run %+v", syncRun)
+	}
+	if len(ran) != 1 {
+		t.Errorf("expected only the sets step to run, got %v", ran)
+	}
+}
+
+func TestStartStopsIfSchedulesNeverMatch(t *testing.T) {
+	jobDB.UseJobStore(jobDB.NewMemoryJobStore())
+	db.UseSyncRunStore(db.NewMemorySyncRunStore())
+	ran := []string{}
+	useSteps(t, []Step{step("cards", nil, &ran)})
+
+	// ParseSchedule rejects the 30th of February, a schedule created otherwise must not run syncs back to back
+	never := &Schedule{
+		expression: "0 0 30 2 *",
+		minutes:    map[int]bool{0: true},
+		hours:      map[int]bool{0: true},
+		days:       map[int]bool{30: true},
+		months:     map[int]bool{2: true},
+		weekdays:   map[int]bool{0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true},
+		anyWeekday: true,
+	}
+	if next := never.Next(time.Now()); !next.IsZero() {
+		t.Fatalf("Expected no next time, got %v", next)
+	}
+	daily, _ := ParseSchedule("0 3 * * *")
+	mutex.Lock()
+	schedules = []*Schedule{never, daily}
+	mutex.Unlock()
+	if schedule, _ := nextRun(time.Now()); schedule != daily {
+		t.Errorf("Expected the daily schedule to run next, got %v", schedule)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	Start(ctx, []*Schedule{never})
+	time.Sleep(50 * time.Millisecond)
+
+	status, err := GetStatus(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(ran) != 0 || status.NextRunAt != nil {
+		t.Errorf("Expected no sync, got %v and next run at %v", ran, status.NextRunAt)
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/maedu/mtg-cards/scryfall/db"
+)
+
+// Ruling is a ruling of a card
+type Ruling struct {
+	Source      string `json:"source"`
+	PublishedAt string `json:"published_at"`
+	Comment     string `json:"comment"`
+}
+
+// Printing is a printing of a card in a set
+type Printing struct {
+	ID              string                 `json:"id"`
+	Set             string                 `json:"set"`
+	SetName         string                 `json:"set_name"`
+	CollectorNumber string                 `json:"collector_number"`
+	Rarity          string                 `json:"rarity"`
+	ReleasedAt      string                 `json:"released_at"`
+	ImageURLs       map[string]string      `json:"image_uris"`
+	Prices          map[db.Currency]string `json:"prices"`
+}
+
+type listResponse struct {
+	Data     json.RawMessage `json:"data"`
+	HasMore  bool            `json:"has_more"`
+	NextPage string          `json:"next_page"`
+}
+
+// GetRulings returns the rulings of the card with the scryfall ID
+func GetRulings(ctx context.Context, scryfallID string) ([]Ruling, error) {
+	rulings := []Ruling{}
+	err := getList(ctx, fmt.Sprintf("%s/cards/%s/rulings", baseURL(), url.PathEscape(scryfallID)), func(data json.RawMessage) error {
+		var page []Ruling
+		err := json.Unmarshal(data, &page)
+		rulings = append(rulings, page...)
+		return err
+	})
+	return rulings, err
+}
+
+// GetPrintings returns all printings of the card with the oracle ID, ordered by their release
+func GetPrintings(ctx context.Context, oracleID string) ([]Printing, error) {
+	query := url.Values{}
+	query.Set("q", "oracleid:"+oracleID)
+	query.Set("unique", "prints")
+	query.Set("order", "released")
+	query.Set("dir", "asc")
+
+	printings := []Printing{}
+	err := getList(ctx, baseURL()+"/cards/search?"+query.Encode(), func(data json.RawMessage) error {
+		var page []Printing
+		err := json.Unmarshal(data, &page)
+		printings = append(printings, page...)
+		return err
+	})
+	return printings, err
+}
+
+// getList loads all pages of a scryfall list
+func getList(ctx context.Context, url string, addPage func(data json.RawMessage) error) error {
+	for url != "" {
+		log.Printf("Get list: %s", url)
+		resp, err := get(ctx, url)
+		if err != nil {
+			return err
+		}
+
+		var res listResponse
+		err = json.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		err = addPage(res.Data)
+		if err != nil {
+			return err
+		}
+
+		url = ""
+		if res.HasMore {
+			url = res.NextPage
+		}
+	}
+	return nil
+}
@@ -1,104 +1,216 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
-	"net/http"
+	"strings"
+	"time"
 
+	env "bitbucket.org/spinnerweb/accounting_common/env"
+
+	mtgDB "github.com/maedu/mtg-cards/db"
 	"github.com/maedu/mtg-cards/scryfall/db"
 )
 
-const oracleCards = "oracle_cards"
+const (
+	oracleCards  = "oracle_cards"
+	defaultCards = "default_cards"
+)
+
+// CardImporter stores the cards of a bulk data import, they replace the previous cards only on Commit.
+// If the import fails the cards are discarded and the previous cards are kept.
+type CardImporter interface {
+	CreateMany(ctx context.Context, cards []*db.ScryfallCard) error
+	Commit(ctx context.Context) error
+	Discard(ctx context.Context) error
+}
+
+// scryfallCardImporter imports the cards into a staging collection, which replaces the scryfall cards collection on Commit
+type scryfallCardImporter struct {
+	staging *db.ScryfallCardCollection
+	target  *db.ScryfallCardCollection
+}
+
+func newScryfallCardImporter(ctx context.Context) (CardImporter, error) {
+	target := db.GetScryfallCardCollection()
+	staging, err := mtgDB.NewStagingCollection(ctx, target.Collection)
+	if err != nil {
+		return nil, err
+	}
+	return scryfallCardImporter{staging: &db.ScryfallCardCollection{Collection: staging}, target: target}, nil
+}
+
+func (importer scryfallCardImporter) CreateMany(ctx context.Context, cards []*db.ScryfallCard) error {
+	_, err := importer.staging.CreateMany(ctx, cards)
+	return err
+}
+
+func (importer scryfallCardImporter) Commit(ctx context.Context) error {
+	return mtgDB.ReplaceCollection(ctx, importer.staging.Collection, importer.target.Collection)
+}
+
+func (importer scryfallCardImporter) Discard(ctx context.Context) error {
+	return importer.staging.Drop(ctx)
+}
 
 type BulkDataResponse struct {
 	Data []*BulkData `json:"data"`
 }
 
 type BulkData struct {
-	Type        string `json:"type"`
-	DownloadURI string `json:"download_uri"`
+	Type        string    `json:"type"`
+	DownloadURI string    `json:"download_uri"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Size        int64     `json:"size"`
 }
 
-func UpdateCards() error {
+// IsImported returns true if the bulk data is the same as the one of the last import
+func (bulkData *BulkData) IsImported(lastImport *db.BulkDataImport) bool {
+	return lastImport != nil && lastImport.UpdatedAt.Equal(bulkData.UpdatedAt) && lastImport.Size == bulkData.Size
+}
+
+// UpdateCards replaces the scryfall cards with the oracle cards of the current bulk data.
+// The download is skipped if the bulk data did not change since the last import, unless force is set.
+// It returns false if the cards were not updated.
+func UpdateCards(ctx context.Context, force bool, progress func(ImportProgress)) (bool, error) {
 	log.Println("UpdateCards")
-	bulkData, err := getBulkData()
-	if err != nil {
-		return fmt.Errorf("getBulkData: %w", err)
-	}
+	return updateBulkData(ctx, oracleCards, force, newScryfallCardImporter, progress)
+}
 
-	uri, err := uri(bulkData)
-	if err != nil {
-		return fmt.Errorf("uri: %w", err)
+// UpdateDefaultCards imports the default cards of the current bulk data, which contain every printing of every card,
+// with the importer created by newImporter. Like UpdateCards, it returns false if the bulk data did not change since the last import and force is not set.
+func UpdateDefaultCards(ctx context.Context, force bool, newImporter func(ctx context.Context) (CardImporter, error), progress func(ImportProgress)) (bool, error) {
+	log.Println("UpdateDefaultCards")
+	return updateBulkData(ctx, defaultCards, force, newImporter, progress)
+}
+
+// updateBulkData imports the cards of the bulk data type, either from the configured file or from scryfall
+func updateBulkData(ctx context.Context, bulkType string, force bool, newImporter func(ctx context.Context) (CardImporter, error), progress func(ImportProgress)) (bool, error) {
+	if path := bulkDataFile(bulkType); path != "" {
+		body, size, err := openFile(path)
+		if err != nil {
+			return false, err
+		}
+		defer body.Close()
+		err = importCards(ctx, body, size, newImporter, progress)
+		if err != nil {
+			return false, fmt.Errorf("importCards: %w", err)
+		}
+		return true, nil
 	}
 
-	cards, err := getCards(uri)
+	bulkData, err := findBulkData(ctx, bulkType)
 	if err != nil {
-		return fmt.Errorf("getCards: %w", err)
+		return false, err
 	}
-	log.Println("GetScryfallCardCollection")
-	collection := db.GetScryfallCardCollection()
-	defer collection.Disconnect()
-	_, err = collection.ReplaceAll(cards)
-	return err
-}
 
-func getBulkData() ([]*BulkData, error) {
-	url := "https://api.scryfall.com/bulk-data"
-	log.Printf("Get BulkData: %s", url)
+	imports := db.GetBulkDataImportCollection()
+	if !force {
+		lastImport, err := imports.GetBulkDataImport(ctx, bulkType)
+		if err != nil {
+			return false, err
+		}
+		if bulkData.IsImported(lastImport) {
+			log.Printf("Bulk data %s of %v already imported", bulkType, bulkData.UpdatedAt)
+			return false, nil
+		}
+	}
 
-	resp, err := http.Get(url)
+	log.Printf("Get Cards: %s", bulkData.DownloadURI)
+	resp, err := get(ctx, bulkData.DownloadURI)
 	if err != nil {
-		log.Print(err)
-		return nil, err
+		return false, err
 	}
+	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		log.Printf("Status not 200 but %d", resp.StatusCode)
-		return nil, fmt.Errorf("Status not 200 but %d", resp.StatusCode)
+	err = importCards(ctx, resp.Body, resp.ContentLength, newImporter, progress)
+	if err != nil {
+		return false, fmt.Errorf("importCards: %w", err)
 	}
 
-	defer resp.Body.Close()
-		var res BulkDataResponse
-		err = json.NewDecoder(resp.Body).Decode(&res)
-
-		if err != nil {
-			log.Print(err)
-			return nil, err
-		}
-		return res.Data, nil
+	err = imports.Save(ctx, &db.BulkDataImport{
+		Type:       bulkData.Type,
+		UpdatedAt:  bulkData.UpdatedAt,
+		Size:       bulkData.Size,
+		ImportedAt: time.Now(),
+	})
+	return true, err
 }
 
-func uri(bulkDataSlice []*BulkData) (string, error) {
+// findBulkData returns the current bulk data item of the type
+func findBulkData(ctx context.Context, bulkType string) (*BulkData, error) {
+	bulkDataSlice, err := getBulkData(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getBulkData: %w", err)
+	}
+
 	for _, bulkData := range bulkDataSlice {
-		if bulkData.Type == oracleCards {
-			return bulkData.DownloadURI, nil
+		if bulkData.Type == bulkType {
+			return bulkData, nil
 		}
 	}
 
-	return "", fmt.Errorf("no bulk_data for oracleCards found")
+	return nil, fmt.Errorf("no bulk_data for %s found", bulkType)
 }
 
-func getCards(uri string) ([]*db.ScryfallCard, error) {
-	log.Printf("Get Cards: %s", uri)
-	resp, err := http.Get(uri)
+// bulkDataFile returns the local file configured for the bulk data type in SCRYFALL_<TYPE>_FILE,
+// e.g. SCRYFALL_ORACLE_CARDS_FILE, which is imported instead of downloading from scryfall
+func bulkDataFile(bulkType string) string {
+	return env.GetEnv(fmt.Sprintf("SCRYFALL_%s_FILE", strings.ToUpper(bulkType)), "")
+}
+
+func getBulkData(ctx context.Context) ([]*BulkData, error) {
+	url := baseURL() + "/bulk-data"
+	log.Printf("Get BulkData: %s", url)
+
+	resp, err := get(ctx, url)
 	if err != nil {
-		log.Print(err)
 		return nil, err
 	}
 
-	if resp.StatusCode != 200 {
-		log.Printf("Status not 200 but %d", resp.StatusCode)
-		return nil, fmt.Errorf("Status not 200 but %d", resp.StatusCode)
-	}
-
 	defer resp.Body.Close()
-	var res []*db.ScryfallCard
+	var res BulkDataResponse
 	err = json.NewDecoder(resp.Body).Decode(&res)
 
 	if err != nil {
 		log.Print(err)
 		return nil, err
 	}
-	return res, nil
+	return res.Data, nil
+}
+
+// importCards imports the cards of the body with a new importer, they replace the previous cards only once all are imported
+func importCards(ctx context.Context, body io.Reader, size int64, newImporter func(ctx context.Context) (CardImporter, error), progress func(ImportProgress)) error {
+	importer, err := newImporter(ctx)
+	if err != nil {
+		return err
+	}
+
+	total, err := DecodeCards(ctx, body, size, importBatchSize(), func(cards []*db.ScryfallCard, current ImportProgress) error {
+		err := importer.CreateMany(ctx, cards)
+		if err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(current)
+		}
+		return nil
+	})
+	if err != nil {
+		// Keep the previous cards, the context might be cancelled already
+		if discardErr := importer.Discard(context.Background()); discardErr != nil {
+			log.Printf("Discarding the imported cards failed: %v", discardErr)
+		}
+		return err
+	}
+
+	err = importer.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Imported %d cards", total.Cards)
+	return nil
 }
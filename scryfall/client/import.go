@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	env "bitbucket.org/spinnerweb/accounting_common/env"
+	"github.com/maedu/mtg-cards/scryfall/db"
+)
+
+const defaultImportBatchSize = 1000
+
+// ImportProgress is the state of a running import
+type ImportProgress struct {
+	Cards      int   `json:"cards"`
+	ReadBytes  int64 `json:"readBytes"`
+	TotalBytes int64 `json:"totalBytes"` // -1 if unknown
+}
+
+// DecodeCards decodes the JSON array of cards token by token and passes them in batches of batchSize to handle,
+// so only one batch is kept in memory. totalBytes is the size of the input, -1 if unknown.
+func DecodeCards(ctx context.Context, r io.Reader, totalBytes int64, batchSize int, handle func(cards []*db.ScryfallCard, progress ImportProgress) error) (ImportProgress, error) {
+	counter := &countingReader{reader: r}
+	decoder := json.NewDecoder(counter)
+	progress := ImportProgress{TotalBytes: totalBytes}
+
+	token, err := decoder.Token()
+	if err != nil {
+		return progress, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return progress, fmt.Errorf("expected an array of cards, got %v", token)
+	}
+
+	batch := make([]*db.ScryfallCard, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		progress.Cards += len(batch)
+		progress.ReadBytes = counter.count
+		err := handle(batch, progress)
+		batch = make([]*db.ScryfallCard, 0, batchSize)
+		return err
+	}
+
+	for decoder.More() {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+		var card *db.ScryfallCard
+		err = decoder.Decode(&card)
+		if err != nil {
+			return progress, fmt.Errorf("failed decoding card %d: %w", progress.Cards+len(batch)+1, err)
+		}
+		batch = append(batch, card)
+		if len(batch) >= batchSize {
+			err = flush()
+			if err != nil {
+				return progress, err
+			}
+		}
+	}
+
+	_, err = decoder.Token()
+	if err != nil {
+		return progress, err
+	}
+	return progress, flush()
+}
+
+func importBatchSize() int {
+	batchSize, err := strconv.Atoi(env.GetEnv("SCRYFALL_IMPORT_BATCH_SIZE", strconv.Itoa(defaultImportBatchSize)))
+	if err != nil || batchSize < 1 {
+		log.Printf("Invalid SCRYFALL_IMPORT_BATCH_SIZE, using %d", defaultImportBatchSize)
+		return defaultImportBatchSize
+	}
+	return batchSize
+}
+
+// LogProgress logs the progress of an import
+func LogProgress(progress ImportProgress) {
+	if progress.TotalBytes > 0 {
+		log.Printf("Imported %d cards (%d%%)", progress.Cards, progress.ReadBytes*100/progress.TotalBytes)
+		return
+	}
+	log.Printf("Imported %d cards", progress.Cards)
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
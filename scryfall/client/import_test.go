@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/maedu/mtg-cards/scryfall/db"
+)
+
+func TestDecodeCards(t *testing.T) {
+	input := `[{"name": "Sol Ring"}, {"name": "Forest"}, {"name": "Harmonize"}]`
+
+	var batches [][]string
+	progress, err := DecodeCards(context.Background(), strings.NewReader(input), int64(len(input)), 2, func(cards []*db.ScryfallCard, progress ImportProgress) error {
+		names := []string{}
+		for _, card := range cards {
+			names = append(names, card.Name)
+		}
+		batches = append(batches, names)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 || batches[1][0] != "Harmonize" {
+		t.Errorf("unexpected batches %v", batches)
+	}
+	if progress.Cards != 3 {
+		t.Errorf("expected 3 cards, got %d", progress.Cards)
+	}
+	if progress.ReadBytes != int64(len(input)) {
+		t.Errorf("expected %d read bytes, got %d", len(input), progress.ReadBytes)
+	}
+}
+
+func TestDecodeCardsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"no array", `{"name": "Sol Ring"}`},
+		{"truncated", `[{"name": "Sol Ring"}, {"name": `},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecodeCards(context.Background(), strings.NewReader(test.input), -1, 10, func(cards []*db.ScryfallCard, progress ImportProgress) error {
+				return nil
+			})
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
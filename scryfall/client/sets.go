@@ -1,10 +1,11 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
-	"net/http"
 
 	"github.com/maedu/mtg-cards/scryfall/db"
 )
@@ -13,38 +14,29 @@ type SetsBulkDataResponse struct {
 	Data []*db.ScryfallSet `json:"data"`
 }
 
-func UpdateSets() error {
+// UpdateSets replaces the scryfall sets, either from the file configured in SCRYFALL_SETS_FILE or from scryfall
+func UpdateSets(ctx context.Context) error {
 	log.Println("UpdateSets")
 
-	sets, err := getSets()
+	sets, err := getSets(ctx)
 	if err != nil {
 		return fmt.Errorf("getSets: %w", err)
 	}
 	log.Println("GetScryfallSetCollection")
 	collection := db.GetScryfallSetCollection()
-	defer collection.Disconnect()
-	_, err = collection.ReplaceAll(sets)
+	_, err = collection.ReplaceAll(ctx, sets)
 	return err
 }
 
-func getSets() ([]*db.ScryfallSet, error) {
-	url := "https://api.scryfall.com/sets"
-	log.Printf("Get Sets: %s", url)
-
-	resp, err := http.Get(url)
+func getSets(ctx context.Context) ([]*db.ScryfallSet, error) {
+	body, err := openSets(ctx)
 	if err != nil {
-		log.Print(err)
 		return nil, err
 	}
+	defer body.Close()
 
-	if resp.StatusCode != 200 {
-		log.Printf("Status not 200 but %d", resp.StatusCode)
-		return nil, fmt.Errorf("Status not 200 but %d", resp.StatusCode)
-	}
-
-	defer resp.Body.Close()
 	var res SetsBulkDataResponse
-	err = json.NewDecoder(resp.Body).Decode(&res)
+	err = json.NewDecoder(body).Decode(&res)
 
 	if err != nil {
 		log.Print(err)
@@ -52,3 +44,18 @@ func getSets() ([]*db.ScryfallSet, error) {
 	}
 	return res.Data, nil
 }
+
+func openSets(ctx context.Context) (io.ReadCloser, error) {
+	if path := bulkDataFile("sets"); path != "" {
+		body, _, err := openFile(path)
+		return body, err
+	}
+
+	url := baseURL() + "/sets"
+	log.Printf("Get Sets: %s", url)
+	resp, err := get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	env "bitbucket.org/spinnerweb/accounting_common/env"
+)
+
+const defaultBaseURL = "https://api.scryfall.com"
+
+// baseURL returns the scryfall API URL, configurable with SCRYFALL_BASE_URL, e.g. to use a local stand-in server
+func baseURL() string {
+	return strings.TrimSuffix(env.GetEnv("SCRYFALL_BASE_URL", defaultBaseURL), "/")
+}
+
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		log.Printf("Status not 200 but %d", resp.StatusCode)
+		return nil, fmt.Errorf("Status not 200 but %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// openFile opens a local JSON file, which may be gzip compressed. It returns the size of the file, -1 if compressed.
+func openFile(path string) (io.ReadCloser, int64, error) {
+	log.Printf("Open file: %s", path)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	reader := bufio.NewReader(file)
+	magic, err := reader.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			file.Close()
+			return nil, 0, fmt.Errorf("failed reading gzip file %s: %w", path, err)
+		}
+		return &fileReader{Reader: gzipReader, closers: []io.Closer{gzipReader, file}}, -1, nil
+	}
+
+	size := int64(-1)
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	return &fileReader{Reader: reader, closers: []io.Closer{file}}, size, nil
+}
+
+type fileReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *fileReader) Close() error {
+	var err error
+	for _, closer := range r.closers {
+		if closeErr := closer.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
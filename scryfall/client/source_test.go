@@ -0,0 +1,142 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maedu/mtg-cards/scryfall/db"
+)
+
+const oracleCardsJSON = `[{"name": "Sol Ring"}]`
+
+func TestFindBulkDataFromBaseURL(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bulk-data":
+			w.Write([]byte(`{"data": [{"type": "oracle_cards", "download_uri": "` + server.URL + `/oracle-cards.json", "updated_at": "2020-12-01T10:02:17.371+00:00", "size": 23}]}`))
+		case "/oracle-cards.json":
+			w.Write([]byte(oracleCardsJSON))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	setEnv(t, "SCRYFALL_BASE_URL", server.URL+"/")
+
+	bulkData, err := findBulkData(context.Background(), oracleCards)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bulkData.Size != 23 || bulkData.UpdatedAt.Year() != 2020 {
+		t.Errorf("unexpected bulk data %+v", bulkData)
+	}
+
+	resp, err := get(context.Background(), bulkData.DownloadURI)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	assertBody(t, resp.Body)
+
+	_, err = findBulkData(context.Background(), "default_cards")
+	if err == nil {
+		t.Error("expected an error for missing bulk data")
+	}
+}
+
+func TestIsImported(t *testing.T) {
+	updatedAt := time.Date(2020, 12, 1, 10, 2, 17, 0, time.UTC)
+	bulkData := &BulkData{Type: oracleCards, UpdatedAt: updatedAt, Size: 23}
+
+	tests := []struct {
+		name       string
+		lastImport *db.BulkDataImport
+		want       bool
+	}{
+		{"never imported", nil, false},
+		{"same", &db.BulkDataImport{UpdatedAt: updatedAt, Size: 23}, true},
+		{"older", &db.BulkDataImport{UpdatedAt: updatedAt.Add(-time.Hour), Size: 23}, false},
+		{"other size", &db.BulkDataImport{UpdatedAt: updatedAt, Size: 24}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := bulkData.IsImported(test.lastImport); got != test.want {
+				t.Errorf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	plainPath := filepath.Join(dir, "oracle-cards.json")
+	err := ioutil.WriteFile(plainPath, []byte(oracleCardsJSON), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gzipPath := filepath.Join(dir, "oracle-cards.json.gz")
+	file, err := os.Create(gzipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := gzip.NewWriter(file)
+	writer.Write([]byte(oracleCardsJSON))
+	writer.Close()
+	file.Close()
+
+	tests := []struct {
+		name string
+		path string
+		size int64
+	}{
+		{"plain", plainPath, int64(len(oracleCardsJSON))},
+		{"gzip", gzipPath, -1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, size, err := openFile(test.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer body.Close()
+			if size != test.size {
+				t.Errorf("expected size %d, got %d", test.size, size)
+			}
+			assertBody(t, body)
+		})
+	}
+}
+
+func assertBody(t *testing.T, body io.Reader) {
+	t.Helper()
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != oracleCardsJSON {
+		t.Errorf("unexpected body %q", data)
+	}
+}
+
+func setEnv(t *testing.T, key string, value string) {
+	t.Helper()
+	previous, exists := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if exists {
+			os.Setenv(key, previous)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
@@ -19,6 +19,11 @@ const (
 	Commander GameType  = "commander"
 	Legal     LegalText = "legal"
 
+	Restricted LegalText = "restricted"
+
+	TokenComponent      = "token"
+	ComboPieceComponent = "combo_piece"
+
 	USD      Currency = "usd"
 	EUR      Currency = "eur"
 	USD_FOIL Currency = "usd_foil"
@@ -48,8 +53,11 @@ type ScryfallCard struct {
 	ColorIdentity []string               `json:"color_identity"`
 	Power         string                 `json:"power"`
 	Toughness     string                 `json:"toughness"`
+	Loyalty       string                 `json:"loyalty"`
+	ProducedMana  []string               `json:"produced_mana"`
 	Keywords      []string               `json:"keywords"`
 	CardFaces     []ScryfallCard         `json:"card_faces"`
+	AllParts      []RelatedCard          `json:"all_parts"`
 	Legalities    map[GameType]LegalText `json:"legalities"`
 	Set           string                 `json:"set"`
 	SetName       string                 `json:"set_name"`
@@ -60,6 +68,24 @@ type ScryfallCard struct {
 	EdhrecRank    int                    `json:"edhrec_rank"`
 	Prices        map[Currency]string    `json:"prices"`
 	RelatedURIs   map[RelatedURI]string  `json:"related_uris"`
+	Reserved      bool                   `json:"reserved"`
+	Digital       bool                   `json:"digital"`
+	Promo         bool                   `json:"promo"`
+
+	// CollectorNumber, ReleasedAt, Foil, Nonfoil and Booster belong to the printing, they are only meaningful in default_cards
+	CollectorNumber string `json:"collector_number"`
+	ReleasedAt      string `json:"released_at"`
+	Foil            bool   `json:"foil"`
+	Nonfoil         bool   `json:"nonfoil"`
+	Booster         bool   `json:"booster"`
+}
+
+// RelatedCard is a part of all_parts, e.g. a token created by the card or a card creating the token
+type RelatedCard struct {
+	ID        string `json:"id"`
+	Component string `json:"component"`
+	Name      string `json:"name"`
+	TypeLine  string `json:"type_line"`
 }
 
 type GameType string
@@ -69,36 +95,19 @@ type RelatedURI string
 
 // ScryfallCardCollection ...
 type ScryfallCardCollection struct {
-	*mongo.Client
 	*mongo.Collection
-	context.Context
-	context.CancelFunc
 }
 
-// GetScryfallCardCollection ...
-func GetScryfallCardCollection() ScryfallCardCollection {
-	client, ctx, cancel := db.GetConnection()
-	db := client.Database(db.GetDatabaseName())
-	collection := db.Collection("scryfallcards")
-
-	return ScryfallCardCollection{
-		Client:     client,
-		Collection: collection,
-		Context:    ctx,
-		CancelFunc: cancel,
+// GetScryfallCardCollection returns the ScryfallCardCollection using the shared client
+func GetScryfallCardCollection() *ScryfallCardCollection {
+	return &ScryfallCardCollection{
+		Collection: db.GetClient().Database(db.GetDatabaseName()).Collection("scryfallcards"),
 	}
 }
 
-// Disconnect ...
-func (collection *ScryfallCardCollection) Disconnect() {
-	collection.CancelFunc()
-	collection.Client.Disconnect(collection.Context)
-}
-
 // GetAllScryfallCards Retrives all scryfallcards from the db
-func (collection *ScryfallCardCollection) GetAllScryfallCards() ([]*ScryfallCard, error) {
+func (collection *ScryfallCardCollection) GetAllScryfallCards(ctx context.Context) ([]*ScryfallCard, error) {
 	var scryfallcards []*ScryfallCard = []*ScryfallCard{}
-	ctx := collection.Context
 
 	cursor, err := collection.Collection.Find(ctx, bson.D{})
 	if err != nil {
@@ -114,38 +123,38 @@ func (collection *ScryfallCardCollection) GetAllScryfallCards() ([]*ScryfallCard
 }
 
 // GetCScryfallCards Retrives all cards from the db
-func (collection *ScryfallCardCollection) GetScryfallCardsPaginated(limit int64, page int64) (PaginatedResult, error) {
+func (collection *ScryfallCardCollection) GetScryfallCardsPaginated(ctx context.Context, limit int64, page int64) (PaginatedResult, error) {
 
 	projection := bson.M{}
 
 	filter := bson.M{}
 
 	sort := bson.D{}
-	paginatedData, err := pagination.New(collection.Collection).Limit(limit).Page(page).Filter(filter).Select(projection).Sort(sort).Find()
+	query := db.PaginatedQuery{Limit: limit, Page: page, Filter: filter, Projection: projection, Sort: sort}
+	data, paginationData, err := query.Find(ctx, collection.Collection)
 	if err != nil {
 		return PaginatedResult{}, err
 	}
 
 	var cards []*ScryfallCard = []*ScryfallCard{}
-	for _, raw := range paginatedData.Data {
+	for _, raw := range data {
 		var card *ScryfallCard
 		if marshallErr := bson.Unmarshal(raw, &card); marshallErr != nil {
-			log.Printf("Failed marshalling: %v", err)
-			return PaginatedResult{}, err
+			log.Printf("Failed marshalling: %v", marshallErr)
+			return PaginatedResult{}, marshallErr
 		}
 		cards = append(cards, card)
 
 	}
 	return PaginatedResult{
 		Cards:      cards,
-		Pagination: paginatedData.Pagination,
+		Pagination: paginationData,
 	}, nil
 }
 
 // GetScryfallCardByID Retrives a scryfallcard by its id from the db
-func (collection *ScryfallCardCollection) GetScryfallCardByID(id string) (*ScryfallCard, error) {
+func (collection *ScryfallCardCollection) GetScryfallCardByID(ctx context.Context, id string) (*ScryfallCard, error) {
 	var scryfallcard *ScryfallCard
-	ctx := collection.Context
 
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -166,8 +175,7 @@ func (collection *ScryfallCardCollection) GetScryfallCardByID(id string) (*Scryf
 }
 
 // GetScryfallCardByIDs Retrives scryfallcards by their ids from the db
-func (collection *ScryfallCardCollection) GetScryfallCardByIDs(ids *[]primitive.ObjectID) (*[]ScryfallCard, error) {
-	ctx := collection.Context
+func (collection *ScryfallCardCollection) GetScryfallCardByIDs(ctx context.Context, ids *[]primitive.ObjectID) (*[]ScryfallCard, error) {
 	var scryfallcards []ScryfallCard = []ScryfallCard{}
 	cursor, err := collection.Collection.Find(ctx, bson.D{bson.E{Key: "_id", Value: bson.M{"$in": ids}}})
 	if err != nil {
@@ -184,9 +192,8 @@ func (collection *ScryfallCardCollection) GetScryfallCardByIDs(ids *[]primitive.
 }
 
 // GetScryfallCardByKey Retrives a scryfallcard by its key from the db
-func (collection *ScryfallCardCollection) GetScryfallCardByKey(key string) (*ScryfallCard, error) {
+func (collection *ScryfallCardCollection) GetScryfallCardByKey(ctx context.Context, key string) (*ScryfallCard, error) {
 	var scryfallcard *ScryfallCard
-	ctx := collection.Context
 
 	result := collection.Collection.FindOne(ctx, bson.D{bson.E{Key: "key", Value: key}})
 	if result == nil {
@@ -206,8 +213,7 @@ func (collection *ScryfallCardCollection) GetScryfallCardByKey(key string) (*Scr
 }
 
 // Create creating a scryfallcard in a mongo
-func (collection *ScryfallCardCollection) Create(scryfallcard *ScryfallCard) (primitive.ObjectID, error) {
-	ctx := collection.Context
+func (collection *ScryfallCardCollection) Create(ctx context.Context, scryfallcard *ScryfallCard) (primitive.ObjectID, error) {
 	scryfallcard.ID = primitive.NewObjectID().Hex()
 
 	result, err := collection.Collection.InsertOne(ctx, scryfallcard)
@@ -220,8 +226,7 @@ func (collection *ScryfallCardCollection) Create(scryfallcard *ScryfallCard) (pr
 }
 
 // CreateMany creating many scryfallcards in a mongo
-func (collection *ScryfallCardCollection) CreateMany(scryfallcards []*ScryfallCard) (*[]string, error) {
-	ctx := collection.Context
+func (collection *ScryfallCardCollection) CreateMany(ctx context.Context, scryfallcards []*ScryfallCard) (*[]string, error) {
 
 	var ui []interface{}
 	for _, t := range scryfallcards {
@@ -246,8 +251,7 @@ func (collection *ScryfallCardCollection) CreateMany(scryfallcards []*ScryfallCa
 }
 
 //Update updating an existing scryfallcard in a mongo
-func (collection *ScryfallCardCollection) Update(scryfallcard *ScryfallCard) (*ScryfallCard, error) {
-	ctx := collection.Context
+func (collection *ScryfallCardCollection) Update(ctx context.Context, scryfallcard *ScryfallCard) (*ScryfallCard, error) {
 	var updatedScryfallCard *ScryfallCard
 
 	update := bson.M{
@@ -270,8 +274,7 @@ func (collection *ScryfallCardCollection) Update(scryfallcard *ScryfallCard) (*S
 }
 
 // DeleteScryfallCardByID Deletes an scryfallcard by its id from the db
-func (collection *ScryfallCardCollection) DeleteScryfallCardByID(id string) error {
-	ctx := collection.Context
+func (collection *ScryfallCardCollection) DeleteScryfallCardByID(ctx context.Context, id string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		log.Printf("Failed parsing id %v", err)
@@ -289,9 +292,13 @@ func (collection *ScryfallCardCollection) DeleteScryfallCardByID(id string) erro
 	return nil
 }
 
+// DeleteAll deletes all scryfallcards
+func (collection *ScryfallCardCollection) DeleteAll(ctx context.Context) error {
+	return collection.Collection.Drop(ctx)
+}
+
 // ReplaceAll first delete all and then create many cards in a mongo db
-func (collection *ScryfallCardCollection) ReplaceAll(cards []*ScryfallCard) (*[]string, error) {
-	ctx := collection.Context
+func (collection *ScryfallCardCollection) ReplaceAll(ctx context.Context, cards []*ScryfallCard) (*[]string, error) {
 
 	err := collection.Collection.Drop(ctx)
 	if err != nil {
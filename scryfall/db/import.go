@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/maedu/mtg-cards/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BulkDataImport is the last successful import of a scryfall bulk data type, e.g. oracle_cards
+type BulkDataImport struct {
+	Type       string    `bson:"_id" json:"type"`
+	UpdatedAt  time.Time `bson:"updated_at" json:"updatedAt"`
+	Size       int64     `bson:"size" json:"size"`
+	ImportedAt time.Time `bson:"imported_at" json:"importedAt"`
+}
+
+// BulkDataImportCollection ...
+type BulkDataImportCollection struct {
+	*mongo.Collection
+}
+
+// GetBulkDataImportCollection returns the BulkDataImportCollection using the shared client
+func GetBulkDataImportCollection() *BulkDataImportCollection {
+	return &BulkDataImportCollection{
+		Collection: db.GetClient().Database(db.GetDatabaseName()).Collection("scryfall_imports"),
+	}
+}
+
+// GetBulkDataImport retrieves the last import of the bulk data type, nil if there was none
+func (collection *BulkDataImportCollection) GetBulkDataImport(ctx context.Context, bulkType string) (*BulkDataImport, error) {
+	var bulkDataImport *BulkDataImport
+	err := collection.Collection.FindOne(ctx, bson.M{"_id": bulkType}).Decode(&bulkDataImport)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Failed marshalling %v", err)
+		return nil, err
+	}
+	return bulkDataImport, nil
+}
+
+// Save creates or replaces the import of its bulk data type
+func (collection *BulkDataImportCollection) Save(ctx context.Context, bulkDataImport *BulkDataImport) error {
+	_, err := collection.Collection.ReplaceOne(ctx, bson.M{"_id": bulkDataImport.Type}, bulkDataImport, options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Printf("Could not save BulkDataImport: %v", err)
+	}
+	return err
+}
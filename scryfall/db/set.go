@@ -33,36 +33,19 @@ type ScryfallSet struct {
 
 // ScryfallSetCollection ...
 type ScryfallSetCollection struct {
-	*mongo.Client
 	*mongo.Collection
-	context.Context
-	context.CancelFunc
 }
 
-// GetScryfallSetCollection ...
-func GetScryfallSetCollection() ScryfallSetCollection {
-	client, ctx, cancel := db.GetConnection()
-	db := client.Database(db.GetDatabaseName())
-	collection := db.Collection("scryfallsets")
-
-	return ScryfallSetCollection{
-		Client:     client,
-		Collection: collection,
-		Context:    ctx,
-		CancelFunc: cancel,
+// GetScryfallSetCollection returns the ScryfallSetCollection using the shared client
+func GetScryfallSetCollection() *ScryfallSetCollection {
+	return &ScryfallSetCollection{
+		Collection: db.GetClient().Database(db.GetDatabaseName()).Collection("scryfallsets"),
 	}
 }
 
-// Disconnect ...
-func (collection *ScryfallSetCollection) Disconnect() {
-	collection.CancelFunc()
-	collection.Client.Disconnect(collection.Context)
-}
-
 // GetAllScryfallSets Retrives all scryfallsets from the db
-func (collection *ScryfallSetCollection) GetAllScryfallSets() ([]*ScryfallSet, error) {
+func (collection *ScryfallSetCollection) GetAllScryfallSets(ctx context.Context) ([]*ScryfallSet, error) {
 	var scryfallsets []*ScryfallSet = []*ScryfallSet{}
-	ctx := collection.Context
 
 	cursor, err := collection.Collection.Find(ctx, bson.D{})
 	if err != nil {
@@ -78,9 +61,8 @@ func (collection *ScryfallSetCollection) GetAllScryfallSets() ([]*ScryfallSet, e
 }
 
 // GetScryfallSetByID Retrives a scryfallset by its id from the db
-func (collection *ScryfallSetCollection) GetScryfallSetByID(id string) (*ScryfallSet, error) {
+func (collection *ScryfallSetCollection) GetScryfallSetByID(ctx context.Context, id string) (*ScryfallSet, error) {
 	var scryfallset *ScryfallSet
-	ctx := collection.Context
 
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -101,8 +83,7 @@ func (collection *ScryfallSetCollection) GetScryfallSetByID(id string) (*Scryfal
 }
 
 // GetScryfallSetByIDs Retrives scryfallsets by their ids from the db
-func (collection *ScryfallSetCollection) GetScryfallSetByIDs(ids *[]primitive.ObjectID) (*[]ScryfallSet, error) {
-	ctx := collection.Context
+func (collection *ScryfallSetCollection) GetScryfallSetByIDs(ctx context.Context, ids *[]primitive.ObjectID) (*[]ScryfallSet, error) {
 	var scryfallsets []ScryfallSet = []ScryfallSet{}
 	cursor, err := collection.Collection.Find(ctx, bson.D{bson.E{Key: "_id", Value: bson.M{"$in": ids}}})
 	if err != nil {
@@ -119,9 +100,8 @@ func (collection *ScryfallSetCollection) GetScryfallSetByIDs(ids *[]primitive.Ob
 }
 
 // GetScryfallSetByKey Retrives a scryfallset by its key from the db
-func (collection *ScryfallSetCollection) GetScryfallSetByKey(key string) (*ScryfallSet, error) {
+func (collection *ScryfallSetCollection) GetScryfallSetByKey(ctx context.Context, key string) (*ScryfallSet, error) {
 	var scryfallset *ScryfallSet
-	ctx := collection.Context
 
 	result := collection.Collection.FindOne(ctx, bson.D{bson.E{Key: "key", Value: key}})
 	if result == nil {
@@ -141,8 +121,7 @@ func (collection *ScryfallSetCollection) GetScryfallSetByKey(key string) (*Scryf
 }
 
 // Create creating a scryfallset in a mongo
-func (collection *ScryfallSetCollection) Create(scryfallset *ScryfallSet) (primitive.ObjectID, error) {
-	ctx := collection.Context
+func (collection *ScryfallSetCollection) Create(ctx context.Context, scryfallset *ScryfallSet) (primitive.ObjectID, error) {
 	scryfallset.ID = primitive.NewObjectID().Hex()
 
 	result, err := collection.Collection.InsertOne(ctx, scryfallset)
@@ -155,8 +134,7 @@ func (collection *ScryfallSetCollection) Create(scryfallset *ScryfallSet) (primi
 }
 
 // CreateMany creating many scryfallsets in a mongo
-func (collection *ScryfallSetCollection) CreateMany(scryfallsets []*ScryfallSet) (*[]string, error) {
-	ctx := collection.Context
+func (collection *ScryfallSetCollection) CreateMany(ctx context.Context, scryfallsets []*ScryfallSet) (*[]string, error) {
 
 	var ui []interface{}
 	for _, t := range scryfallsets {
@@ -181,8 +159,7 @@ func (collection *ScryfallSetCollection) CreateMany(scryfallsets []*ScryfallSet)
 }
 
 //Update updating an existing scryfallset in a mongo
-func (collection *ScryfallSetCollection) Update(scryfallset *ScryfallSet) (*ScryfallSet, error) {
-	ctx := collection.Context
+func (collection *ScryfallSetCollection) Update(ctx context.Context, scryfallset *ScryfallSet) (*ScryfallSet, error) {
 	var updatedScryfallSet *ScryfallSet
 
 	update := bson.M{
@@ -205,8 +182,7 @@ func (collection *ScryfallSetCollection) Update(scryfallset *ScryfallSet) (*Scry
 }
 
 // DeleteScryfallSetByID Deletes an scryfallset by its id from the db
-func (collection *ScryfallSetCollection) DeleteScryfallSetByID(id string) error {
-	ctx := collection.Context
+func (collection *ScryfallSetCollection) DeleteScryfallSetByID(ctx context.Context, id string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		log.Printf("Failed parsing id %v", err)
@@ -225,8 +201,7 @@ func (collection *ScryfallSetCollection) DeleteScryfallSetByID(id string) error
 }
 
 // ReplaceAll first delete all and then create many cards in a mongo db
-func (collection *ScryfallSetCollection) ReplaceAll(cards []*ScryfallSet) (*[]string, error) {
-	ctx := collection.Context
+func (collection *ScryfallSetCollection) ReplaceAll(ctx context.Context, cards []*ScryfallSet) (*[]string, error) {
 
 	err := collection.Collection.Drop(ctx)
 	if err != nil {
@@ -13,9 +13,10 @@ func Configure() *gin.Engine {
 		AllowOriginFunc: func(origin string) bool {
 			return true //return strings.Contains(origin, "localhost")
 		},
-		AllowMethods: []string{"GET", "POST", "DELETE"},
-		AllowHeaders: []string{"Authorization", "Content-Type"},
-		MaxAge:       12 * time.Hour,
+		AllowMethods:  []string{"GET", "POST", "DELETE"},
+		AllowHeaders:  []string{"Authorization", "Content-Type"},
+		ExposeHeaders: []string{"X-Job-ID"},
+		MaxAge:        12 * time.Hour,
 	}))
 
 	return r
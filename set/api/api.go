@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"strconv"
@@ -8,32 +9,32 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/maedu/mtg-cards/job"
+	jobDB "github.com/maedu/mtg-cards/job/db"
 	"github.com/maedu/mtg-cards/scryfall/client"
 	scryfallDB "github.com/maedu/mtg-cards/scryfall/db"
 	"github.com/maedu/mtg-cards/set/db"
+	"github.com/maedu/mtg-cards/user/auth"
+	userDB "github.com/maedu/mtg-cards/user/db"
 )
 
 // Setup Setup REST API
 func Setup(r *gin.Engine) {
 	r.GET("/api/sets", handleGetSets)
-	r.GET("/api/sets/update", handleUpdateSets)
-	r.GET("/api/sets/transform", handleTransformSets)
+	r.POST("/api/sets/update", auth.RequireRole(userDB.AdminRole), handleUpdateSets)
+	r.POST("/api/sets/transform", auth.RequireRole(userDB.AdminRole), handleTransformSets)
 
 }
 
 func handleGetSet(c *gin.Context) {
+	ctx := c.Request.Context()
 
 	var err error
 
 	set := c.Query("set")
-	collection, err := db.GetSetCollection()
-	if err != nil {
-		c.Error(err)
-		return
-	}
-	defer collection.Disconnect()
+	collection := db.GetSetStore()
 
-	loadedSets, err := collection.GetSetsBySetName(set)
+	loadedSets, err := collection.GetSetsBySetName(ctx, set)
 	if err != nil {
 		c.Error(err)
 		return
@@ -48,17 +49,13 @@ func handleGetSet(c *gin.Context) {
 }
 
 func handleGetSets(c *gin.Context) {
+	ctx := c.Request.Context()
 
 	var err error
 
-	collection, err := db.GetSetCollection()
-	if err != nil {
-		c.Error(err)
-		return
-	}
-	defer collection.Disconnect()
+	collection := db.GetSetStore()
 
-	loadedSets, err := collection.GetAllSets()
+	loadedSets, err := collection.GetAllSets(ctx)
 	//loadedSets, err := collection.FindSets(filterByFullText)
 	if err != nil {
 		c.Error(err)
@@ -67,42 +64,51 @@ func handleGetSets(c *gin.Context) {
 	c.JSON(http.StatusOK, loadedSets)
 }
 
-func handleUpdateSets(c *gin.Context) {
-
-	err := client.UpdateSets()
-	if err != nil {
-		c.Error(err)
-		return
-	}
+// Job types and the key of all jobs replacing the sets, only one of them runs at a time
+const (
+	UpdateSetsJob    = "update_sets"
+	TransformSetsJob = "transform_sets"
+	setsJobKey       = "sets"
+)
 
-	err = transformSets()
+func handleUpdateSets(c *gin.Context) {
+	updateJob, err := EnqueueUpdateSets()
 	if err != nil {
 		c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, nil)
-
+	c.JSON(http.StatusAccepted, updateJob)
 }
 
 func handleTransformSets(c *gin.Context) {
-
-	err := transformSets()
+	transformJob, err := job.Enqueue(TransformSetsJob, setsJobKey, func(ctx context.Context, report func(progress jobDB.Progress)) error {
+		return transformSets(ctx)
+	})
 	if err != nil {
 		c.Error(err)
 		return
 	}
+	c.JSON(http.StatusAccepted, transformJob)
+}
 
-	c.JSON(http.StatusOK, nil)
-
+// EnqueueUpdateSets adds a job importing the scryfall sets and transforming them
+func EnqueueUpdateSets() (*jobDB.Job, error) {
+	return job.Enqueue(UpdateSetsJob, setsJobKey, func(ctx context.Context, report func(progress jobDB.Progress)) error {
+		err := client.UpdateSets(ctx)
+		if err != nil {
+			return err
+		}
+		report(jobDB.Progress{Message: "Transforming sets"})
+		return transformSets(ctx)
+	})
 }
 
-func transformSets() error {
+func transformSets(ctx context.Context) error {
 
 	log.Println("Get scryfallCollection")
 	scryfallCollection := scryfallDB.GetScryfallSetCollection()
-	defer scryfallCollection.Disconnect()
 
-	var loadedScryfallSets, err = scryfallCollection.GetAllScryfallSets()
+	var loadedScryfallSets, err = scryfallCollection.GetAllScryfallSets(ctx)
 
 	if err != nil {
 		return err
@@ -121,12 +127,8 @@ func transformSets() error {
 	}
 
 	log.Println("Get sets collection")
-	collection, err := db.GetSetCollection()
-	if err != nil {
-		return err
-	}
-	defer collection.Disconnect()
-	_, err = collection.ReplaceAll(sets)
+	collection := db.GetSetStore()
+	_, err = collection.ReplaceAll(ctx, sets)
 
 	return err
 }
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/maedu/mtg-cards/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemorySetStore is a SetStore keeping all sets in memory, used by tests and when running without MongoDB
+type MemorySetStore struct {
+	mutex sync.RWMutex
+	sets  []*Set
+}
+
+// NewMemorySetStore creates a MemorySetStore containing the given sets
+func NewMemorySetStore(sets ...*Set) *MemorySetStore {
+	store := &MemorySetStore{}
+	store.ReplaceAll(context.Background(), sets)
+	return store
+}
+
+// GetAllSets returns copies of all sets
+func (store *MemorySetStore) GetAllSets(ctx context.Context) ([]*Set, error) {
+	return store.find(func(set *Set) bool { return true }), nil
+}
+
+// GetSetsPaginated returns a page of the sets, sorted by name, which contain the text in their name or code
+func (store *MemorySetStore) GetSetsPaginated(ctx context.Context, limit int64, page int64, filterByFullText string) (PaginatedResult, error) {
+	text := strings.ToLower(strings.TrimSpace(filterByFullText))
+	sets := store.find(func(set *Set) bool {
+		return strings.Contains(strings.ToLower(set.Name), text) || strings.ToLower(set.Code) == text
+	})
+	sort.SliceStable(sets, func(i, j int) bool { return sets[i].Name < sets[j].Name })
+
+	if limit < 1 {
+		limit = 10
+	}
+	if page < 1 {
+		page = 1
+	}
+	total := int64(len(sets))
+	data := db.PaginationData(total, page, limit)
+
+	start := (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	return PaginatedResult{
+		Sets:       sets[start:end],
+		Pagination: data,
+	}, nil
+}
+
+// GetSetsBySetName returns the sets with the name
+func (store *MemorySetStore) GetSetsBySetName(ctx context.Context, setName string) ([]*Set, error) {
+	return store.find(func(set *Set) bool { return set.Name == setName }), nil
+}
+
+// ReplaceAll removes all sets and adds the given ones
+func (store *MemorySetStore) ReplaceAll(ctx context.Context, sets []*Set) (*[]primitive.ObjectID, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.sets = nil
+	oids := []primitive.ObjectID{}
+	for _, set := range sets {
+		set.ID = primitive.NewObjectID()
+		copied := *set
+		store.sets = append(store.sets, &copied)
+		oids = append(oids, set.ID)
+	}
+	return &oids, nil
+}
+
+func (store *MemorySetStore) find(matches func(set *Set) bool) []*Set {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	sets := []*Set{}
+	for _, set := range store.sets {
+		if matches(set) {
+			copied := *set
+			sets = append(sets, &copied)
+		}
+	}
+	return sets
+}
@@ -53,36 +53,19 @@ type Set struct {
 
 // SetCollection ...
 type SetCollection struct {
-	*mongo.Client
 	*mongo.Collection
-	context.Context
-	context.CancelFunc
 }
 
-// GetSetCollection ...
-func GetSetCollection() (SetCollection, error) {
-	client, ctx, cancel := db.GetConnection()
-	db := client.Database(db.GetDatabaseName())
-	collection := db.Collection("sets")
-
-	return SetCollection{
-		Client:     client,
-		Collection: collection,
-		Context:    ctx,
-		CancelFunc: cancel,
-	}, nil
-}
-
-// Disconnect ...
-func (collection *SetCollection) Disconnect() {
-	collection.CancelFunc()
-	collection.Client.Disconnect(collection.Context)
+// NewSetCollection creates the SetCollection using the shared client
+func NewSetCollection(client *mongo.Client) *SetCollection {
+	return &SetCollection{
+		Collection: client.Database(db.GetDatabaseName()).Collection("sets"),
+	}
 }
 
 // GetAllSets Retrives all sets from the db
-func (collection *SetCollection) GetAllSets() ([]*Set, error) {
+func (collection *SetCollection) GetAllSets(ctx context.Context) ([]*Set, error) {
 	var sets []*Set = []*Set{}
-	ctx := collection.Context
 
 	cursor, err := collection.Collection.Find(ctx, bson.D{})
 	if err != nil {
@@ -98,14 +81,14 @@ func (collection *SetCollection) GetAllSets() ([]*Set, error) {
 }
 
 // GetSetsPaginated Retrives all sets from the db
-func (collection *SetCollection) GetSetsPaginated(limit int64, page int64, filterByFullText string) (PaginatedResult, error) {
+func (collection *SetCollection) GetSetsPaginated(ctx context.Context, limit int64, page int64, filterByFullText string) (PaginatedResult, error) {
 	var sets []*Set = []*Set{}
 	filter := bson.M{}
 
 	trimmedFilterByFullText := strings.TrimSpace(filterByFullText)
 	projection := bson.M{}
 	var sort interface{} = bson.D{
-		{"name", 1},
+		{Key: "name", Value: 1},
 	}
 	if trimmedFilterByFullText != "" {
 		filter = bson.M{
@@ -126,30 +109,30 @@ func (collection *SetCollection) GetSetsPaginated(limit int64, page int64, filte
 			},
 		}
 	}
-	paginatedData, err := pagination.New(collection.Collection).Limit(limit).Page(page).Filter(filter).Select(projection).Sort(sort).Find()
+	query := db.PaginatedQuery{Limit: limit, Page: page, Filter: filter, Projection: projection, Sort: sort}
+	data, paginationData, err := query.Find(ctx, collection.Collection)
 	if err != nil {
 		return PaginatedResult{}, err
 	}
 
-	for _, raw := range paginatedData.Data {
+	for _, raw := range data {
 		var set *Set
 		if marshallErr := bson.Unmarshal(raw, &set); marshallErr != nil {
-			log.Printf("Failed marshalling: %v", err)
-			return PaginatedResult{}, err
+			log.Printf("Failed marshalling: %v", marshallErr)
+			return PaginatedResult{}, marshallErr
 		}
 		sets = append(sets, set)
 
 	}
 	return PaginatedResult{
 		Sets:       sets,
-		Pagination: paginatedData.Pagination,
+		Pagination: paginationData,
 	}, nil
 }
 
 // GetSetByID Retrives a set by its id from the db
-func (collection *SetCollection) GetSetByID(id string) (*Set, error) {
+func (collection *SetCollection) GetSetByID(ctx context.Context, id string) (*Set, error) {
 	var set *Set
-	ctx := collection.Context
 
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -170,8 +153,7 @@ func (collection *SetCollection) GetSetByID(id string) (*Set, error) {
 }
 
 // GetSetByIDs Retrives sets by their ids from the db
-func (collection *SetCollection) GetSetByIDs(ids *[]primitive.ObjectID) (*[]Set, error) {
-	ctx := collection.Context
+func (collection *SetCollection) GetSetByIDs(ctx context.Context, ids *[]primitive.ObjectID) (*[]Set, error) {
 	var sets []Set = []Set{}
 	cursor, err := collection.Collection.Find(ctx, bson.D{bson.E{Key: "_id", Value: bson.M{"$in": ids}}})
 	if err != nil {
@@ -188,9 +170,8 @@ func (collection *SetCollection) GetSetByIDs(ids *[]primitive.ObjectID) (*[]Set,
 }
 
 // GetSetByName retrives a set by its key from the db
-func (collection *SetCollection) GetSetByName(name string) (*Set, error) {
+func (collection *SetCollection) GetSetByName(ctx context.Context, name string) (*Set, error) {
 	var set *Set
-	ctx := collection.Context
 
 	filter := bson.M{"$or": bson.A{
 		bson.M{"name": name},
@@ -215,10 +196,9 @@ func (collection *SetCollection) GetSetByName(name string) (*Set, error) {
 }
 
 // GetSetsByNames retrieves sets by their names from the db
-func (collection *SetCollection) GetSetsByNames(names []string) ([]*Set, error) {
+func (collection *SetCollection) GetSetsByNames(ctx context.Context, names []string) ([]*Set, error) {
 	log.Println("find sets by names")
 	var sets []*Set = []*Set{}
-	ctx := collection.Context
 
 	inFilter := bson.M{"$in": names}
 
@@ -241,9 +221,8 @@ func (collection *SetCollection) GetSetsByNames(names []string) ([]*Set, error)
 }
 
 // GetSetsBySetName retrieves a set by its set name from the db
-func (collection *SetCollection) GetSetsBySetName(setName string) ([]*Set, error) {
+func (collection *SetCollection) GetSetsBySetName(ctx context.Context, setName string) ([]*Set, error) {
 	var sets []*Set = []*Set{}
-	ctx := collection.Context
 
 	cursor, err := collection.Collection.Find(ctx, bson.D{bson.E{Key: "set_name", Value: setName}})
 	if err != nil {
@@ -259,8 +238,7 @@ func (collection *SetCollection) GetSetsBySetName(setName string) ([]*Set, error
 }
 
 // Create creating a set in a mongo
-func (collection *SetCollection) Create(set *Set) (primitive.ObjectID, error) {
-	ctx := collection.Context
+func (collection *SetCollection) Create(ctx context.Context, set *Set) (primitive.ObjectID, error) {
 	set.ID = primitive.NewObjectID()
 
 	result, err := collection.Collection.InsertOne(ctx, set)
@@ -273,8 +251,7 @@ func (collection *SetCollection) Create(set *Set) (primitive.ObjectID, error) {
 }
 
 // CreateMany creating many sets in a mongo
-func (collection *SetCollection) CreateMany(sets []*Set) (*[]string, error) {
-	ctx := collection.Context
+func (collection *SetCollection) CreateMany(ctx context.Context, sets []*Set) (*[]string, error) {
 
 	var ui []interface{}
 	for _, t := range sets {
@@ -297,8 +274,7 @@ func (collection *SetCollection) CreateMany(sets []*Set) (*[]string, error) {
 }
 
 //Update updating an existing set in a mongo
-func (collection *SetCollection) Update(set *Set) (*Set, error) {
-	ctx := collection.Context
+func (collection *SetCollection) Update(ctx context.Context, set *Set) (*Set, error) {
 	var updatedSet *Set
 
 	update := bson.M{
@@ -321,8 +297,7 @@ func (collection *SetCollection) Update(set *Set) (*Set, error) {
 }
 
 // DeleteSetByID Deletes an set by its id from the db
-func (collection *SetCollection) DeleteSetByID(id string) error {
-	ctx := collection.Context
+func (collection *SetCollection) DeleteSetByID(ctx context.Context, id string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		log.Printf("Failed parsing id %v", err)
@@ -341,8 +316,7 @@ func (collection *SetCollection) DeleteSetByID(id string) error {
 }
 
 // ReplaceAll first delete all and then create many sets in a mongo db
-func (collection *SetCollection) ReplaceAll(sets []*Set) (*[]primitive.ObjectID, error) {
-	ctx := collection.Context
+func (collection *SetCollection) ReplaceAll(ctx context.Context, sets []*Set) (*[]primitive.ObjectID, error) {
 
 	err := collection.Collection.Drop(ctx)
 	if err != nil {
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SetStore is the storage of the transformed sets used by the handlers
+type SetStore interface {
+	GetAllSets(ctx context.Context) ([]*Set, error)
+	GetSetsPaginated(ctx context.Context, limit int64, page int64, filterByFullText string) (PaginatedResult, error)
+	GetSetsBySetName(ctx context.Context, setName string) ([]*Set, error)
+	ReplaceAll(ctx context.Context, sets []*Set) (*[]primitive.ObjectID, error)
+}
+
+var setStore SetStore
+
+// GetSetStore returns the SetStore configured at startup with UseSetStore
+func GetSetStore() SetStore {
+	return setStore
+}
+
+// UseSetStore sets the SetStore returned by GetSetStore, e.g. a SetCollection or a MemorySetStore
+func UseSetStore(store SetStore) {
+	setStore = store
+}
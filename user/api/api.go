@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -17,26 +18,25 @@ var usernamePattern, _ = regexp.Compile("^[a-zA-Z0-9-]+$")
 func Setup(r *gin.Engine) {
 	r.GET("/api/user", handleGetUser)
 	r.POST("/api/user/rename/:newName", handleRenameUser)
-	r.GET("/api/user/cards", handleGetCards)
+	r.GET("/api/user/cards", auth.RequireScope(db.ReadCollection), handleGetCards)
+	r.GET("/api/user/keys", auth.RequireScope(db.ManageAPIKeys), handleGetAPIKeys)
+	r.POST("/api/user/keys", auth.RequireScope(db.ManageAPIKeys), handleCreateAPIKey)
+	r.DELETE("/api/user/keys/:id", auth.RequireScope(db.ManageAPIKeys), handleRevokeAPIKey)
 }
 
 func handleGetUser(c *gin.Context) {
+	ctx := c.Request.Context()
 	if userID, ok := auth.GetUserIDFromAccessToken(c, true); ok {
-		collection, err := db.GetUserCollection()
-		defer collection.Disconnect()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, err)
-			return
-		}
+		collection := db.GetUserStore()
 
-		user, err := collection.GetUserByUserID(userID)
+		user, err := collection.GetUserByUserID(ctx, userID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, err)
 			return
 		}
 
 		if user == nil {
-			userName, err := calcUserName(userID, &collection)
+			userName, err := calcUserName(ctx, userID, collection)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, err)
 				return
@@ -45,7 +45,7 @@ func handleGetUser(c *gin.Context) {
 				UserID:   userID,
 				UserName: userName,
 			}
-			_, err = collection.Create(user)
+			_, err = collection.Create(ctx, user)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, err)
 				return
@@ -59,14 +59,10 @@ func handleGetUser(c *gin.Context) {
 }
 
 func handleRenameUser(c *gin.Context) {
+	ctx := c.Request.Context()
 	if userID, ok := auth.GetUserIDFromAccessToken(c, true); ok {
 
-		collection, err := db.GetUserCollection()
-		defer collection.Disconnect()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, err)
-			return
-		}
+		collection := db.GetUserStore()
 
 		userName := c.Param("newName")
 		if !validUsername(userName) {
@@ -74,7 +70,7 @@ func handleRenameUser(c *gin.Context) {
 			return
 		}
 
-		available, err := usernameAvailable(userName, userID, &collection)
+		available, err := usernameAvailable(ctx, userName, userID, collection)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, err)
 			return
@@ -84,7 +80,7 @@ func handleRenameUser(c *gin.Context) {
 			return
 		}
 
-		user, err := collection.GetUserByUserID(userID)
+		user, err := collection.GetUserByUserID(ctx, userID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, err)
 			return
@@ -95,14 +91,14 @@ func handleRenameUser(c *gin.Context) {
 				UserID:   userID,
 				UserName: userName,
 			}
-			_, err = collection.Create(user)
+			_, err = collection.Create(ctx, user)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, err)
 				return
 			}
 		} else {
 			user.UserName = userName
-			user, err = collection.Update(user)
+			user, err = collection.Update(ctx, user)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, err)
 				return
@@ -115,11 +111,11 @@ func handleRenameUser(c *gin.Context) {
 	c.JSON(http.StatusUnauthorized, nil)
 }
 
-func calcUserName(userID string, collection *db.UserCollection) (string, error) {
+func calcUserName(ctx context.Context, userID string, collection db.UserStore) (string, error) {
 	var userName string
 	for {
 		userName = fmt.Sprintf("user-%s", util.RandomString(5))
-		available, err := usernameAvailable(userName, userID, collection)
+		available, err := usernameAvailable(ctx, userName, userID, collection)
 		if err != nil {
 			return "", err
 		}
@@ -137,8 +133,8 @@ func validUsername(userName string) bool {
 	return usernamePattern.MatchString(userName)
 }
 
-func usernameAvailable(userName string, userID string, collection *db.UserCollection) (bool, error) {
-	user, err := collection.GetUserByUserName(userName)
+func usernameAvailable(ctx context.Context, userName string, userID string, collection db.UserStore) (bool, error) {
+	user, err := collection.GetUserByUserName(ctx, userName)
 	if err != nil {
 		return true, err
 	}
@@ -153,14 +149,10 @@ func usernameAvailable(userName string, userID string, collection *db.UserCollec
 }
 
 func handleGetCards(c *gin.Context) {
+	ctx := c.Request.Context()
 	if userID, ok := auth.GetUserIDFromAccessToken(c, true); ok {
-		collection, err := db.GetUserCardCollection()
-		defer collection.Disconnect()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, err)
-			return
-		}
-		userCards, err := collection.GetUserCardsByUserID(userID)
+		collection := db.GetUserCardStore()
+		userCards, err := collection.GetUserCardsByUserID(ctx, userID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, err)
 			return
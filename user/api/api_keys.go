@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maedu/mtg-cards/user/auth"
+	"github.com/maedu/mtg-cards/user/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateAPIKeyRequest is the name and the scopes of a new API key, a key without scopes has full access
+type CreateAPIKeyRequest struct {
+	Name   string     `json:"name"`
+	Scopes []db.Scope `json:"scopes"`
+}
+
+// CreateAPIKeyResponse contains the key, it is only returned once when it is created
+type CreateAPIKeyResponse struct {
+	Key    string     `json:"key"`
+	APIKey *db.APIKey `json:"apiKey"`
+}
+
+func handleCreateAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	if userID, ok := auth.GetUserIDFromAccessToken(c, true); ok {
+		var request CreateAPIKeyRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, err.Error())
+			return
+		}
+		request.Name = strings.TrimSpace(request.Name)
+		if request.Name == "" {
+			c.JSON(http.StatusBadRequest, "Name is required")
+			return
+		}
+		for _, scope := range request.Scopes {
+			if _, ok := db.Scopes[scope]; !ok {
+				c.JSON(http.StatusBadRequest, fmt.Sprintf("Unknown scope %s", scope))
+				return
+			}
+		}
+
+		key, hash, err := auth.GenerateAPIKey()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, err)
+			return
+		}
+		apiKey := &db.APIKey{
+			UserID:    userID,
+			Name:      request.Name,
+			Prefix:    auth.ShownPrefix(key),
+			Hash:      hash,
+			Scopes:    request.Scopes,
+			CreatedAt: time.Now(),
+		}
+		_, err = db.GetAPIKeyStore().Create(ctx, apiKey)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, CreateAPIKeyResponse{Key: key, APIKey: apiKey})
+	}
+}
+
+func handleGetAPIKeys(c *gin.Context) {
+	ctx := c.Request.Context()
+	if userID, ok := auth.GetUserIDFromAccessToken(c, true); ok {
+		apiKeys, err := db.GetAPIKeyStore().GetAPIKeysByUserID(ctx, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, err)
+			return
+		}
+		c.JSON(http.StatusOK, apiKeys)
+	}
+}
+
+func handleRevokeAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	if userID, ok := auth.GetUserIDFromAccessToken(c, true); ok {
+		id, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, "API key not found")
+			return
+		}
+
+		revoked, err := db.GetAPIKeyStore().Revoke(ctx, userID, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, err)
+			return
+		}
+		if !revoked {
+			c.JSON(http.StatusNotFound, "API key not found")
+			return
+		}
+		c.JSON(http.StatusOK, nil)
+	}
+}
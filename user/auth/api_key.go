@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maedu/mtg-cards/user/db"
+)
+
+const (
+	// APIKeyPrefix starts every API key, so they can be told apart from OAuth tokens
+	APIKeyPrefix = "mtg_"
+	// shownPrefixLength is the length of the start of a key which is stored to recognize it in the list of keys
+	shownPrefixLength = len(APIKeyPrefix) + 6
+
+	requiredScopeKey = "requiredScope"
+)
+
+// GenerateAPIKey returns a new random API key and its hash
+func GenerateAPIKey() (key string, hash string, err error) {
+	random := make([]byte, 32)
+	_, err = rand.Read(random)
+	if err != nil {
+		return "", "", err
+	}
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hash of the key which is stored instead of the key
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// ShownPrefix returns the start of the key which is shown to recognize it
+func ShownPrefix(key string) string {
+	return key[:shownPrefixLength]
+}
+
+// RequireScope returns a middleware declaring the scope an API key needs for the route.
+// OAuth tokens are not limited by scopes, API keys are only accepted for routes with a scope they have.
+func RequireScope(scope db.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(requiredScopeKey, scope)
+		c.Next()
+	}
+}
+
+func isAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// authenticateAPIKey returns the user ID of the API key if it may be used for the scope
+func authenticateAPIKey(ctx context.Context, key string, scope db.Scope) (string, error) {
+	apiKey, err := db.GetAPIKeyStore().GetAPIKeyByHash(ctx, HashAPIKey(key))
+	if err != nil {
+		return "", err
+	}
+	if apiKey == nil || apiKey.RevokedAt != nil {
+		return "", errors.New("unknown API key")
+	}
+	if !apiKey.Allows(scope) {
+		return "", errors.New("API key has not the required scope")
+	}
+	return apiKey.UserID, nil
+}
+
+func requiredScope(c *gin.Context) db.Scope {
+	scope, _ := c.Get(requiredScopeKey)
+	requiredScope, _ := scope.(db.Scope)
+	return requiredScope
+}
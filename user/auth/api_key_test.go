@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maedu/mtg-cards/user/db"
+)
+
+func createAPIKey(t *testing.T, store db.APIKeyStore, scopes []db.Scope, revoked bool) string {
+	key, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	apiKey := &db.APIKey{UserID: "user@example.com", Name: "test", Prefix: ShownPrefix(key), Hash: hash, Scopes: scopes, CreatedAt: time.Now()}
+	_, err = store.Create(context.Background(), apiKey)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if revoked {
+		_, err = store.Revoke(context.Background(), apiKey.UserID, apiKey.ID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	return key
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	store := db.NewMemoryAPIKeyStore()
+	db.UseAPIKeyStore(store)
+
+	fullAccess := createAPIKey(t, store, nil, false)
+	readDecks := createAPIKey(t, store, []db.Scope{db.ReadDecks}, false)
+	writeDecks := createAPIKey(t, store, []db.Scope{db.WriteDecks}, false)
+	revoked := createAPIKey(t, store, nil, true)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	handler := func(c *gin.Context) {
+		if userID, ok := GetUserIDFromAccessToken(c, true); ok {
+			c.JSON(http.StatusOK, userID)
+		}
+	}
+	r.GET("/decks", RequireScope(db.ReadDecks), handler)
+	r.POST("/decks", RequireScope(db.WriteDecks), handler)
+	r.GET("/keys", RequireScope(db.ManageAPIKeys), handler)
+	r.GET("/user", handler)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		key    string
+		method string
+		path   string
+		status int
+	}{
+		{"full access reads decks", fullAccess, http.MethodGet, "/decks", http.StatusOK},
+		{"full access writes decks", fullAccess, http.MethodPost, "/decks", http.StatusOK},
+		{"full access without scope", fullAccess, http.MethodGet, "/user", http.StatusForbidden},
+		{"full access manages keys", fullAccess, http.MethodGet, "/keys", http.StatusForbidden},
+		{"read decks reads decks", readDecks, http.MethodGet, "/decks", http.StatusOK},
+		{"read decks writes decks", readDecks, http.MethodPost, "/decks", http.StatusForbidden},
+		{"read decks without scope", readDecks, http.MethodGet, "/user", http.StatusForbidden},
+		{"write decks reads decks", writeDecks, http.MethodGet, "/decks", http.StatusOK},
+		{"write decks writes decks", writeDecks, http.MethodPost, "/decks", http.StatusOK},
+		{"revoked", revoked, http.MethodGet, "/decks", http.StatusForbidden},
+		{"unknown", APIKeyPrefix + "unknown", http.MethodGet, "/decks", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(test.method, ts.URL+test.path, nil)
+			req.Header.Set("Authorization", "Bearer "+test.key)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			res.Body.Close()
+			if res.StatusCode != test.status {
+				t.Errorf("expected status %d, got %s", test.status, res.Status)
+			}
+		})
+	}
+}
@@ -4,26 +4,34 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"google.golang.org/api/oauth2/v1"
 )
 
-var httpClient = &http.Client{}
+var httpClient = &http.Client{Timeout: 10 * time.Second}
 
+// GetUserIDFromAccessToken verifies the bearer token of the request and returns its user ID.
+// API keys are verified against the scope required by the route, other tokens with the configured Authenticator.
 func GetUserIDFromAccessToken(c *gin.Context, sendErrorStatus bool) (string, bool) {
 
 	if token, ok := getAccessToken(c); ok {
-		info, err := verifyAccessToken(token)
+		var userID string
+		var err error
+		if isAPIKey(token) {
+			userID, err = authenticateAPIKey(c.Request.Context(), token, requiredScope(c))
+		} else {
+			userID, err = GetAuthenticator().Authenticate(c.Request.Context(), token)
+		}
 		if err != nil {
-
+			log.Printf("Token verification failed: %v\n", err)
 			if sendErrorStatus {
 				c.JSON(http.StatusForbidden, "Token verification failed")
 			}
 			return "", false
 		}
 
-		return info.Email, true
+		return userID, true
 
 	}
 	if sendErrorStatus {
@@ -34,7 +42,6 @@ func GetUserIDFromAccessToken(c *gin.Context, sendErrorStatus bool) (string, boo
 
 func getAccessToken(c *gin.Context) (string, bool) {
 	authHeader := c.Request.Header.Get("Authorization")
-	log.Printf("Auth Header: %s\n", authHeader)
 	if authHeader != "" {
 		splitToken := strings.Split(authHeader, "Bearer ")
 		if len(splitToken) == 2 {
@@ -45,14 +52,3 @@ func getAccessToken(c *gin.Context) (string, bool) {
 
 	return "", false
 }
-
-func verifyAccessToken(accessToken string) (*oauth2.Tokeninfo, error) {
-	oauth2Service, err := oauth2.New(httpClient)
-	tokenInfoCall := oauth2Service.Tokeninfo()
-	tokenInfoCall.AccessToken(accessToken)
-	tokenInfo, err := tokenInfoCall.Do()
-	if err != nil {
-		return nil, err
-	}
-	return tokenInfo, nil
-}
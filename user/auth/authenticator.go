@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	env "bitbucket.org/spinnerweb/accounting_common/env"
+)
+
+// Authenticator verifies an access token and returns the ID of its user
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (string, error)
+}
+
+var authenticator Authenticator = NewCachingAuthenticator(NewGoogleAuthenticator(nil), defaultCacheTTL)
+
+// GetAuthenticator returns the Authenticator configured with UseAuthenticator, by default the Google one
+func GetAuthenticator() Authenticator {
+	return authenticator
+}
+
+// UseAuthenticator sets the Authenticator used by GetUserIDFromAccessToken
+func UseAuthenticator(newAuthenticator Authenticator) {
+	authenticator = newAuthenticator
+}
+
+// NewAuthenticatorFromEnv creates the Authenticator configured with AUTH_PROVIDER:
+//   - google (default): Google ID tokens of the GOOGLE_CLIENT_IDS (separated by ",") and Google access tokens
+//   - oidc: ID tokens of OIDC_ISSUER for OIDC_AUDIENCE, the user ID is the claim OIDC_USER_ID_CLAIM (default email)
+//   - static: the tokens of AUTH_STATIC_TOKENS like "token1=user1@example.com,token2=user2@example.com", for development only
+//
+// Verified tokens are cached for AUTH_CACHE_TTL (default 5m, 0 disables the cache).
+func NewAuthenticatorFromEnv() (Authenticator, error) {
+	var provider Authenticator
+	switch env.GetEnv("AUTH_PROVIDER", "google") {
+	case "google":
+		provider = NewGoogleAuthenticator(splitList(env.GetEnv("GOOGLE_CLIENT_IDS", "")))
+	case "oidc":
+		issuer := env.GetEnv("OIDC_ISSUER", "")
+		audience := env.GetEnv("OIDC_AUDIENCE", "")
+		if issuer == "" || audience == "" {
+			return nil, fmt.Errorf("OIDC_ISSUER and OIDC_AUDIENCE are required for the oidc provider")
+		}
+		provider = NewOIDCAuthenticator(issuer, audience, env.GetEnv("OIDC_USER_ID_CLAIM", "email"))
+	case "static":
+		tokens := map[string]string{}
+		for _, entry := range splitList(env.GetEnv("AUTH_STATIC_TOKENS", "")) {
+			parts := strings.SplitN(entry, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("AUTH_STATIC_TOKENS entry %q is not token=userID", entry)
+			}
+			tokens[parts[0]] = parts[1]
+		}
+		return StaticAuthenticator(tokens), nil
+	default:
+		return nil, fmt.Errorf("unknown AUTH_PROVIDER %q", env.GetEnv("AUTH_PROVIDER", ""))
+	}
+
+	ttl, err := time.ParseDuration(env.GetEnv("AUTH_CACHE_TTL", defaultCacheTTL.String()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTH_CACHE_TTL: %w", err)
+	}
+	if ttl <= 0 {
+		return provider, nil
+	}
+	return NewCachingAuthenticator(provider, ttl), nil
+}
+
+func splitList(list string) []string {
+	values := []string{}
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// StaticAuthenticator maps fixed tokens to user IDs, used for development and tests
+type StaticAuthenticator map[string]string
+
+// Authenticate returns the user ID of the token
+func (tokens StaticAuthenticator) Authenticate(ctx context.Context, token string) (string, error) {
+	if userID, ok := tokens[token]; ok {
+		return userID, nil
+	}
+	return "", fmt.Errorf("unknown token")
+}
+
+const (
+	defaultCacheTTL = 5 * time.Minute
+	maxCacheSize    = 10000
+)
+
+// expiringAuthenticator is an Authenticator which knows until when a token is valid
+type expiringAuthenticator interface {
+	authenticateUntil(ctx context.Context, token string) (string, time.Time, error)
+}
+
+type cacheEntry struct {
+	userID    string
+	expiresAt time.Time
+}
+
+// CachingAuthenticator remembers verified tokens for a short time, so not every request needs to verify the token again.
+// Failed verifications are not cached.
+type CachingAuthenticator struct {
+	authenticator Authenticator
+	ttl           time.Duration
+	mutex         sync.Mutex
+	entries       map[[sha256.Size]byte]cacheEntry
+}
+
+// NewCachingAuthenticator creates a CachingAuthenticator caching the tokens verified by the authenticator for ttl
+func NewCachingAuthenticator(authenticator Authenticator, ttl time.Duration) *CachingAuthenticator {
+	return &CachingAuthenticator{
+		authenticator: authenticator,
+		ttl:           ttl,
+		entries:       map[[sha256.Size]byte]cacheEntry{},
+	}
+}
+
+// Authenticate returns the cached user ID of the token, or verifies it with the wrapped authenticator
+func (cache *CachingAuthenticator) Authenticate(ctx context.Context, token string) (string, error) {
+	// Only the hash of the token is kept in memory
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+
+	cache.mutex.Lock()
+	entry, ok := cache.entries[key]
+	cache.mutex.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.userID, nil
+	}
+
+	expiresAt := now.Add(cache.ttl)
+	var userID string
+	var err error
+	if expiring, ok := cache.authenticator.(expiringAuthenticator); ok {
+		var tokenExpiry time.Time
+		userID, tokenExpiry, err = expiring.authenticateUntil(ctx, token)
+		if !tokenExpiry.IsZero() && tokenExpiry.Before(expiresAt) {
+			expiresAt = tokenExpiry
+		}
+	} else {
+		userID, err = cache.authenticator.Authenticate(ctx, token)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if len(cache.entries) >= maxCacheSize {
+		cache.removeExpired(now)
+	}
+	if len(cache.entries) < maxCacheSize {
+		cache.entries[key] = cacheEntry{userID: userID, expiresAt: expiresAt}
+	}
+	return userID, nil
+}
+
+// removeExpired removes the expired entries, the mutex must be locked
+func (cache *CachingAuthenticator) removeExpired(now time.Time) {
+	for key, entry := range cache.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(cache.entries, key)
+		}
+	}
+}
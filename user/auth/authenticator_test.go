@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testIssuer struct {
+	server     *httptest.Server
+	key        *rsa.PrivateKey
+	keyID      string
+	keyFetches int
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &testIssuer{key: key, keyID: "key-1"}
+	issuer.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"issuer": issuer.server.URL, "jwks_uri": issuer.server.URL + "/keys"})
+		case "/keys":
+			issuer.keyFetches++
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+				"kid": issuer.keyID,
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (issuer *testIssuer) token(t *testing.T, keyID string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": keyID, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, issuer.key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestOIDCAuthenticator(t *testing.T) {
+	issuer := newTestIssuer(t)
+	authenticator := NewOIDCAuthenticator(issuer.server.URL, "mtg-cards", "email")
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":   issuer.server.URL,
+			"aud":   "mtg-cards",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"email": "someone@example.com",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		token  func() string
+		userID string
+	}{
+		{"valid", func() string { return issuer.token(t, issuer.keyID, validClaims()) }, "someone@example.com"},
+		{"audience list", func() string {
+			claims := validClaims()
+			claims["aud"] = []string{"other", "mtg-cards"}
+			return issuer.token(t, issuer.keyID, claims)
+		}, "someone@example.com"},
+		{"expired", func() string {
+			claims := validClaims()
+			claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			return issuer.token(t, issuer.keyID, claims)
+		}, ""},
+		{"other audience", func() string {
+			claims := validClaims()
+			claims["aud"] = "other"
+			return issuer.token(t, issuer.keyID, claims)
+		}, ""},
+		{"other issuer", func() string {
+			claims := validClaims()
+			claims["iss"] = "https://example.com"
+			return issuer.token(t, issuer.keyID, claims)
+		}, ""},
+		{"unknown key", func() string { return issuer.token(t, "key-2", validClaims()) }, ""},
+		{"tampered", func() string { return issuer.token(t, issuer.keyID, validClaims()) + "x" }, ""},
+		{"no JWT", func() string { return "opaque" }, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			userID, err := authenticator.Authenticate(context.Background(), test.token())
+			if test.userID == "" {
+				if err == nil {
+					t.Errorf("expected an error, got user %s", userID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if userID != test.userID {
+				t.Errorf("expected user %s, got %s", test.userID, userID)
+			}
+		})
+	}
+
+	if issuer.keyFetches != 1 {
+		t.Errorf("expected the keys to be fetched once, got %d", issuer.keyFetches)
+	}
+}
+
+type countingAuthenticator struct {
+	calls int
+}
+
+func (authenticator *countingAuthenticator) Authenticate(ctx context.Context, token string) (string, error) {
+	authenticator.calls++
+	return StaticAuthenticator{"valid": "someone@example.com"}.Authenticate(ctx, token)
+}
+
+func TestCachingAuthenticator(t *testing.T) {
+	counting := &countingAuthenticator{}
+	cache := NewCachingAuthenticator(counting, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		userID, err := cache.Authenticate(context.Background(), "valid")
+		if err != nil || userID != "someone@example.com" {
+			t.Fatalf("unexpected result %s, %v", userID, err)
+		}
+		_, err = cache.Authenticate(context.Background(), "invalid")
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+
+	// The valid token is verified once, the invalid one every time
+	if counting.calls != 4 {
+		t.Errorf("expected 4 verifications, got %d", counting.calls)
+	}
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"google.golang.org/api/oauth2/v1"
+	"google.golang.org/api/option"
+)
+
+const googleCertsURL = "https://www.googleapis.com/oauth2/v3/certs"
+
+// GoogleAuthenticator verifies Google ID tokens locally with Google's cached keys,
+// other tokens are verified as access tokens with Google's tokeninfo endpoint. The user ID is the email.
+type GoogleAuthenticator struct {
+	verifier *jwtVerifier
+}
+
+// NewGoogleAuthenticator creates a GoogleAuthenticator accepting the ID tokens issued for the client IDs
+func NewGoogleAuthenticator(clientIDs []string) *GoogleAuthenticator {
+	return &GoogleAuthenticator{
+		verifier: &jwtVerifier{
+			issuers:   []string{"accounts.google.com", "https://accounts.google.com"},
+			audiences: clientIDs,
+			keys: &keySet{uri: func(ctx context.Context) (string, error) {
+				return googleCertsURL, nil
+			}},
+		},
+	}
+}
+
+// Authenticate returns the email of the user of a valid ID or access token
+func (authenticator *GoogleAuthenticator) Authenticate(ctx context.Context, token string) (string, error) {
+	userID, _, err := authenticator.authenticateUntil(ctx, token)
+	return userID, err
+}
+
+func (authenticator *GoogleAuthenticator) authenticateUntil(ctx context.Context, token string) (string, time.Time, error) {
+	if strings.Count(token, ".") != 2 {
+		// Access tokens are opaque, only Google can verify them
+		return verifyAccessToken(ctx, token)
+	}
+	if len(authenticator.verifier.audiences) == 0 {
+		return "", time.Time{}, errors.New("ID tokens require GOOGLE_CLIENT_IDS")
+	}
+
+	claims, err := authenticator.verifier.verify(ctx, token)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if verified, _ := claims["email_verified"].(bool); !verified {
+		return "", time.Time{}, errors.New("email is not verified")
+	}
+	return claims.String("email"), claims.expiry(), nil
+}
+
+func verifyAccessToken(ctx context.Context, accessToken string) (string, time.Time, error) {
+	oauth2Service, err := oauth2.NewService(ctx, option.WithHTTPClient(httpClient))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	tokenInfoCall := oauth2Service.Tokeninfo()
+	tokenInfoCall.AccessToken(accessToken)
+	tokenInfo, err := tokenInfoCall.Context(ctx).Do()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if tokenInfo.Email == "" {
+		return "", time.Time{}, errors.New("token has no email")
+	}
+	return tokenInfo.Email, time.Now().Add(time.Duration(tokenInfo.ExpiresIn) * time.Second), nil
+}
@@ -0,0 +1,290 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// keysTTL is how long the keys of a key set are used before they are fetched again
+	keysTTL = time.Hour
+	// minRefetchInterval limits fetching a key set because of an unknown key id
+	minRefetchInterval = time.Minute
+	// clockSkew is tolerated when checking the expiry of a token
+	clockSkew = time.Minute
+)
+
+// Claims are the claims of a verified JWT
+type Claims map[string]interface{}
+
+// String returns the claim if it is a string
+func (claims Claims) String(name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// keySet are the public keys of a JSON web key set, fetched from its URI and cached
+type keySet struct {
+	uri       func(ctx context.Context) (string, error)
+	mutex     sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (set *keySet) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	key, ok := set.keys[kid]
+	expired := time.Since(set.fetchedAt) > keysTTL
+	// Keys are rotated, so an unknown key id requires fetching the keys again
+	if expired || (!ok && time.Since(set.fetchedAt) > minRefetchInterval) {
+		err := set.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		key, ok = set.keys[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// fetch loads the keys, the mutex must be locked
+func (set *keySet) fetch(ctx context.Context) error {
+	uri, err := set.uri(ctx)
+	if err != nil {
+		return err
+	}
+	var response struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	err = getJSON(ctx, uri, &response)
+	if err != nil {
+		return fmt.Errorf("failed fetching keys: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, webKey := range response.Keys {
+		key, err := webKey.publicKey()
+		if err != nil {
+			// Skip keys of unsupported types
+			continue
+		}
+		keys[webKey.Kid] = key
+	}
+	set.keys = keys
+	set.fetchedAt = time.Now()
+	return nil
+}
+
+func (webKey jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch webKey.Kty {
+	case "RSA":
+		n, err := decodeBigInt(webKey.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(webKey.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if webKey.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", webKey.Crv)
+		}
+		x, err := decodeBigInt(webKey.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(webKey.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", webKey.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
+
+// jwtVerifier verifies the signature, issuer, audience and expiry of JWTs
+type jwtVerifier struct {
+	issuers   []string
+	audiences []string
+	keys      *keySet
+}
+
+// verify returns the claims of the token if it is valid
+func (verifier *jwtVerifier) verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token is not a JWT")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	key, err := verifier.keys.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	err = verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return nil, fmt.Errorf("invalid claims: %w", err)
+	}
+	err = verifier.verifyClaims(claims, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (verifier *jwtVerifier) verifyClaims(claims Claims, now time.Time) error {
+	if !containsString(verifier.issuers, claims.String("iss")) {
+		return fmt.Errorf("unexpected issuer %q", claims.String("iss"))
+	}
+
+	audienceMatches := false
+	switch audience := claims["aud"].(type) {
+	case string:
+		audienceMatches = containsString(verifier.audiences, audience)
+	case []interface{}:
+		for _, value := range audience {
+			if text, ok := value.(string); ok && containsString(verifier.audiences, text) {
+				audienceMatches = true
+			}
+		}
+	}
+	if !audienceMatches {
+		return errors.New("unexpected audience")
+	}
+
+	expiry, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token has no expiry")
+	}
+	if now.Add(-clockSkew).After(time.Unix(int64(expiry), 0)) {
+		return errors.New("token is expired")
+	}
+	if notBefore, ok := claims["nbf"].(float64); ok && now.Add(clockSkew).Before(time.Unix(int64(notBefore), 0)) {
+		return errors.New("token is not valid yet")
+	}
+	return nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	hash := sha256.Sum256([]byte(signed))
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key is not an RSA key")
+		}
+		err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, hash[:], signature)
+		if err != nil {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return errors.New("key is not an EC key")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, hash[:], r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported algorithm %q", alg)
+}
+
+func decodeSegment(segment string, value interface{}) error {
+	bytes, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, value)
+}
+
+// expiry returns the expiry of the claims, the zero time if there is none
+func (claims Claims) expiry() time.Time {
+	if expiry, ok := claims["exp"].(float64); ok {
+		return time.Unix(int64(expiry), 0)
+	}
+	return time.Time{}
+}
+
+func getJSON(ctx context.Context, url string, value interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status not 200 but %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(value)
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
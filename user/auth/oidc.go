@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OIDCAuthenticator verifies ID tokens of an OpenID Connect issuer locally, with the keys of the issuer's discovery document
+type OIDCAuthenticator struct {
+	verifier    *jwtVerifier
+	userIDClaim string
+}
+
+// NewOIDCAuthenticator creates an OIDCAuthenticator accepting the ID tokens of the issuer for the audience.
+// The user ID is the value of the userIDClaim, e.g. email or sub.
+func NewOIDCAuthenticator(issuer string, audience string, userIDClaim string) *OIDCAuthenticator {
+	issuer = strings.TrimSuffix(issuer, "/")
+	discovery := &discovery{url: issuer + "/.well-known/openid-configuration"}
+	return &OIDCAuthenticator{
+		verifier: &jwtVerifier{
+			issuers:   []string{issuer, issuer + "/"},
+			audiences: []string{audience},
+			keys:      &keySet{uri: discovery.loadJWKSURI},
+		},
+		userIDClaim: userIDClaim,
+	}
+}
+
+// Authenticate returns the user ID of a valid ID token
+func (authenticator *OIDCAuthenticator) Authenticate(ctx context.Context, token string) (string, error) {
+	userID, _, err := authenticator.authenticateUntil(ctx, token)
+	return userID, err
+}
+
+func (authenticator *OIDCAuthenticator) authenticateUntil(ctx context.Context, token string) (string, time.Time, error) {
+	claims, err := authenticator.verifier.verify(ctx, token)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	userID := claims.String(authenticator.userIDClaim)
+	if userID == "" {
+		return "", time.Time{}, fmt.Errorf("token has no %s claim", authenticator.userIDClaim)
+	}
+	return userID, claims.expiry(), nil
+}
+
+// discovery loads the jwks_uri of an OpenID Connect discovery document once
+type discovery struct {
+	url     string
+	mutex   sync.Mutex
+	jwksURI string
+}
+
+func (discovery *discovery) loadJWKSURI(ctx context.Context) (string, error) {
+	discovery.mutex.Lock()
+	defer discovery.mutex.Unlock()
+
+	if discovery.jwksURI != "" {
+		return discovery.jwksURI, nil
+	}
+	var document struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	err := getJSON(ctx, discovery.url, &document)
+	if err != nil {
+		return "", fmt.Errorf("failed loading discovery document: %w", err)
+	}
+	if document.JWKSURI == "" {
+		return "", fmt.Errorf("discovery document %s has no jwks_uri", discovery.url)
+	}
+	discovery.jwksURI = document.JWKSURI
+	return discovery.jwksURI, nil
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	env "bitbucket.org/spinnerweb/accounting_common/env"
+	"github.com/gin-gonic/gin"
+	"github.com/maedu/mtg-cards/user/db"
+)
+
+// UserIDKey is the key of the verified user id in the gin context, set by RequireRole
+const UserIDKey = "userID"
+
+// RequireRole returns a middleware which only lets users with the role pass, API keys are never accepted.
+// The users in ADMIN_USER_IDS (separated by ",") have the AdminRole in addition to the roles stored on them.
+func RequireRole(role db.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, ok := getAccessToken(c); ok && isAPIKey(token) {
+			c.AbortWithStatusJSON(http.StatusForbidden, "API keys can't be used for this")
+			return
+		}
+		userID, ok := GetUserIDFromAccessToken(c, true)
+		if !ok {
+			c.Abort()
+			return
+		}
+
+		user, err := db.GetUserStore().GetUserByUserID(c.Request.Context(), userID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, err)
+			return
+		}
+		if user == nil {
+			user = &db.User{UserID: userID}
+		}
+		if isConfiguredAdmin(userID) {
+			user.Roles = append(user.Roles, db.AdminRole)
+		}
+
+		if !user.HasRole(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, "You are not allowed to do this")
+			return
+		}
+		c.Set(UserIDKey, userID)
+		c.Next()
+	}
+}
+
+func isConfiguredAdmin(userID string) bool {
+	for _, adminUserID := range strings.Split(env.GetEnv("ADMIN_USER_IDS", ""), ",") {
+		if strings.TrimSpace(adminUserID) == userID {
+			return true
+		}
+	}
+	return false
+}
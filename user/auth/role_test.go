@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maedu/mtg-cards/user/db"
+)
+
+func setupRoleServer(t *testing.T) *httptest.Server {
+	previous := GetAuthenticator()
+	UseAuthenticator(StaticAuthenticator{
+		"admin":      "admin@example.com",
+		"configured": "configured@example.com",
+		"user":       "user@example.com",
+		"unknown":    "unknown@example.com",
+	})
+	t.Cleanup(func() { UseAuthenticator(previous) })
+
+	db.UseUserStore(db.NewMemoryUserStore(
+		&db.User{UserID: "admin@example.com", UserName: "admin", Roles: []db.Role{db.AdminRole}},
+		&db.User{UserID: "user@example.com", UserName: "user"},
+	))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin", RequireRole(db.AdminRole), func(c *gin.Context) {
+		c.JSON(http.StatusOK, c.GetString(UserIDKey))
+	})
+	return httptest.NewServer(r)
+}
+
+func TestRequireRole(t *testing.T) {
+	ts := setupRoleServer(t)
+	defer ts.Close()
+	os.Setenv("ADMIN_USER_IDS", "configured@example.com")
+	defer os.Unsetenv("ADMIN_USER_IDS")
+
+	unscopedKey, hash, _ := GenerateAPIKey()
+	db.UseAPIKeyStore(db.NewMemoryAPIKeyStore(&db.APIKey{UserID: "admin@example.com", Name: "leaked", Prefix: ShownPrefix(unscopedKey), Hash: hash}))
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"admin", "admin", http.StatusOK},
+		{"configured admin", "configured", http.StatusOK},
+		{"unscoped API key of an admin", unscopedKey, http.StatusForbidden},
+		{"user", "user", http.StatusForbidden},
+		{"unknown user", "unknown", http.StatusForbidden},
+		{"invalid token", "invalid", http.StatusForbidden},
+		{"no token", "", http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, ts.URL+"/admin", nil)
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			res.Body.Close()
+			if res.StatusCode != test.status {
+				t.Errorf("expected status %d, got %s", test.status, res.Status)
+			}
+		})
+	}
+}
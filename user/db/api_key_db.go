@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/maedu/mtg-cards/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Scope limits what an API key can be used for
+type Scope string
+
+const (
+	ReadDecks       Scope = "decks:read"
+	WriteDecks      Scope = "decks:write"
+	ReadCollection  Scope = "collection:read"
+	WriteCollection Scope = "collection:write"
+	// ManageAPIKeys is required to create and revoke API keys, no API key has it
+	ManageAPIKeys Scope = "keys:manage"
+)
+
+// Scopes which can be given to an API key, a write scope includes the read scope
+var Scopes = map[Scope][]Scope{
+	ReadDecks:       {ReadDecks},
+	WriteDecks:      {ReadDecks, WriteDecks},
+	ReadCollection:  {ReadCollection},
+	WriteCollection: {ReadCollection, WriteCollection},
+}
+
+// APIKey is a personal key of a user for scripted access, only the hash of the key is stored
+type APIKey struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	UserID    string             `bson:"user_id" json:"-"`
+	Name      string             `bson:"name" json:"name"`
+	Prefix    string             `bson:"prefix" json:"prefix"`
+	Hash      string             `bson:"hash" json:"-"`
+	Scopes    []Scope            `bson:"scopes" json:"scopes"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+	RevokedAt *time.Time         `bson:"revoked_at,omitempty" json:"revokedAt,omitempty"`
+}
+
+// Allows returns true if the key may be used for the scope.
+// A key without scopes may be used for every scope except managing API keys, no key may be used for routes without a scope.
+func (apiKey *APIKey) Allows(scope Scope) bool {
+	if apiKey.RevokedAt != nil || scope == "" || scope == ManageAPIKeys {
+		return false
+	}
+	if len(apiKey.Scopes) == 0 {
+		return true
+	}
+	for _, keyScope := range apiKey.Scopes {
+		for _, included := range Scopes[keyScope] {
+			if included == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// APIKeyCollection ...
+type APIKeyCollection struct {
+	*mongo.Collection
+}
+
+// NewAPIKeyCollection creates the APIKeyCollection using the shared client
+func NewAPIKeyCollection(client *mongo.Client) *APIKeyCollection {
+	return &APIKeyCollection{
+		Collection: client.Database(db.GetDatabaseName()).Collection("api_keys"),
+	}
+}
+
+// CreateAPIKeyIndexes creates the unique index on the hash used to authenticate and the index used to list the keys of a user
+func CreateAPIKeyIndexes(ctx context.Context, database *mongo.Database) error {
+	models := []mongo.IndexModel{
+		{
+			Keys:    bson.M{"hash": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.M{"user_id": 1},
+		},
+	}
+	_, err := database.Collection("api_keys").Indexes().CreateMany(ctx, models)
+	return err
+}
+
+// GetAPIKeysByUserID retrieves all keys of the user, including the revoked ones
+func (collection *APIKeyCollection) GetAPIKeysByUserID(ctx context.Context, userID string) ([]*APIKey, error) {
+	var apiKeys []*APIKey = []*APIKey{}
+
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := collection.Collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &apiKeys)
+	if err != nil {
+		log.Printf("Failed marshalling %v", err)
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// GetAPIKeyByHash retrieves the key with the hash, nil if there is none
+func (collection *APIKeyCollection) GetAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error) {
+	var apiKey *APIKey
+	err := collection.Collection.FindOne(ctx, bson.M{"hash": hash}).Decode(&apiKey)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Failed marshalling %v", err)
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+// Create creating an api key in a mongo
+func (collection *APIKeyCollection) Create(ctx context.Context, apiKey *APIKey) (primitive.ObjectID, error) {
+	apiKey.ID = primitive.NewObjectID()
+
+	_, err := collection.Collection.InsertOne(ctx, apiKey)
+	if err != nil {
+		log.Printf("Could not create APIKey: %v", err)
+		return primitive.NilObjectID, err
+	}
+	return apiKey.ID, nil
+}
+
+// Revoke marks the key of the user as revoked, it returns false if the user has no such key
+func (collection *APIKeyCollection) Revoke(ctx context.Context, userID string, id primitive.ObjectID) (bool, error) {
+	result, err := collection.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		log.Printf("Could not revoke APIKey: %v", err)
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
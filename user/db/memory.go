@@ -0,0 +1,229 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryUserStore is a UserStore keeping all users in memory, used by tests and when running without MongoDB
+type MemoryUserStore struct {
+	mutex sync.RWMutex
+	users []*User
+}
+
+// NewMemoryUserStore creates a MemoryUserStore containing the given users
+func NewMemoryUserStore(users ...*User) *MemoryUserStore {
+	store := &MemoryUserStore{}
+	for _, user := range users {
+		store.Create(context.Background(), user)
+	}
+	return store
+}
+
+// GetAllUsers returns copies of all users
+func (store *MemoryUserStore) GetAllUsers(ctx context.Context) ([]*User, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	users := []*User{}
+	for _, user := range store.users {
+		users = append(users, copyUser(user))
+	}
+	return users, nil
+}
+
+// GetUserByUserID returns the user with the user id or nil if there is none
+func (store *MemoryUserStore) GetUserByUserID(ctx context.Context, userID string) (*User, error) {
+	return store.findOne(func(user *User) bool { return user.UserID == userID }), nil
+}
+
+// GetUserByUserName returns the user with the user name or nil if there is none
+func (store *MemoryUserStore) GetUserByUserName(ctx context.Context, userName string) (*User, error) {
+	return store.findOne(func(user *User) bool { return user.UserName == userName }), nil
+}
+
+// Create adds a user
+func (store *MemoryUserStore) Create(ctx context.Context, user *User) (primitive.ObjectID, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	user.ID = primitive.NewObjectID()
+	store.users = append(store.users, copyUser(user))
+	return user.ID, nil
+}
+
+// Update replaces the user with the same ID, or adds it if there is none
+func (store *MemoryUserStore) Update(ctx context.Context, user *User) (*User, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for i, storedUser := range store.users {
+		if storedUser.ID == user.ID {
+			store.users[i] = copyUser(user)
+			return copyUser(user), nil
+		}
+	}
+	store.users = append(store.users, copyUser(user))
+	return copyUser(user), nil
+}
+
+func (store *MemoryUserStore) findOne(matches func(user *User) bool) *User {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, user := range store.users {
+		if matches(user) {
+			return copyUser(user)
+		}
+	}
+	return nil
+}
+
+// MemoryUserCardStore is a UserCardStore keeping all user cards in memory, used by tests and when running without MongoDB
+type MemoryUserCardStore struct {
+	mutex     sync.RWMutex
+	userCards []*UserCard
+}
+
+// NewMemoryUserCardStore creates a MemoryUserCardStore containing the given user cards
+func NewMemoryUserCardStore(userCards ...*UserCard) *MemoryUserCardStore {
+	store := &MemoryUserCardStore{}
+	for _, userCard := range userCards {
+		userCard.ID = primitive.NewObjectID().Hex()
+		store.userCards = append(store.userCards, copyUserCard(userCard))
+	}
+	return store
+}
+
+// GetAllUserCards returns copies of all user cards
+func (store *MemoryUserCardStore) GetAllUserCards(ctx context.Context) ([]*UserCard, error) {
+	return store.find(func(userCard *UserCard) bool { return true }), nil
+}
+
+// GetUserCardsByUserID returns the cards collected by the user
+func (store *MemoryUserCardStore) GetUserCardsByUserID(ctx context.Context, userID string) ([]*UserCard, error) {
+	return store.find(func(userCard *UserCard) bool { return userCard.UserID == userID }), nil
+}
+
+// ReplaceAllOfUser removes all cards of the user and adds the given ones
+func (store *MemoryUserCardStore) ReplaceAllOfUser(ctx context.Context, userID string, userCards []*UserCard) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	kept := []*UserCard{}
+	for _, userCard := range store.userCards {
+		if userCard.UserID != userID {
+			kept = append(kept, userCard)
+		}
+	}
+	for _, userCard := range userCards {
+		userCard.ID = primitive.NewObjectID().Hex()
+		kept = append(kept, copyUserCard(userCard))
+	}
+	store.userCards = kept
+	return nil
+}
+
+func (store *MemoryUserCardStore) find(matches func(userCard *UserCard) bool) []*UserCard {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	userCards := []*UserCard{}
+	for _, userCard := range store.userCards {
+		if matches(userCard) {
+			userCards = append(userCards, copyUserCard(userCard))
+		}
+	}
+	return userCards
+}
+
+// copyUser copies the user, so callers can't modify the stored one
+func copyUser(user *User) *User {
+	copied := *user
+	copied.Roles = append([]Role(nil), user.Roles...)
+	return &copied
+}
+
+// copyUserCard copies the user card, so callers can't modify the stored one
+func copyUserCard(userCard *UserCard) *UserCard {
+	copied := *userCard
+	copied.Sets = append([]Set{}, userCard.Sets...)
+	return &copied
+}
+
+// MemoryAPIKeyStore is an APIKeyStore keeping all API keys in memory, used by tests and when running without MongoDB
+type MemoryAPIKeyStore struct {
+	mutex   sync.RWMutex
+	apiKeys []*APIKey
+}
+
+// NewMemoryAPIKeyStore creates a MemoryAPIKeyStore containing the given API keys
+func NewMemoryAPIKeyStore(apiKeys ...*APIKey) *MemoryAPIKeyStore {
+	store := &MemoryAPIKeyStore{}
+	for _, apiKey := range apiKeys {
+		store.Create(context.Background(), apiKey)
+	}
+	return store
+}
+
+// GetAPIKeysByUserID returns copies of all keys of the user
+func (store *MemoryAPIKeyStore) GetAPIKeysByUserID(ctx context.Context, userID string) ([]*APIKey, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	apiKeys := []*APIKey{}
+	for _, apiKey := range store.apiKeys {
+		if apiKey.UserID == userID {
+			apiKeys = append(apiKeys, copyAPIKey(apiKey))
+		}
+	}
+	return apiKeys, nil
+}
+
+// GetAPIKeyByHash returns the key with the hash or nil if there is none
+func (store *MemoryAPIKeyStore) GetAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, apiKey := range store.apiKeys {
+		if apiKey.Hash == hash {
+			return copyAPIKey(apiKey), nil
+		}
+	}
+	return nil, nil
+}
+
+// Create adds an API key
+func (store *MemoryAPIKeyStore) Create(ctx context.Context, apiKey *APIKey) (primitive.ObjectID, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	apiKey.ID = primitive.NewObjectID()
+	store.apiKeys = append(store.apiKeys, copyAPIKey(apiKey))
+	return apiKey.ID, nil
+}
+
+// Revoke marks the key of the user as revoked, it returns false if the user has no such key
+func (store *MemoryAPIKeyStore) Revoke(ctx context.Context, userID string, id primitive.ObjectID) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, apiKey := range store.apiKeys {
+		if apiKey.ID == id && apiKey.UserID == userID && apiKey.RevokedAt == nil {
+			revokedAt := time.Now()
+			apiKey.RevokedAt = &revokedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// copyAPIKey copies the API key, so callers can't modify the stored one
+func copyAPIKey(apiKey *APIKey) *APIKey {
+	copied := *apiKey
+	copied.Scopes = append([]Scope(nil), apiKey.Scopes...)
+	return &copied
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserStore is the storage of the users used by the handlers
+type UserStore interface {
+	GetAllUsers(ctx context.Context) ([]*User, error)
+	GetUserByUserID(ctx context.Context, userID string) (*User, error)
+	GetUserByUserName(ctx context.Context, userName string) (*User, error)
+	Create(ctx context.Context, user *User) (primitive.ObjectID, error)
+	Update(ctx context.Context, user *User) (*User, error)
+}
+
+// UserCardStore is the storage of the collected cards of the users used by the handlers
+type UserCardStore interface {
+	GetAllUserCards(ctx context.Context) ([]*UserCard, error)
+	GetUserCardsByUserID(ctx context.Context, userID string) ([]*UserCard, error)
+	ReplaceAllOfUser(ctx context.Context, userID string, userCards []*UserCard) error
+}
+
+// APIKeyStore is the storage of the API keys of the users
+type APIKeyStore interface {
+	GetAPIKeysByUserID(ctx context.Context, userID string) ([]*APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+	Create(ctx context.Context, apiKey *APIKey) (primitive.ObjectID, error)
+	Revoke(ctx context.Context, userID string, id primitive.ObjectID) (bool, error)
+}
+
+var userStore UserStore
+var userCardStore UserCardStore
+var apiKeyStore APIKeyStore
+
+// GetUserStore returns the UserStore configured at startup with UseUserStore
+func GetUserStore() UserStore {
+	return userStore
+}
+
+// UseUserStore sets the UserStore returned by GetUserStore, e.g. a UserCollection or a MemoryUserStore
+func UseUserStore(store UserStore) {
+	userStore = store
+}
+
+// GetUserCardStore returns the UserCardStore configured at startup with UseUserCardStore
+func GetUserCardStore() UserCardStore {
+	return userCardStore
+}
+
+// UseUserCardStore sets the UserCardStore returned by GetUserCardStore, e.g. a UserCardCollection or a MemoryUserCardStore
+func UseUserCardStore(store UserCardStore) {
+	userCardStore = store
+}
+
+// GetAPIKeyStore returns the APIKeyStore configured at startup with UseAPIKeyStore
+func GetAPIKeyStore() APIKeyStore {
+	return apiKeyStore
+}
+
+// UseAPIKeyStore sets the APIKeyStore returned by GetAPIKeyStore, e.g. an APIKeyCollection or a MemoryAPIKeyStore
+func UseAPIKeyStore(store APIKeyStore) {
+	apiKeyStore = store
+}
@@ -20,51 +20,67 @@ type Set struct {
 }
 
 type UserCard struct {
-	ID     string `bson:"_id" json:"-"`
-	UserID string `bson:"user_id" json:"-"`
-	Name   string `bson:"name" json:"name"`
-	Sets   []Set  `bson:"sets" json:"sets"`
+	ID       string `bson:"_id" json:"-"`
+	UserID   string `bson:"user_id" json:"-"`
+	Name     string `bson:"name" json:"name"`
+	OracleID string `bson:"oracle_id,omitempty" json:"oracleId,omitempty"`
+	Sets     []Set  `bson:"sets" json:"sets"`
 }
 
 // UserCardCollection ...
 type UserCardCollection struct {
-	*mongo.Client
 	*mongo.Collection
-	context.Context
-	context.CancelFunc
 }
 
-// GetUserCardCollection ...
-func GetUserCardCollection() (UserCardCollection, error) {
-	client, ctx, cancel := db.GetConnection()
-	db := client.Database(db.GetDatabaseName())
-	collection := db.Collection("user_cards")
+// NewUserCardCollection creates the UserCardCollection using the shared client
+func NewUserCardCollection(client *mongo.Client) *UserCardCollection {
+	return &UserCardCollection{
+		Collection: client.Database(db.GetDatabaseName()).Collection("user_cards"),
+	}
+}
 
+// CreateUserCardUserIDIndex creates the index used to find the cards of a user
+func CreateUserCardUserIDIndex(ctx context.Context, database *mongo.Database) error {
 	model := mongo.IndexModel{
 		Keys: bson.M{
 			"user_id": 1,
 		}, Options: nil,
 	}
-	_, err := collection.Indexes().CreateOne(ctx, model)
-
-	return UserCardCollection{
-		Client:     client,
-		Collection: collection,
-		Context:    ctx,
-		CancelFunc: cancel,
-	}, err
+	_, err := database.Collection("user_cards").Indexes().CreateOne(ctx, model)
+	return err
 }
 
-// Disconnect ...
-func (collection *UserCardCollection) Disconnect() {
-	collection.CancelFunc()
-	collection.Client.Disconnect(collection.Context)
+// BackfillUserCardOracleIDs sets the oracle IDs of the user cards stored without them, oracleIDs returns them by card name
+func BackfillUserCardOracleIDs(ctx context.Context, database *mongo.Database, oracleIDs func(ctx context.Context, names []string) (map[string]string, error)) error {
+	collection := database.Collection("user_cards")
+	withoutOracleID := bson.M{"$or": bson.A{bson.M{"oracle_id": bson.M{"$exists": false}}, bson.M{"oracle_id": ""}}}
+	values, err := collection.Distinct(ctx, "name", withoutOracleID)
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for _, value := range values {
+		if name, ok := value.(string); ok {
+			names = append(names, name)
+		}
+	}
+
+	ids, err := oracleIDs(ctx, names)
+	if err != nil {
+		return err
+	}
+	for name, oracleID := range ids {
+		_, err = collection.UpdateMany(ctx, bson.M{"$and": bson.A{bson.M{"name": name}, withoutOracleID}}, bson.M{"$set": bson.M{"oracle_id": oracleID}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // GetAllUserCards Retrives all usercards from the db
-func (collection *UserCardCollection) GetAllUserCards() ([]*UserCard, error) {
+func (collection *UserCardCollection) GetAllUserCards(ctx context.Context) ([]*UserCard, error) {
 	var usercards []*UserCard = []*UserCard{}
-	ctx := collection.Context
 
 	cursor, err := collection.Collection.Find(ctx, bson.D{})
 	if err != nil {
@@ -80,8 +96,7 @@ func (collection *UserCardCollection) GetAllUserCards() ([]*UserCard, error) {
 }
 
 // GetUserCardsByUserID retrieves usercards for the user from the db
-func (collection *UserCardCollection) GetUserCardsByUserID(userID string) ([]*UserCard, error) {
-	ctx := collection.Context
+func (collection *UserCardCollection) GetUserCardsByUserID(ctx context.Context, userID string) ([]*UserCard, error) {
 	var usercards []*UserCard = []*UserCard{}
 	cursor, err := collection.Collection.Find(ctx, bson.D{bson.E{Key: "user_id", Value: userID}})
 	if err != nil {
@@ -98,8 +113,7 @@ func (collection *UserCardCollection) GetUserCardsByUserID(userID string) ([]*Us
 }
 
 // ReplaceAllOfUserAndSource first delete all for userId & source and then create many cards in a mongo db
-func (collection *UserCardCollection) ReplaceAllOfUserAndSourceNewBad(userID string, source string, userCards []*UserCard) error {
-	ctx := collection.Context
+func (collection *UserCardCollection) ReplaceAllOfUserAndSourceNewBad(ctx context.Context, userID string, source string, userCards []*UserCard) error {
 
 	models := []mongo.WriteModel{}
 	upsert := true
@@ -170,8 +184,7 @@ func (collection *UserCardCollection) ReplaceAllOfUserAndSourceNewBad(userID str
 }
 
 // ReplaceAllOfUser first delete all for userId & source and then create many cards in a mongo db
-func (collection *UserCardCollection) ReplaceAllOfUser(userID string, userCards []*UserCard) error {
-	ctx := collection.Context
+func (collection *UserCardCollection) ReplaceAllOfUser(ctx context.Context, userID string, userCards []*UserCard) error {
 
 	filter := bson.M{"user_id": bson.M{"$eq": userID}}
 
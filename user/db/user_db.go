@@ -11,51 +11,62 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Role grants a user access to endpoints
+type Role string
+
+const (
+	// UserRole is the role of every signed in user
+	UserRole Role = "user"
+	// AdminRole allows updating and transforming the cards and sets
+	AdminRole Role = "admin"
+)
+
 type User struct {
 	ID       primitive.ObjectID `bson:"_id" json:"-"`
 	UserID   string             `bson:"user_id" json:"userId"`
 	UserName string             `bson:"user_name" json:"userName"`
+	Roles    []Role             `bson:"roles,omitempty" json:"roles"`
+}
+
+// HasRole returns true if the user has the role, every user has the UserRole
+func (user *User) HasRole(role Role) bool {
+	if role == UserRole {
+		return true
+	}
+	for _, userRole := range user.Roles {
+		if userRole == role {
+			return true
+		}
+	}
+	return false
 }
 
 // UserCollection ...
 type UserCollection struct {
-	*mongo.Client
 	*mongo.Collection
-	context.Context
-	context.CancelFunc
 }
 
-// GetUserCollection ...
-func GetUserCollection() (UserCollection, error) {
-	client, ctx, cancel := db.GetConnection()
-	db := client.Database(db.GetDatabaseName())
-	collection := db.Collection("users")
+// NewUserCollection creates the UserCollection using the shared client
+func NewUserCollection(client *mongo.Client) *UserCollection {
+	return &UserCollection{
+		Collection: client.Database(db.GetDatabaseName()).Collection("users"),
+	}
+}
 
+// CreateUserIDIndex creates the index used to find a user by the ID
+func CreateUserIDIndex(ctx context.Context, database *mongo.Database) error {
 	model := mongo.IndexModel{
 		Keys: bson.M{
 			"user_id": 1,
 		}, Options: nil,
 	}
-	_, err := collection.Indexes().CreateOne(ctx, model)
-
-	return UserCollection{
-		Client:     client,
-		Collection: collection,
-		Context:    ctx,
-		CancelFunc: cancel,
-	}, err
-}
-
-// Disconnect ...
-func (collection *UserCollection) Disconnect() {
-	collection.CancelFunc()
-	collection.Client.Disconnect(collection.Context)
+	_, err := database.Collection("users").Indexes().CreateOne(ctx, model)
+	return err
 }
 
 // GetAllUsers Retrives all users from the db
-func (collection *UserCollection) GetAllUsers() ([]*User, error) {
+func (collection *UserCollection) GetAllUsers(ctx context.Context) ([]*User, error) {
 	var users []*User = []*User{}
-	ctx := collection.Context
 
 	cursor, err := collection.Collection.Find(ctx, bson.D{})
 	if err != nil {
@@ -71,9 +82,8 @@ func (collection *UserCollection) GetAllUsers() ([]*User, error) {
 }
 
 // GetUserByUserID retrives a user by its user id (aka email)
-func (collection *UserCollection) GetUserByUserID(userID string) (*User, error) {
+func (collection *UserCollection) GetUserByUserID(ctx context.Context, userID string) (*User, error) {
 	var user *User
-	ctx := collection.Context
 
 	result := collection.Collection.FindOne(ctx, bson.D{bson.E{Key: "user_id", Value: userID}})
 	if result == nil {
@@ -93,9 +103,8 @@ func (collection *UserCollection) GetUserByUserID(userID string) (*User, error)
 }
 
 // GetUserByUserID retrives a user by its user name
-func (collection *UserCollection) GetUserByUserName(userName string) (*User, error) {
+func (collection *UserCollection) GetUserByUserName(ctx context.Context, userName string) (*User, error) {
 	var user *User
-	ctx := collection.Context
 
 	result := collection.Collection.FindOne(ctx, bson.D{bson.E{Key: "user_name", Value: userName}})
 	if result == nil {
@@ -115,8 +124,7 @@ func (collection *UserCollection) GetUserByUserName(userName string) (*User, err
 }
 
 // Create creating a user in a mongo
-func (collection *UserCollection) Create(user *User) (primitive.ObjectID, error) {
-	ctx := collection.Context
+func (collection *UserCollection) Create(ctx context.Context, user *User) (primitive.ObjectID, error) {
 	user.ID = primitive.NewObjectID()
 
 	result, err := collection.Collection.InsertOne(ctx, user)
@@ -128,8 +136,7 @@ func (collection *UserCollection) Create(user *User) (primitive.ObjectID, error)
 	return oid, nil
 }
 
-func (collection *UserCollection) Update(user *User) (*User, error) {
-	ctx := collection.Context
+func (collection *UserCollection) Update(ctx context.Context, user *User) (*User, error) {
 	var updatedUser *User
 
 	update := bson.M{
@@ -9,12 +9,13 @@ import (
 
 	"github.com/dimchansky/utfbom"
 	"github.com/gin-gonic/gin"
+	cardDB "github.com/maedu/mtg-cards/card/db"
 	"github.com/maedu/mtg-cards/user/auth"
 	"github.com/maedu/mtg-cards/user/db"
 )
 
 func Setup(r *gin.Engine) {
-	r.POST("/api/user/cards/upload/:source", handleUploadCards)
+	r.POST("/api/user/cards/upload/:source", auth.RequireScope(db.WriteCollection), handleUploadCards)
 }
 
 func handleUploadCards(c *gin.Context) {
@@ -30,20 +31,16 @@ func handleUploadCards(c *gin.Context) {
 }
 
 func uploadCards(c *gin.Context, request ParseRequest) {
+	ctx := c.Request.Context()
 
 	parsedCards, err := parseUserCards(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
-	collection, err := db.GetUserCardCollection()
-	defer collection.Disconnect()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, err)
-		return
-	}
+	collection := db.GetUserCardStore()
 
-	existingCards, err := collection.GetUserCardsByUserID(request.UserID)
+	existingCards, err := collection.GetUserCardsByUserID(ctx, request.UserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return
@@ -88,11 +85,25 @@ func uploadCards(c *gin.Context, request ParseRequest) {
 	}
 
 	cards := []*db.UserCard{}
+	names := []string{}
 	for _, card := range cardMap {
 		cards = append(cards, card)
+		names = append(names, card.Name)
+	}
+
+	// The oracle IDs identify the cards, even if they are renamed
+	oracleIDs, err := cardDB.OracleIDsByName(ctx, cardDB.GetCardStore(), names)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+	for _, card := range cards {
+		if oracleID, ok := oracleIDs[card.Name]; ok {
+			card.OracleID = oracleID
+		}
 	}
 
-	err = collection.ReplaceAllOfUser(request.UserID, cards)
+	err = collection.ReplaceAllOfUser(ctx, request.UserID, cards)
 	if err != nil {
 		fmt.Printf("Error while storing: %v", err)
 		c.JSON(http.StatusInternalServerError, err)